@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// annotateCmd inserts depup comments above version lines to bootstrap depup in existing repositories
+var annotateCmd = &cobra.Command{
+	Use:   "annotate DIR",
+	Short: "Insert depup comments for version lines selected by key or location",
+	Long: `Insert a depup comment on its own line next to every version line selected by a mapping, using the
+indentation of the line and the comment style of the file format. Versions are never changed, and lines
+that are already annotated are left alone.
+
+A mapping selects lines either by key, e.g. image=nginx annotates all lines like "image: nginx:1.25.0",
+or by location, e.g. deploy.yaml:12=nginx annotates line 12 of deploy.yaml. Files are matched by their
+path relative to DIR or their base name, globs are supported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Merge settings like the update command: flags > environment > configuration file
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		commentPosition, _ := cmd.Flags().GetString("comment-position")
+		rawMappings, _ := cmd.Flags().GetStringArray("map")
+
+		if commentPosition != updater.CommentPositionAbove && commentPosition != updater.CommentPositionBelow {
+			return fmt.Errorf("invalid --comment-position value %q: expected %q or %q", commentPosition, updater.CommentPositionAbove, updater.CommentPositionBelow)
+		}
+
+		rules, err := parseAnnotationRules(rawMappings)
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return fmt.Errorf("no mappings given, add one with --map KEY=PACKAGE or --map FILE:LINE=PACKAGE")
+		}
+
+		u := updater.NewUpdater(
+			updater.WithDryRun(dryRun),
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+			updater.WithCommentPosition(commentPosition),
+		)
+
+		annotations, err := u.Annotate(args[0], rules)
+		for _, annotation := range annotations {
+			verb := "Annotated"
+			if dryRun {
+				verb = "Would annotate"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s:%d %s %s\n", verb, displayFile(annotation.File), annotation.Line, annotation.Package, annotation.Version)
+		}
+
+		return err
+	},
+}
+
+func init() {
+	// Register the annotate command as a subcommand of the root command
+	rootCmd.AddCommand(annotateCmd)
+
+	// Flag to select the version lines to annotate
+	annotateCmd.Flags().StringArrayP("map", "m", []string{}, "Annotate version lines with a package, by key (-m image=nginx) or by location (-m deploy.yaml:12=nginx)")
+
+	// Flag to show the annotations without writing them
+	annotateCmd.Flags().BoolP("dry-run", "d", false, "Show which lines would be annotated without changing files")
+
+	// Flags affecting which files are processed, mirroring the update command
+	annotateCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	annotateCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	annotateCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+
+	// Flag to choose where the comments are inserted
+	annotateCmd.Flags().String("comment-position", updater.CommentPositionAbove, "Insert depup comments \"above\" or \"below\" the version they annotate")
+}
+
+// parseAnnotationRules parses mappings in the format KEY=PACKAGE or FILE:LINE=PACKAGE
+func parseAnnotationRules(rawMappings []string) ([]updater.AnnotationRule, error) {
+	rules := make([]updater.AnnotationRule, 0, len(rawMappings))
+	for _, rawMapping := range rawMappings {
+		selector, packageName, ok := strings.Cut(rawMapping, "=")
+		if !ok || selector == "" || packageName == "" {
+			return nil, fmt.Errorf("invalid mapping %q: expected KEY=PACKAGE or FILE:LINE=PACKAGE", rawMapping)
+		}
+
+		// A selector ending in :NUMBER is a location, anything else is a key
+		if file, rawLine, isLocation := strings.Cut(selector, ":"); isLocation {
+			line, err := strconv.Atoi(rawLine)
+			if err != nil || line < 1 || file == "" {
+				return nil, fmt.Errorf("invalid mapping %q: expected a line number after %q", rawMapping, file+":")
+			}
+			rules = append(rules, updater.AnnotationRule{Package: packageName, File: file, Line: line})
+			continue
+		}
+
+		rules = append(rules, updater.AnnotationRule{Package: packageName, Key: selector})
+	}
+
+	return rules, nil
+}
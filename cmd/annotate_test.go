@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dtomasi/depup/internal/updater"
+)
+
+func TestParseAnnotationRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []string
+		expected    []updater.AnnotationRule
+		expectError bool
+	}{
+		{name: "key", input: []string{"image=nginx"}, expected: []updater.AnnotationRule{{Package: "nginx", Key: "image"}}},
+		{name: "location", input: []string{"infra/main.tf:12=aws"}, expected: []updater.AnnotationRule{{Package: "aws", File: "infra/main.tf", Line: 12}}},
+		{name: "missing package", input: []string{"image="}, expectError: true},
+		{name: "missing separator", input: []string{"image"}, expectError: true},
+		{name: "invalid line", input: []string{"deploy.yaml:top=nginx"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseAnnotationRules(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseAnnotationRules() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(rules, tt.expected) {
+				t.Errorf("parseAnnotationRules() = %+v, expected %+v", rules, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnnotateCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"deploy.yaml":   "spec:\n  image: nginx:1.25.0\n",
+		"infra/main.tf": "provider \"aws\" {\n  version = \"4.0.0\"\n}\n",
+	})
+	t.Chdir(tempDir)
+
+	output, err := executeCommand(t, "annotate", ".", "-r", "-e", ".yaml", "-e", ".tf", "-m", "image=nginx", "-m", "infra/main.tf:2=aws")
+	if err != nil {
+		t.Fatalf("annotate unexpected error: %v", err)
+	}
+
+	expectedOutput := "Annotated deploy.yaml:3 nginx 1.25.0\nAnnotated infra/main.tf:3 aws 4.0.0\n"
+	if output != expectedOutput {
+		t.Errorf("annotate output = %q, expected %q", output, expectedOutput)
+	}
+
+	for file, expected := range map[string]string{
+		"deploy.yaml":   "spec:\n  # depup package=nginx\n  image: nginx:1.25.0\n",
+		"infra/main.tf": "provider \"aws\" {\n  # depup package=aws\n  version = \"4.0.0\"\n}\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(tempDir, file))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, expected %q", file, string(content), expected)
+		}
+	}
+
+	// Running it again doesn't add a second comment
+	if output, err := executeCommand(t, "annotate", ".", "-r", "-e", ".yaml", "-m", "image=nginx"); err != nil || output != "" {
+		t.Errorf("annotate again = %q, %v, expected no annotations", output, err)
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// bumpCmd increments the annotated versions of packages instead of updating them to a given version
+var bumpCmd = &cobra.Command{
+	Use:   "bump DIR",
+	Short: "Increment the major, minor or patch part of annotated versions",
+	Long: `Read the current version of each --package from its annotated lines, increment the --part and
+write the new version back. Lower parts are reset and pre-release and build metadata are dropped, so a
+patch bump turns 1.2.3-rc.1 into 1.2.4. All annotations of a package must hold the same version.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		names, _ := cmd.Flags().GetStringArray("package")
+		part, _ := cmd.Flags().GetString("part")
+
+		if len(names) == 0 {
+			return fmt.Errorf("no packages to bump, pass them with --package")
+		}
+		if part != updater.BumpMajor && part != updater.BumpMinor && part != updater.BumpPatch {
+			return fmt.Errorf("invalid --part value %q: expected %q, %q or %q", part, updater.BumpMajor, updater.BumpMinor, updater.BumpPatch)
+		}
+
+		u := updater.NewUpdater(
+			updater.WithDryRun(dryRun),
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+			updater.WithOutput(cmd.OutOrStdout()),
+			updater.WithWarnings(cmd.ErrOrStderr()),
+		)
+
+		result, err := u.Scan(args[0])
+		if err != nil {
+			return err
+		}
+
+		packages := make([]updater.Package, 0, len(names))
+		for _, name := range names {
+			current, err := currentVersion(result.Annotations, name)
+			if err != nil {
+				return err
+			}
+			version, err := updater.BumpVersion(current, part)
+			if err != nil {
+				return fmt.Errorf("package %s: %w", name, err)
+			}
+			packages = append(packages, updater.Package{Name: name, Version: version, Source: updater.SourceFlag})
+		}
+
+		return u.UpdateContext(cmd.Context(), args[0], packages)
+	},
+}
+
+// currentVersion returns the version annotated for the package
+// Bumping is ambiguous if the package isn't annotated with a version or with differing versions
+func currentVersion(annotations []updater.Annotation, name string) (string, error) {
+	var versions []string
+	for _, annotation := range annotations {
+		if annotation.Package == name && !annotation.Ignored() && !slices.Contains(versions, annotation.Version) {
+			versions = append(versions, annotation.Version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return "", fmt.Errorf("package %s has no annotated version to bump", name)
+	case 1:
+		return versions[0], nil
+	default:
+		return "", fmt.Errorf("package %s is annotated with differing versions %v, update it with an explicit version instead", name, versions)
+	}
+}
+
+func init() {
+	// Register the bump command as a subcommand of the root command
+	rootCmd.AddCommand(bumpCmd)
+
+	// Flags selecting what to bump
+	bumpCmd.Flags().StringArrayP("package", "p", []string{}, "Name of a package to bump, may be repeated")
+	bumpCmd.Flags().String("part", updater.BumpPatch, "Part of the version to increment: \"major\", \"minor\" or \"patch\"")
+	bumpCmd.Flags().BoolP("dry-run", "d", false, "Show what would be updated without making changes")
+
+	// Flags affecting which files are processed, mirroring the update command
+	bumpCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	bumpCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	bumpCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+}
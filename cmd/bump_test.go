@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBumpCmd(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		args        []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "patch by default",
+			content:  "# depup package=app\nversion: 1.2.3\n",
+			expected: "# depup package=app\nversion: 1.2.4\n",
+		},
+		{
+			name:     "minor",
+			content:  "# depup package=app\nversion: 1.2.3\n",
+			args:     []string{"--part", "minor"},
+			expected: "# depup package=app\nversion: 1.3.0\n",
+		},
+		{
+			name:     "major keeps the v prefix",
+			content:  "# depup package=app\nimage: app:v1.2.3\n",
+			args:     []string{"--part", "major"},
+			expected: "# depup package=app\nimage: app:v2.0.0\n",
+		},
+		{
+			name:     "pre-release is cleared",
+			content:  "# depup package=app\nversion: 1.2.3-rc.1\n",
+			expected: "# depup package=app\nversion: 1.2.4\n",
+		},
+		{
+			name:     "dry run",
+			content:  "# depup package=app\nversion: 1.2.3\n",
+			args:     []string{"--dry-run"},
+			expected: "# depup package=app\nversion: 1.2.3\n",
+		},
+		{
+			name:        "differing versions",
+			content:     "# depup package=app\nversion: 1.2.3\n# depup package=app\nother: 1.3.0\n",
+			expectError: true,
+			expected:    "# depup package=app\nversion: 1.2.3\n# depup package=app\nother: 1.3.0\n",
+		},
+		{
+			name:        "package not annotated",
+			content:     "# depup package=db\nversion: 1.2.3\n",
+			expectError: true,
+			expected:    "# depup package=db\nversion: 1.2.3\n",
+		},
+		{
+			name:        "invalid part",
+			content:     "# depup package=app\nversion: 1.2.3\n",
+			args:        []string{"--part", "build"},
+			expectError: true,
+			expected:    "# depup package=app\nversion: 1.2.3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": tt.content})
+
+			args := append([]string{"bump", tempDir, "--package", "app"}, tt.args...)
+			_, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("bump error = %v, expectError %v", err, tt.expectError)
+			}
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+			if err != nil {
+				t.Fatalf("failed to read app.yaml: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("app.yaml = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
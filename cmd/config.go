@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/dtomasi/depup/internal/config"
+	"github.com/dtomasi/depup/internal/resolver"
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configCmd groups the subcommands dealing with the configuration file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the " + config.FileName + " configuration file",
+}
+
+// configSchemaCmd prints the JSON Schema of the configuration file
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration file",
+	Long: `Print the JSON Schema describing ` + config.FileName + `, so editors and CI validators
+can check the configuration file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		compactJSON, _, err := jsonFormat(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		schema, err := config.Schema()
+		if err != nil {
+			return err
+		}
+		if compactJSON {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, schema); err != nil {
+				return err
+			}
+			schema = compacted.Bytes()
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(schema))
+
+		return nil
+	},
+}
+
+// configGenerateCmd bootstraps a configuration file from the annotations of a directory
+var configGenerateCmd = &cobra.Command{
+	Use:   "generate DIR",
+	Short: "Generate a configuration file from the annotated packages of a directory",
+	Long: `Scan DIR for depup comments and print a configuration file listing every annotated package,
+ready to be filled with versions. With --with-versions, the highest version annotated for a package
+is filled in. The scan settings (--recursive, --extension, --exclude) are kept in the file, so updates
+with it process the same files.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		withVersions, _ := cmd.Flags().GetBool("with-versions")
+		output, _ := cmd.Flags().GetString("output")
+		force, _ := cmd.Flags().GetBool("force")
+
+		extensions := resolveExtensions(rawExtensions, defaultExtensions)
+		u := updater.NewUpdater(
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(extensions),
+			updater.WithExcludes(excludes),
+		)
+
+		result, err := u.Scan(args[0])
+		if err != nil {
+			return err
+		}
+
+		content, err := config.Marshal(generateConfig(result.Annotations, recursive, extensions, excludes, withVersions))
+		if err != nil {
+			return err
+		}
+
+		if output == "" {
+			_, err = cmd.OutOrStdout().Write(content)
+			return err
+		}
+
+		// Never replace an existing configuration by accident
+		if _, err := os.Stat(output); err == nil && !force {
+			return fmt.Errorf("config %s already exists, pass --force to overwrite it", output)
+		}
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fmt.Errorf("cannot write config %s: %w", output, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s with %d packages\n", output, len(generateConfigPackageNames(result.Annotations)))
+
+		return nil
+	},
+}
+
+func init() {
+	// Register the config command and its subcommands
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configGenerateCmd)
+
+	// Flags to print the schema on a single line or indented, which is the default
+	addJSONFormatFlags(configSchemaCmd.Flags())
+
+	// Flags affecting which files are scanned, mirroring the update command
+	configGenerateCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	configGenerateCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	configGenerateCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+
+	// Flags controlling the generated file
+	configGenerateCmd.Flags().Bool("with-versions", false, "Fill in the highest version annotated for each package instead of leaving versions blank")
+	configGenerateCmd.Flags().StringP("output", "o", "", "Write the configuration to this file instead of stdout, e.g. "+config.FileName)
+	configGenerateCmd.Flags().Bool("force", false, "Overwrite the --output file if it exists")
+}
+
+// generateConfig returns a configuration listing the annotated packages sorted by name
+// Scan settings that differ from the defaults are kept, so updates with the configuration process the same files
+func generateConfig(annotations []updater.Annotation, recursive bool, extensions, excludes []string, withVersions bool) *config.Config {
+	cfg := &config.Config{Excludes: excludes}
+	if recursive {
+		cfg.Recursive = &recursive
+	}
+	if !slices.Equal(extensions, defaultExtensions) {
+		cfg.Extensions = extensions
+	}
+
+	versions := map[string]string{}
+	for _, annotation := range annotations {
+		if current, ok := versions[annotation.Package]; !ok || annotation.Version != "" && (current == "" || updater.CompareVersions(annotation.Version, current) > 0) {
+			versions[annotation.Package] = annotation.Version
+		}
+	}
+
+	for _, name := range generateConfigPackageNames(annotations) {
+		pkg := config.Package{Name: name}
+		if withVersions {
+			pkg.Version = versions[name]
+		}
+		cfg.Packages = append(cfg.Packages, pkg)
+	}
+
+	return cfg
+}
+
+// generateConfigPackageNames returns the sorted and unique package names of the annotations
+func generateConfigPackageNames(annotations []updater.Annotation) []string {
+	var names []string
+	for _, annotation := range annotations {
+		if !slices.Contains(names, annotation.Package) {
+			names = append(names, annotation.Package)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// loadConfig loads the configuration file passed with --config or found in the working directory
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	return config.Find(path)
+}
+
+// applyConfigDefaults seeds flags that weren't passed explicitly from the configuration file
+// Environment variables are applied afterwards and therefore take precedence over the file
+func applyConfigDefaults(flags *pflag.FlagSet, cfg *config.Config) error {
+	values := map[string][]string{}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			values[name] = []string{strconv.FormatBool(*value)}
+		}
+	}
+	setString := func(name string, value string) {
+		if value != "" {
+			values[name] = []string{value}
+		}
+	}
+
+	setBool("dry-run", cfg.DryRun)
+	setBool("recursive", cfg.Recursive)
+	setBool("relative-paths", cfg.RelativePaths)
+	setBool("respect-editorconfig", cfg.RespectEditorConfig)
+	setBool("force-write", cfg.ForceWrite)
+	setBool("dereference-config-packages", cfg.Dereference)
+	setString("on-unparseable", cfg.OnUnparseable)
+	setString("group-by", cfg.GroupBy)
+	setString("quote-style", cfg.QuoteStyle)
+	setString("scheme", cfg.Scheme)
+	setString("update-mode", cfg.UpdateMode)
+	setString("comment-position", cfg.CommentPosition)
+	setString("comment-regex", cfg.CommentRegex)
+	setString("timeout", cfg.Timeout)
+	setString("max-file-size", cfg.MaxFileSize)
+	setString("file-mode", cfg.FileMode)
+	setBool("no-write-on-partial-failure", cfg.Transactional)
+	setBool("verify", cfg.Verify)
+	setString("cache-dir", cfg.CacheDir)
+	setString("cache-ttl", cfg.CacheTTL)
+	setString("registry-auth", cfg.RegistryAuth)
+	setString("report-format", cfg.ReportFormat)
+	setString("sarif-level", cfg.SARIFLevel)
+	setBool("show-version-source", cfg.ShowVersionSource)
+	setBool("json-compact", cfg.JSONCompact)
+	setBool("canonical-versions", cfg.CanonicalVersions)
+	setBool("allow-leading-zeros", cfg.AllowLeadingZeros)
+	setBool("version-prefix-auto", cfg.VersionPrefixAuto)
+	setBool("sync-comment-version", cfg.SyncCommentVersion)
+	setBool("nested-versions", cfg.NestedVersions)
+	setBool("error-on-multiple-matches", cfg.ErrorOnMultipleMatches)
+	setBool("quarantine-on-conflict", cfg.QuarantineOnConflict)
+	setBool("treat-warnings-as-errors", cfg.WarningsAsErrors)
+	setBool("report-only-errors", cfg.ReportOnlyErrors)
+	setBool("strict-semver", cfg.StrictSemver)
+	if len(cfg.Extensions) > 0 {
+		values["extension"] = cfg.Extensions
+	}
+	if len(cfg.Excludes) > 0 {
+		values["exclude"] = cfg.Excludes
+	}
+	if len(cfg.CommentPrefixes) > 0 {
+		values["comment-prefix"] = cfg.CommentPrefixes
+	}
+
+	for name, value := range values {
+		if err := setFlagDefault(flags, name, value); err != nil {
+			return fmt.Errorf("invalid value %v for %s in config: %w", value, name, err)
+		}
+	}
+
+	return nil
+}
+
+// effectiveConfig returns the configuration resolved from flags, environment variables and the configuration file
+// It mirrors applyConfigDefaults, so every setting holds its final value. Packages passed with --package come first,
+// followed by the configured packages that weren't passed, which aren't resolved from their datasource.
+func effectiveConfig(flags *pflag.FlagSet, cfg *config.Config, packages []updater.Package) *config.Config {
+	getBool := func(name string) *bool {
+		value, _ := flags.GetBool(name)
+		return &value
+	}
+	getString := func(name string) string {
+		return flags.Lookup(name).Value.String()
+	}
+	// An explicit --json-pretty overrides json_compact of the configuration file
+	compactJSON, _, _ := jsonFormat(flags)
+	rawExtensions, _ := flags.GetStringArray("extension")
+	excludes, _ := flags.GetStringArray("exclude")
+	commentPrefixes, _ := flags.GetStringArray("comment-prefix")
+
+	effective := &config.Config{
+		DryRun:                 getBool("dry-run"),
+		Recursive:              getBool("recursive"),
+		Extensions:             resolveExtensions(rawExtensions, defaultExtensions),
+		Excludes:               excludes,
+		RelativePaths:          getBool("relative-paths"),
+		RespectEditorConfig:    getBool("respect-editorconfig"),
+		GroupBy:                getString("group-by"),
+		QuoteStyle:             getString("quote-style"),
+		ForceWrite:             getBool("force-write"),
+		Scheme:                 getString("scheme"),
+		UpdateMode:             getString("update-mode"),
+		CommentPosition:        getString("comment-position"),
+		CommentPrefixes:        commentPrefixes,
+		CommentRegex:           getString("comment-regex"),
+		ReportFormat:           getString("report-format"),
+		SARIFLevel:             getString("sarif-level"),
+		JSONCompact:            &compactJSON,
+		ShowVersionSource:      getBool("show-version-source"),
+		CanonicalVersions:      getBool("canonical-versions"),
+		AllowLeadingZeros:      getBool("allow-leading-zeros"),
+		VersionPrefixAuto:      getBool("version-prefix-auto"),
+		SyncCommentVersion:     getBool("sync-comment-version"),
+		NestedVersions:         getBool("nested-versions"),
+		ErrorOnMultipleMatches: getBool("error-on-multiple-matches"),
+		QuarantineOnConflict:   getBool("quarantine-on-conflict"),
+		WarningsAsErrors:       getBool("treat-warnings-as-errors"),
+		ReportOnlyErrors:       getBool("report-only-errors"),
+		StrictSemver:           getBool("strict-semver"),
+		Transactional:          getBool("no-write-on-partial-failure"),
+		Verify:                 getBool("verify"),
+		MaxFileSize:            getString("max-file-size"),
+		FileMode:               getString("file-mode"),
+		Timeout:                getString("timeout"),
+		CacheDir:               getString("cache-dir"),
+		CacheTTL:               getString("cache-ttl"),
+		RegistryAuth:           getString("registry-auth"),
+		Dereference:            getBool("dereference-config-packages"),
+		OnUnparseable:          getString("on-unparseable"),
+	}
+
+	passed := map[string]bool{}
+	for _, pkg := range packages {
+		passed[pkg.Name] = true
+		effective.Packages = append(effective.Packages, config.Package{Name: pkg.Name, Version: pkg.Version, File: pkg.File})
+	}
+	for _, pkg := range cfg.Packages {
+		if !passed[pkg.Name] {
+			effective.Packages = append(effective.Packages, pkg)
+		}
+	}
+
+	return effective
+}
+
+// dereferenceConfigPackages resolves the version of configured packages that name a datasource instead of a version
+// Without dereference, such packages are rejected so nothing is resolved unexpectedly
+func dereferenceConfigPackages(ctx context.Context, cfg *config.Config, versionResolver resolver.VersionResolver, dereference bool) error {
+	for i, pkg := range cfg.Packages {
+		if pkg.Version != "" || pkg.Datasource == "" {
+			continue
+		}
+		if !dereference {
+			return fmt.Errorf("package %s in config has no version, pass --dereference-config-packages to resolve it from datasource %q", pkg.Name, pkg.Datasource)
+		}
+
+		version, err := versionResolver.Resolve(ctx, resolver.Request{Package: pkg.Name, Datasource: pkg.Datasource, Options: pkg.Options})
+		if err != nil {
+			return err
+		}
+		cfg.Packages[i].Version = version
+		cfg.Packages[i].Resolved = true
+	}
+
+	return nil
+}
+
+// Values of --on-unparseable
+const (
+	onUnparseableSkip  = "skip"  // Drop the package with a warning
+	onUnparseableError = "error" // Fail the run
+)
+
+// checkResolvedVersions handles configured packages a datasource resolved to a version depup can't write,
+// e.g. the PEP 440 version 1.2.3.post1. Such packages are dropped with a warning or fail the run.
+func checkResolvedVersions(warnings io.Writer, cfg *config.Config, onUnparseable string) error {
+	packages := cfg.Packages[:0]
+	for _, pkg := range cfg.Packages {
+		if !pkg.Resolved || updater.IsVersion(pkg.Version) {
+			packages = append(packages, pkg)
+			continue
+		}
+		if onUnparseable == onUnparseableError {
+			return fmt.Errorf("package %s resolved to unparseable version %q from datasource %q", pkg.Name, pkg.Version, pkg.Datasource)
+		}
+		fmt.Fprintf(warnings, "Warning: skipped package %s: datasource %q returned unparseable version %q\n", pkg.Name, pkg.Datasource, pkg.Version)
+	}
+	cfg.Packages = packages
+
+	return nil
+}
+
+// mergeConfigPackages appends the configured packages that weren't passed on the command line
+func mergeConfigPackages(packages []updater.Package, cfg *config.Config) []updater.Package {
+	passed := map[string]bool{}
+	for _, pkg := range packages {
+		passed[pkg.Name] = true
+	}
+
+	for _, pkg := range cfg.Packages {
+		if passed[pkg.Name] {
+			continue
+		}
+
+		source := updater.SourceConfig
+		if pkg.Resolved {
+			source = updater.SourceResolver + pkg.Datasource
+		}
+		packages = append(packages, updater.Package{Name: pkg.Name, Version: pkg.Version, File: pkg.File, Source: source})
+	}
+
+	return packages
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dtomasi/depup/internal/config"
+	"github.com/dtomasi/depup/internal/resolver"
+)
+
+func TestConfigSchemaCmd(t *testing.T) {
+	output, err := executeCommand(t, "config", "schema")
+	if err != nil {
+		t.Fatalf("config schema unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(output), &schema); err != nil {
+		t.Fatalf("config schema emitted invalid JSON: %v", err)
+	}
+
+	for _, property := range []string{"dry_run", "recursive", "extensions", "packages"} {
+		if _, ok := schema.Properties[property]; !ok {
+			t.Errorf("config schema is missing property %q", property)
+		}
+	}
+}
+
+func TestConfigSchemaCmd_JSONFormat(t *testing.T) {
+	indented, err := executeCommand(t, "config", "schema")
+	if err != nil {
+		t.Fatalf("config schema unexpected error: %v", err)
+	}
+	if !strings.Contains(indented, "\n  ") {
+		t.Errorf("config schema output is not indented by default:\n%s", indented)
+	}
+
+	compact, err := executeCommand(t, "config", "schema", "--json-compact")
+	if err != nil {
+		t.Fatalf("config schema --json-compact unexpected error: %v", err)
+	}
+	if strings.Count(compact, "\n") != 1 || !json.Valid([]byte(compact)) {
+		t.Errorf("config schema --json-compact output is not a single line of JSON: %q", compact)
+	}
+}
+
+func TestUpdateCmd_Config(t *testing.T) {
+	const original = "# depup package=app\nversion: 1.0.0\n"
+	const updated = "# depup package=app\nversion: 2.0.0\n"
+
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		args     []string
+		expected map[string]string
+	}{
+		{
+			name:   "packages and recursion from config",
+			config: "recursive: true\npackages:\n  - name: app\n    version: 2.0.0\n",
+			expected: map[string]string{
+				"app.yaml":        updated,
+				"nested/app.yaml": updated,
+			},
+		},
+		{
+			name:   "environment takes precedence over config",
+			config: "recursive: true\npackages:\n  - name: app\n    version: 2.0.0\n",
+			env:    map[string]string{"DEPUP_RECURSIVE": "false"},
+			expected: map[string]string{
+				"app.yaml":        updated,
+				"nested/app.yaml": original,
+			},
+		},
+		{
+			name:   "flags take precedence over config",
+			config: "dry_run: true\npackages:\n  - name: app\n    version: 3.0.0\n",
+			args:   []string{"--dry-run=false", "-p", "app=2.0.0"},
+			expected: map[string]string{
+				"app.yaml":        updated,
+				"nested/app.yaml": original,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{
+				"app.yaml":        original,
+				"nested/app.yaml": original,
+			})
+
+			configPath := filepath.Join(t.TempDir(), "depup.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			args := append([]string{"update", tempDir, "--config", configPath}, tt.args...)
+			if _, err := executeCommand(t, args...); err != nil {
+				t.Fatalf("update unexpected error: %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(content) != expected {
+					t.Errorf("%s = %q, expected %q", name, string(content), expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDereferenceConfigPackages(t *testing.T) {
+	registry := resolver.NewRegistry()
+	registry.Register("mock", resolver.VersionResolverFunc(func(ctx context.Context, request resolver.Request) (string, error) {
+		return request.Options["channel"] + "-resolved", nil
+	}))
+
+	tests := []struct {
+		name        string
+		packages    []config.Package
+		dereference bool
+		expected    []string
+		expectError bool
+	}{
+		{
+			name: "resolved via mock datasource",
+			packages: []config.Package{
+				{Name: "app", Datasource: "mock", Options: map[string]string{"channel": "stable"}},
+				{Name: "lib", Version: "1.0.0", Datasource: "mock"},
+			},
+			dereference: true,
+			expected:    []string{"stable-resolved", "1.0.0"},
+		},
+		{
+			name:        "dereference disabled",
+			packages:    []config.Package{{Name: "app", Datasource: "mock"}},
+			dereference: false,
+			expectError: true,
+		},
+		{
+			name:        "unknown datasource",
+			packages:    []config.Package{{Name: "app", Datasource: "missing"}},
+			dereference: true,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Packages: tt.packages}
+			err := dereferenceConfigPackages(context.Background(), cfg, registry, tt.dereference)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("dereferenceConfigPackages() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			for i, expected := range tt.expected {
+				if cfg.Packages[i].Version != expected {
+					t.Errorf("package %s version = %q, expected %q", cfg.Packages[i].Name, cfg.Packages[i].Version, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_ConfigDatasource(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nversion: 1.0.0\n",
+	})
+
+	configPath := filepath.Join(t.TempDir(), "depup.yaml")
+	configContent := "packages:\n  - name: app\n    datasource: env\n    env: APP_VERSION\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("APP_VERSION", "2.0.0")
+
+	if _, err := executeCommand(t, "update", tempDir, "--config", configPath); err == nil {
+		t.Error("update without --dereference-config-packages expected error")
+	}
+
+	if _, err := executeCommand(t, "update", tempDir, "--config", configPath, "--dereference-config-packages"); err != nil {
+		t.Fatalf("update unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if string(content) != "# depup package=app\nversion: 2.0.0\n" {
+		t.Errorf("app.yaml = %q, expected resolved version", string(content))
+	}
+}
+
+func TestConfigGenerateCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":        "# depup package=redis\nredis: 6.0.0\nimage: app:1.0.0 # depup package=app\n# depup package=db\ndb: TODO\n",
+		"nested/app.yaml": "# depup package=app\nversion: 1.2.0\n",
+		"infra/main.tf":   "# depup package=aws\nversion = \"4.0.0\"\n",
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "package names",
+			expected: "packages:\n  - name: app\n  - name: db\n  - name: redis\n",
+		},
+		{
+			name: "recursive with versions",
+			args: []string{"-r", "--with-versions"},
+			expected: "recursive: true\npackages:\n" +
+				"  - name: app\n    version: 1.2.0\n" +
+				"  - name: db\n" +
+				"  - name: redis\n    version: 6.0.0\n",
+		},
+		{
+			name:     "scan settings are kept",
+			args:     []string{"-r", "-e", ".tf", "-x", "nested"},
+			expected: "recursive: true\nextensions:\n  - .tf\nexcludes:\n  - nested\npackages:\n  - name: aws\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(t, append([]string{"config", "generate", tempDir}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("config generate unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("config generate output = %q, expected %q", output, tt.expected)
+			}
+		})
+	}
+
+	t.Run("output file", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), config.FileName)
+		if _, err := executeCommand(t, "config", "generate", tempDir, "-r", "-o", configPath); err != nil {
+			t.Fatalf("config generate unexpected error: %v", err)
+		}
+
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			t.Fatalf("generated config doesn't load: %v", err)
+		}
+		if len(cfg.Packages) != 3 || cfg.Packages[0].Name != "app" || cfg.Recursive == nil || !*cfg.Recursive {
+			t.Errorf("generated config = %+v, expected three packages and recursion", cfg)
+		}
+
+		// An existing file is only replaced with --force
+		if _, err := executeCommand(t, "config", "generate", tempDir, "-o", configPath); err == nil {
+			t.Errorf("config generate expected an error for an existing output file")
+		}
+		if _, err := executeCommand(t, "config", "generate", tempDir, "-o", configPath, "--force"); err != nil {
+			t.Errorf("config generate --force unexpected error: %v", err)
+		}
+	})
+}
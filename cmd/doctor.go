@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtomasi/depup/internal/config"
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// doctorCmd diagnoses why an update of a directory might not change anything
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [DIR]",
+	Short: "Diagnose the configuration and the files depup would process",
+	Long: `Report the registered updaters and their extensions, the effective configuration after merging
+flags, environment variables and the configuration file, how many files match and any comments
+that look like depup comments but cannot be parsed. Annotations without a version, e.g. a placeholder
+like "version: TODO", are reported unless --allow-empty-version is set. Files are never modified.
+With --fail-on-dangling, the command fails if any annotation has no version line it can update, e.g. in CI.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		// Merge settings like the update command and remember where each value came from
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		sources := map[string]string{}
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Changed {
+				sources[flag.Name] = "flag"
+			}
+		})
+		values := flagValues(cmd.Flags())
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		values = trackSources(cmd.Flags(), values, sources, "config")
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+		trackSources(cmd.Flags(), values, sources, "env")
+
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		strictSemver, _ := cmd.Flags().GetBool("strict-semver")
+		dedupeAnnotations, _ := cmd.Flags().GetBool("dedupe-annotations")
+		allowEmptyVersion, _ := cmd.Flags().GetBool("allow-empty-version")
+		failOnDangling, _ := cmd.Flags().GetBool("fail-on-dangling")
+		extensions := resolveExtensions(rawExtensions, defaultExtensions)
+
+		u := updater.NewUpdater(
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(extensions),
+			updater.WithExcludes(excludes),
+			updater.WithStrictSemver(strictSemver),
+			updater.WithDedupeAnnotations(dedupeAnnotations),
+		)
+
+		result, err := u.Scan(dir)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+
+		fmt.Fprintln(out, "Updaters:")
+		table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, fileUpdater := range u.Updaters() {
+			supported := fileUpdater.GetSupportedExtensions()
+			slices.Sort(supported)
+			fmt.Fprintf(table, "  %s\t%s\n", fileUpdater.Name(), strings.Join(supported, ", "))
+		}
+		table.Flush()
+
+		fmt.Fprintln(out, "\nConfiguration:")
+		table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		configFile, _ := cmd.Flags().GetString("config")
+		if configFile == "" {
+			if _, err := os.Stat(config.FileName); err == nil {
+				configFile = config.FileName
+			}
+		}
+		fmt.Fprintf(table, "  config file\t%s\n", valueOrNone(configFile))
+		fmt.Fprintf(table, "  recursive\t%t (%s)\n", recursive, sourceOf(sources, "recursive"))
+		fmt.Fprintf(table, "  extensions\t%s (%s)\n", valueOrNone(strings.Join(extensions, ", ")), sourceOf(sources, "extension"))
+		fmt.Fprintf(table, "  excludes\t%s (%s)\n", valueOrNone(strings.Join(excludes, ", ")), sourceOf(sources, "exclude"))
+		fmt.Fprintf(table, "  strict semver\t%t (%s)\n", strictSemver, sourceOf(sources, "strict-semver"))
+		fmt.Fprintf(table, "  packages\t%d (config)\n", len(cfg.Packages))
+		table.Flush()
+
+		fmt.Fprintln(out, "\nFiles:")
+		fmt.Fprintf(out, "  %d files match, %d annotated versions found\n", len(result.Files), len(result.Annotations))
+		if len(result.Files) == 0 {
+			fmt.Fprintln(out, "  no files match, check --recursive, --extension and --exclude")
+		} else if len(result.Annotations) == 0 {
+			fmt.Fprintln(out, "  no annotated versions found, add a comment like: # depup package=NAME")
+		}
+
+		var unversioned []updater.Annotation
+		for _, annotation := range result.Annotations {
+			if annotation.Ignored() && !allowEmptyVersion {
+				unversioned = append(unversioned, annotation)
+			}
+		}
+
+		fmt.Fprintln(out, "\nProblems:")
+		if len(result.Malformed) == 0 && len(unversioned) == 0 && len(result.NonStrict) == 0 && len(result.Conflicts) == 0 {
+			fmt.Fprintln(out, "  none found")
+		}
+		for _, comment := range result.Malformed {
+			fmt.Fprintf(out, "  %s:%d malformed depup comment: %s\n", displayFile(comment.File), comment.Line, strings.TrimSpace(comment.Content))
+		}
+
+		for _, annotation := range unversioned {
+			fmt.Fprintf(out, "  %s:%d package %q is unversioned, no version found: %s\n", displayFile(annotation.File), annotation.Line, annotation.Package, strings.TrimSpace(annotation.Content))
+		}
+
+		for _, annotation := range result.NonStrict {
+			fmt.Fprintf(out, "  %s:%d version of package %q is not a strict semantic version: %s\n", displayFile(annotation.File), annotation.Line, annotation.Package, strings.TrimSpace(annotation.Content))
+		}
+
+		for _, conflict := range result.Conflicts {
+			locations := make([]string, 0, len(conflict.Annotations))
+			for _, annotation := range conflict.Annotations {
+				locations = append(locations, fmt.Sprintf("%s (line %d)", annotation.Version, annotation.Line))
+			}
+			fmt.Fprintf(out, "  %s package %q is annotated with differing versions, consider splitting it: %s\n", displayFile(conflict.File), conflict.Package, strings.Join(locations, ", "))
+		}
+
+		// Depup comments annotating no line always count, placeholders only unless they are allowed
+		dangling := len(unversioned)
+		if allowEmptyVersion {
+			dangling = len(result.Dangling)
+		}
+		if failOnDangling && dangling > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d annotation(s) without a version line to update found", dangling)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	// Register the doctor command as a subcommand of the root command
+	rootCmd.AddCommand(doctorCmd)
+
+	// Flags affecting which files are processed, mirroring the update command
+	doctorCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	doctorCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	doctorCmd.Flags().Bool("strict-semver", false, "Report annotated versions that aren't strict semantic versions")
+	doctorCmd.Flags().Bool("allow-empty-version", false, "Don't report annotations without a version, e.g. placeholders like \"version: TODO\"")
+	doctorCmd.Flags().Bool("fail-on-dangling", false, "Fail if a depup comment annotates no version line, or a placeholder unless --allow-empty-version is set")
+	doctorCmd.Flags().Bool("dedupe-annotations", false, "Report packages annotated more than once in a file with differing versions")
+	doctorCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+}
+
+// flagValues returns the current value of every flag by name
+func flagValues(flags *pflag.FlagSet) map[string]string {
+	values := map[string]string{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		values[flag.Name] = flag.Value.String()
+	})
+	return values
+}
+
+// trackSources records the source of every flag whose value changed since the previous values
+// Returns the current values for the next step
+func trackSources(flags *pflag.FlagSet, previous map[string]string, sources map[string]string, source string) map[string]string {
+	values := flagValues(flags)
+	for name, value := range values {
+		if value != previous[name] {
+			sources[name] = source
+		}
+	}
+	return values
+}
+
+// sourceOf returns where the value of a flag came from, "default" if it wasn't set
+func sourceOf(sources map[string]string, name string) string {
+	if source, ok := sources[name]; ok {
+		return source
+	}
+	return "default"
+}
+
+// valueOrNone returns the value, or "none" if it is empty
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// displayFile returns the path relative to the working directory if possible
+func displayFile(path string) string {
+	if workingDir, err := os.Getwd(); err == nil {
+		if relPath, err := filepath.Rel(workingDir, path); err == nil {
+			return relPath
+		}
+	}
+	return path
+}
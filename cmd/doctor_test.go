@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDoctorCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		".depup.yaml":       "recursive: true\npackages:\n  - name: app\n    version: 2.0.0\n",
+		"app.yaml":          "# depup package=app\nimage: app:1.0.0\n",
+		"deploy/broken.yml": "# depup pakage=typo\nversion: 1.0.0\n",
+		"vendor/lib.yaml":   "# depup package=lib\nversion: 1.0.0\n",
+		"main.tf":           "# depup package=aws\nversion = \"4.0.0\"\n",
+		"legacy.yaml":       "# depup package=legacy\nversion: 01.2.3\n",
+	})
+	t.Chdir(tempDir)
+	t.Setenv("DEPUP_EXTENSIONS", ".yaml,.yml,.tf")
+
+	output, err := executeCommand(t, "doctor", ".", "-x", "vendor", "--strict-semver")
+	if err != nil {
+		t.Fatalf("doctor unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Updaters:\n  yaml          .yaml, .yml\n  hcl           .hcl, .tf, .tfvars\n",
+		"  config file    .depup.yaml\n",
+		"  recursive      true (config)\n",
+		"  extensions     .yaml, .yml, .tf (env)\n",
+		"  excludes       vendor (flag)\n",
+		"  packages       1 (config)\n",
+		"  strict semver  true (flag)\n",
+		"Files:\n  5 files match, 3 annotated versions found\n",
+		"Problems:\n  deploy/broken.yml:1 malformed depup comment: # depup pakage=typo\n",
+		"  legacy.yaml:2 version of package \"legacy\" is not a strict semantic version: version: 01.2.3\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("doctor output missing %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestDoctorCmd_NoFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"main.tf": "version = \"4.0.0\"\n",
+	})
+
+	output, err := executeCommand(t, "doctor", tempDir)
+	if err != nil {
+		t.Fatalf("doctor unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"  extensions     .yaml, .yml (default)\n",
+		"  0 files match, 0 annotated versions found\n  no files match",
+		"Problems:\n  none found\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("doctor output missing %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestDoctorCmd_DedupeAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":    "# depup package=app\napi: app:1.0.0\n# depup package=app\nworker: app:1.1.0\n",
+		"shared.yaml": "# depup package=db\nprimary: db:5.0.0\n# depup package=db\nreplica: db:5.0.0\n",
+	})
+	t.Chdir(tempDir)
+
+	output, err := executeCommand(t, "doctor", ".", "--dedupe-annotations")
+	if err != nil {
+		t.Fatalf("doctor unexpected error: %v", err)
+	}
+
+	expected := "Problems:\n  app.yaml package \"app\" is annotated with differing versions, consider splitting it: 1.0.0 (line 2), 1.1.0 (line 4)\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("doctor output missing %q, got:\n%s", expected, output)
+	}
+	if strings.Contains(output, "\"db\"") {
+		t.Errorf("doctor output reports the shared version of db, got:\n%s", output)
+	}
+}
+
+func TestDoctorCmd_AllowEmptyVersion(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nversion: TODO\n# depup package=db\n",
+	})
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "reported by default",
+			expected: "Problems:\n" +
+				"  app.yaml:2 package \"app\" is unversioned, no version found: version: TODO\n" +
+				"  app.yaml:3 package \"db\" is unversioned, no version found: # depup package=db\n",
+		},
+		{
+			name:     "allowed",
+			args:     []string{"--allow-empty-version"},
+			expected: "Problems:\n  none found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(t, append([]string{"doctor", "."}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("doctor unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(output, tt.expected) {
+				t.Errorf("doctor output doesn't end with %q, got:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestDoctorCmd_FailOnDangling(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileContent string
+		args        []string
+		expectError string
+	}{
+		{
+			name:        "dangling comment",
+			fileContent: "version: 1.0.0\n# depup package=app\n",
+			expectError: "1 annotation(s) without a version line to update found",
+		},
+		{
+			name:        "dangling comment with placeholders allowed",
+			fileContent: "# depup package=app\n# depup package=db\nversion: 1.0.0\n",
+			args:        []string{"--allow-empty-version"},
+			expectError: "1 annotation(s) without a version line to update found",
+		},
+		{
+			name:        "placeholder",
+			fileContent: "# depup package=app\nversion: TODO\n",
+			expectError: "1 annotation(s) without a version line to update found",
+		},
+		{
+			name:        "placeholder allowed",
+			fileContent: "# depup package=app\nversion: TODO\n",
+			args:        []string{"--allow-empty-version"},
+		},
+		{
+			name:        "all annotations live",
+			fileContent: "# depup package=app\nversion: 1.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": tt.fileContent})
+			t.Chdir(tempDir)
+
+			_, err := executeCommand(t, append([]string{"doctor", ".", "--fail-on-dangling"}, tt.args...)...)
+			if tt.expectError == "" && err != nil || tt.expectError != "" && (err == nil || err.Error() != tt.expectError) {
+				t.Errorf("doctor --fail-on-dangling %v error = %v, expected %q", tt.args, err, tt.expectError)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// updateEnvVars maps update command flags to the environment variables providing their defaults
+var /* const */ updateEnvVars = map[string]string{
+	"dry-run":   "DEPUP_DRY_RUN",
+	"recursive": "DEPUP_RECURSIVE",
+	"extension": "DEPUP_EXTENSIONS",
+}
+
+// applyEnvDefaults seeds flags that weren't passed explicitly from their environment variables
+// Flags given on the command line always take precedence over the environment
+func applyEnvDefaults(flags *pflag.FlagSet, envVars map[string]string) error {
+	for name, envVar := range envVars {
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			continue
+		}
+
+		// Slice flags take a comma separated list, e.g. DEPUP_EXTENSIONS=.yaml,.yml
+		if err := setFlagDefault(flags, name, splitEnvList(value)); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, envVar, err)
+		}
+	}
+
+	return nil
+}
+
+// setFlagDefault sets the value of a flag unless it was passed explicitly
+// Slice flags are replaced with all values, other flags are set to the first value
+func setFlagDefault(flags *pflag.FlagSet, name string, values []string) error {
+	flag := flags.Lookup(name)
+	if flag == nil || flag.Changed {
+		return nil
+	}
+
+	if sliceValue, isSlice := flag.Value.(pflag.SliceValue); isSlice {
+		return sliceValue.Replace(values)
+	}
+	if len(values) == 0 {
+		return flag.Value.Set("")
+	}
+
+	return flag.Value.Set(values[0])
+}
+
+// splitEnvList splits a comma separated environment value, dropping empty entries
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
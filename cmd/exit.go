@@ -0,0 +1,52 @@
+package cmd
+
+import "errors"
+
+// ExitCode is the status depup exits with, so scripts can tell a failure from pending updates
+type ExitCode int
+
+const (
+	// ExitOK means the command succeeded and nothing needs to be done
+	ExitOK ExitCode = 0
+	// ExitError means the command failed, e.g. because of an invalid flag or an unreadable file
+	ExitError ExitCode = 1
+	// ExitChangesNeeded means the command succeeded but files need updates, e.g. reported by update --count
+	ExitChangesNeeded ExitCode = 2
+)
+
+// exitError is an error carrying the exit code depup exits with when a command returns it
+type exitError struct {
+	err  error
+	code ExitCode
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode attaches an exit code to an error, nil stays nil
+func withExitCode(err error, code ExitCode) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{err: err, code: code}
+}
+
+// ExitCodeOf maps an error returned by Execute to the exit code of the process
+// Errors without an attached exit code are plain failures.
+func ExitCodeOf(err error) ExitCode {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return ExitError
+}
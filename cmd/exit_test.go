@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCodeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ExitCode
+	}{
+		{name: "no error", err: nil, expected: ExitOK},
+		{name: "plain error", err: errors.New("boom"), expected: ExitError},
+		{name: "changes needed", err: withExitCode(errors.New("1 file(s) need updates"), ExitChangesNeeded), expected: ExitChangesNeeded},
+		{name: "wrapped exit error", err: fmt.Errorf("update: %w", withExitCode(errors.New("pending"), ExitChangesNeeded)), expected: ExitChangesNeeded},
+		{name: "nil stays nil", err: withExitCode(nil, ExitChangesNeeded), expected: ExitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCodeOf(tt.err); code != tt.expected {
+				t.Errorf("ExitCodeOf(%v) = %d, expected %d", tt.err, code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExitCodeOf_Commands(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{"app.yaml": "version: 1.0.0 # depup package=app\n"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected ExitCode
+	}{
+		{name: "up to date", args: []string{"update", tempDir, "--count", "-p", "app=1.0.0"}, expected: ExitOK},
+		{name: "files need updates", args: []string{"update", tempDir, "--count", "-p", "app=2.0.0"}, expected: ExitChangesNeeded},
+		{name: "invalid package", args: []string{"update", tempDir, "--count", "-p", "app"}, expected: ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeCommand(t, tt.args...)
+			if code := ExitCodeOf(err); code != tt.expected {
+				t.Errorf("depup %v exit code = %d (%v), expected %d", tt.args, code, err, tt.expected)
+			}
+		})
+	}
+}
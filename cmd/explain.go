@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd represents the explain command for debugging annotations
+var explainCmd = &cobra.Command{
+	Use:   "explain FILE",
+	Short: "Describe how depup parses a file line by line",
+	Long: `Print every line of a file together with what depup sees on it: depup comments, the package
+name they reference, the version found on annotated lines and whether the line would be updated.
+The file is never modified.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rawPackages, _ := cmd.Flags().GetStringArray("package")
+
+		packages, err := parsePackages(rawPackages)
+		if err != nil {
+			return err
+		}
+
+		analysis, err := updater.NewUpdater().Explain(args[0], packages)
+		if err != nil {
+			return err
+		}
+
+		supplied := map[string]bool{}
+		for _, pkg := range packages {
+			supplied[pkg.Name] = true
+		}
+
+		out := cmd.OutOrStdout()
+		for i, line := range analysis {
+			// Lines annotated with several packages are described once per package
+			if i == 0 || analysis[i-1].Line != line.Line {
+				fmt.Fprintf(out, "%4d %s\n", line.Line, line.Content)
+			}
+
+			if line.Malformed {
+				fmt.Fprintln(out, "       malformed depup comment, expected: depup package=NAME")
+			}
+			if line.Annotation != "" {
+				fmt.Fprintf(out, "       depup comment for package %q\n", line.Annotation)
+			}
+			if line.Package == "" {
+				continue
+			}
+
+			switch {
+			case line.Version == "":
+				fmt.Fprintf(out, "       annotated with package %q, but no version found\n", line.Package)
+			case line.WouldUpdate():
+				fmt.Fprintf(out, "       annotated with package %q, version %s would be updated to %s\n", line.Package, line.Version, line.NewVersion)
+			case supplied[line.Package]:
+				fmt.Fprintf(out, "       annotated with package %q, version %s is up to date\n", line.Package, line.Version)
+			default:
+				fmt.Fprintf(out, "       annotated with package %q, version %s (no version supplied)\n", line.Package, line.Version)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	// Register the explain command as a subcommand of the root command
+	rootCmd.AddCommand(explainCmd)
+
+	// Flag to specify packages to evaluate in the format IDENTIFIER=SEMVER_VERSION
+	explainCmd.Flags().StringArrayP("package", "p", []string{}, "Specify dependencies to evaluate in the format IDENTIFIER=SEMVER_VERSION (-p package=1.2.3)")
+}
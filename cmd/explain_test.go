@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.env": "# depup package=app\nAPP_VERSION=1.0.0\n# depup pakage=typo\nDB_VERSION=2.0.0 # depup package=db\n",
+	})
+
+	output, err := executeCommand(t, "explain", filepath.Join(tempDir, "app.env"), "-p", "app=1.1.0", "-p", "db=2.0.0")
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"   1 # depup package=app\n       depup comment for package \"app\"\n",
+		"   2 APP_VERSION=1.0.0\n       annotated with package \"app\", version 1.0.0 would be updated to 1.1.0\n",
+		"   3 # depup pakage=typo\n       malformed depup comment",
+		"annotated with package \"db\", version 2.0.0 is up to date\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("explain output missing %q, got:\n%s", expected, output)
+		}
+	}
+}
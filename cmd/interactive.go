@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dtomasi/depup/internal/updater"
+)
+
+// confirmChanges asks for every change of the plan whether it should be applied
+// Answers are y (apply), n (skip), a (apply this and all remaining changes) and q (skip this and all remaining changes)
+// Returns the plan reduced to the confirmed changes
+func confirmChanges(in io.Reader, out io.Writer, plan *updater.Plan) (*updater.Plan, error) {
+	reader := bufio.NewReader(in)
+	confirmed := map[updater.Change]bool{}
+	applyAll, quit := false, false
+
+	for _, change := range plan.Changes() {
+		for !applyAll && !quit {
+			fmt.Fprintf(out, "Apply %s:%d %s %s -> %s? [y,n,a,q] ",
+				displayFile(change.File), change.Line, change.Package, change.OldVersion, change.NewVersion)
+
+			answer, err := reader.ReadString('\n')
+			if err != nil && answer == "" {
+				// Treat the end of the input like quitting, keeping what was confirmed so far
+				fmt.Fprintln(out)
+				quit = true
+				break
+			}
+
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				confirmed[change] = true
+			case "n", "no":
+			case "a", "all":
+				applyAll = true
+			case "q", "quit":
+				quit = true
+			default:
+				fmt.Fprintln(out, "Please answer y (apply), n (skip), a (apply all remaining) or q (quit)")
+				continue
+			}
+			break
+		}
+
+		if applyAll {
+			confirmed[change] = true
+		}
+	}
+
+	return plan.Select(func(change updater.Change) bool { return confirmed[change] })
+}
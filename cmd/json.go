@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/spf13/pflag"
+)
+
+// addJSONFormatFlags registers the flags choosing between compact and indented JSON on a command writing JSON.
+// Without either flag, every output keeps its own default: documents are indented, JSON lines are compact.
+func addJSONFormatFlags(flags *pflag.FlagSet) {
+	flags.Bool("json-compact", false, "Write JSON output on a single line instead of indented")
+	flags.Bool("json-pretty", false, "Write JSON output indented, including JSON lines, which are compact by default")
+}
+
+// jsonFormat returns whether JSON output was requested compact or indented, both are false to keep the defaults
+// --json-pretty on the command line overrides json_compact from the configuration file, passing both fails.
+func jsonFormat(flags *pflag.FlagSet) (compact bool, pretty bool, err error) {
+	compact, _ = flags.GetBool("json-compact")
+	pretty, _ = flags.GetBool("json-pretty")
+	if compact && pretty {
+		if flags.Changed("json-compact") {
+			return false, false, errors.New("--json-compact and --json-pretty cannot be combined")
+		}
+		compact = false
+	}
+
+	return compact, pretty, nil
+}
+
+// newJSONEncoder returns an encoder writing a JSON document to w, indented unless compact is set
+func newJSONEncoder(w io.Writer, compact bool) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if !compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestJSONFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		configCompact   bool // json_compact from the configuration file
+		expectedCompact bool
+		expectedPretty  bool
+		expectError     bool
+	}{
+		{name: "defaults"},
+		{name: "compact", args: []string{"--json-compact"}, expectedCompact: true},
+		{name: "pretty", args: []string{"--json-pretty"}, expectedPretty: true},
+		{name: "compact from config", configCompact: true, expectedCompact: true},
+		{name: "pretty overrides config", args: []string{"--json-pretty"}, configCompact: true, expectedPretty: true},
+		{name: "both flags", args: []string{"--json-compact", "--json-pretty"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			addJSONFormatFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if tt.configCompact {
+				if err := setFlagDefault(flags, "json-compact", []string{"true"}); err != nil {
+					t.Fatalf("failed to apply config default: %v", err)
+				}
+			}
+
+			compact, pretty, err := jsonFormat(flags)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("jsonFormat() error = %v, expectError %v", err, tt.expectError)
+			}
+			if compact != tt.expectedCompact || pretty != tt.expectedPretty {
+				t.Errorf("jsonFormat() = %v, %v, expected %v, %v", compact, pretty, tt.expectedCompact, tt.expectedPretty)
+			}
+		})
+	}
+}
+
+func TestNewJSONEncoder(t *testing.T) {
+	value := map[string]int{"files": 2}
+
+	var pretty bytes.Buffer
+	if err := newJSONEncoder(&pretty, false).Encode(value); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	if pretty.String() != "{\n  \"files\": 2\n}\n" {
+		t.Errorf("indented output = %q", pretty.String())
+	}
+
+	var compact bytes.Buffer
+	if err := newJSONEncoder(&compact, true).Encode(value); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	if compact.String() != "{\"files\":2}\n" {
+		t.Errorf("compact output = %q", compact.String())
+	}
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// listEntry is a single annotation in the JSON output of the list command
+type listEntry struct {
+	File    string `json:"file"`    // Absolute path of the file
+	Line    int    `json:"line"`    // 1-based line number of the annotated version
+	Package string `json:"package"` // Package name of the depup comment
+	Version string `json:"version"` // Version found on the line, empty if none was found
+	Updater string `json:"updater"` // Name of the updater handling the file
+	Ignored bool   `json:"ignored"` // Whether an update skips the annotation because no version was found
+}
+
+// updaterCountEntry is the number of files and annotations of an updater in the JSON output of the list command
+type updaterCountEntry struct {
+	Updater     string `json:"updater"`     // Name of the updater
+	Files       int    `json:"files"`       // Files handled by the updater
+	Annotations int    `json:"annotations"` // Annotations found in those files, including ignored ones
+}
+
+// listCmd prints every annotated version of a directory without changing it
+var listCmd = &cobra.Command{
+	Use:   "list DIR",
+	Short: "List the annotated versions depup would process",
+	Long: `Print every version annotated with a depup comment together with its file, line number, package
+and the updater handling the file. Annotations without a version on their line are ignored by updates
+and marked as such. Pass --count-by-updater to print how many files and annotations each updater handles
+instead. Files are never modified.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Merge settings like the update command: flags > environment > configuration file
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		compactJSON, _, err := jsonFormat(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		countByUpdater, _ := cmd.Flags().GetBool("count-by-updater")
+		rawCommentPrefixes, _ := cmd.Flags().GetStringArray("comment-prefix")
+		rawCommentRegex, _ := cmd.Flags().GetString("comment-regex")
+
+		commentPrefixes, err := parseCommentPrefixes(rawCommentPrefixes)
+		if err != nil {
+			return err
+		}
+		commentRegex, err := parseCommentRegex(rawCommentRegex)
+		if err != nil {
+			return err
+		}
+
+		u := updater.NewUpdater(
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+			updater.WithCommentPrefixes(commentPrefixes),
+			updater.WithCommentRegex(commentRegex),
+		)
+
+		result, err := u.Scan(args[0])
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if countByUpdater {
+			return printUpdaterCounts(out, u, result, asJSON, compactJSON)
+		}
+		if asJSON {
+			entries := make([]listEntry, 0, len(result.Annotations))
+			for _, annotation := range result.Annotations {
+				entries = append(entries, listEntry{
+					File:    annotation.File,
+					Line:    annotation.Line,
+					Package: annotation.Package,
+					Version: annotation.Version,
+					Updater: annotation.Updater,
+					Ignored: annotation.Ignored(),
+				})
+			}
+
+			return newJSONEncoder(out, compactJSON).Encode(entries)
+		}
+
+		for _, annotation := range result.Annotations {
+			if annotation.Ignored() {
+				fmt.Fprintf(out, "%s:%d %s (%s, ignored: no version found)\n", displayFile(annotation.File), annotation.Line, annotation.Package, annotation.Updater)
+				continue
+			}
+			fmt.Fprintf(out, "%s:%d %s %s (%s)\n", displayFile(annotation.File), annotation.Line, annotation.Package, annotation.Version, annotation.Updater)
+		}
+
+		return nil
+	},
+}
+
+// printUpdaterCounts prints the number of files and annotations handled by each updater as a table or JSON array
+func printUpdaterCounts(out io.Writer, u *updater.Updater, result *updater.ScanResult, asJSON, compactJSON bool) error {
+	counts, err := u.CountByUpdater(result)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		entries := make([]updaterCountEntry, 0, len(counts))
+		for _, count := range counts {
+			entries = append(entries, updaterCountEntry{Updater: count.Updater, Files: count.Files, Annotations: count.Annotations})
+		}
+
+		return newJSONEncoder(out, compactJSON).Encode(entries)
+	}
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "UPDATER\tFILES\tANNOTATIONS")
+	for _, count := range counts {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", count.Updater, count.Files, count.Annotations)
+	}
+
+	return table.Flush()
+}
+
+func init() {
+	// Register the list command as a subcommand of the root command
+	rootCmd.AddCommand(listCmd)
+
+	// Flags affecting which files are processed, mirroring the update command
+	listCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	listCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	listCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+	listCmd.Flags().StringArray("comment-prefix", []string{}, commentPrefixUsage)
+	listCmd.Flags().String("comment-regex", "", commentRegexUsage)
+
+	// Flag to print the annotations as a JSON array for tooling
+	listCmd.Flags().Bool("json", false, "Print the annotations as a JSON array")
+	addJSONFormatFlags(listCmd.Flags())
+	// Flag to print statistics per updater instead of the annotations
+	listCmd.Flags().Bool("count-by-updater", false, "Print the number of files and annotations handled by each updater")
+}
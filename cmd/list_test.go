@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":      "# depup package=app\nimage: app:1.0.0\nredis: 6.0.0 # depup package=redis\n# depup package=db\ndb: TODO\n",
+		"infra/main.tf": "# depup package=aws\nversion = \"4.0.0\"\n",
+	})
+	t.Chdir(tempDir)
+
+	appPath := filepath.Join(tempDir, "app.yaml")
+	tfPath := filepath.Join(tempDir, "infra", "main.tf")
+
+	t.Run("text", func(t *testing.T) {
+		output, err := executeCommand(t, "list", ".", "-r", "-e", ".yaml", "-e", ".tf")
+		if err != nil {
+			t.Fatalf("list unexpected error: %v", err)
+		}
+
+		expected := "app.yaml:2 app 1.0.0 (yaml)\n" +
+			"app.yaml:3 redis 6.0.0 (yaml)\n" +
+			"app.yaml:5 db (yaml, ignored: no version found)\n" +
+			"infra/main.tf:2 aws 4.0.0 (hcl)\n"
+		if output != expected {
+			t.Errorf("list output = %q, expected %q", output, expected)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		output, err := executeCommand(t, "list", ".", "-r", "-e", ".yaml", "-e", ".tf", "--json")
+		if err != nil {
+			t.Fatalf("list --json unexpected error: %v", err)
+		}
+
+		var entries []listEntry
+		if err := json.Unmarshal([]byte(output), &entries); err != nil {
+			t.Fatalf("list --json output is not valid JSON: %v\n%s", err, output)
+		}
+
+		expected := []listEntry{
+			{File: appPath, Line: 2, Package: "app", Version: "1.0.0", Updater: "yaml"},
+			{File: appPath, Line: 3, Package: "redis", Version: "6.0.0", Updater: "yaml"},
+			{File: appPath, Line: 5, Package: "db", Updater: "yaml", Ignored: true},
+			{File: tfPath, Line: 2, Package: "aws", Version: "4.0.0", Updater: "hcl"},
+		}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Errorf("list --json = %+v, expected %+v", entries, expected)
+		}
+		if !strings.Contains(output, `"ignored": true`) {
+			t.Errorf("list --json output missing ignored field, got:\n%s", output)
+		}
+	})
+
+	t.Run("no annotations", func(t *testing.T) {
+		output, err := executeCommand(t, "list", ".", "--json", "-e", ".json")
+		if err != nil {
+			t.Fatalf("list --json unexpected error: %v", err)
+		}
+		if output != "[]\n" {
+			t.Errorf("list --json output = %q, expected an empty array", output)
+		}
+	})
+}
+
+func TestListCmd_CountByUpdater(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":      "# depup package=app\nimage: app:1.0.0\nredis: 6.0.0 # depup package=redis\n# depup package=db\ndb: TODO\n",
+		"plain.yaml":    "name: plain\n",
+		"infra/main.tf": "# depup package=aws\nversion = \"4.0.0\"\n",
+		".env":          "# depup package=node\nNODE_VERSION=20.1.0\n",
+	})
+	t.Chdir(tempDir)
+
+	args := []string{"list", ".", "-r", "-e", ".yaml", "-e", ".tf", "-e", ".env", "--count-by-updater"}
+
+	t.Run("text", func(t *testing.T) {
+		output, err := executeCommand(t, args...)
+		if err != nil {
+			t.Fatalf("list --count-by-updater unexpected error: %v", err)
+		}
+
+		expected := "UPDATER  FILES  ANNOTATIONS\n" +
+			"yaml     2      3\n" +
+			"dotenv   1      1\n" +
+			"hcl      1      1\n"
+		if output != expected {
+			t.Errorf("list --count-by-updater output = %q, expected %q", output, expected)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		output, err := executeCommand(t, append(args, "--json")...)
+		if err != nil {
+			t.Fatalf("list --count-by-updater --json unexpected error: %v", err)
+		}
+
+		var entries []updaterCountEntry
+		if err := json.Unmarshal([]byte(output), &entries); err != nil {
+			t.Fatalf("list --count-by-updater --json output is not valid JSON: %v\n%s", err, output)
+		}
+
+		expected := []updaterCountEntry{
+			{Updater: "yaml", Files: 2, Annotations: 3},
+			{Updater: "dotenv", Files: 1, Annotations: 1},
+			{Updater: "hcl", Files: 1, Annotations: 1},
+		}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Errorf("list --count-by-updater --json = %+v, expected %+v", entries, expected)
+		}
+	})
+}
+
+func TestListCmd_JSONFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nimage: app:1.0.0\n",
+	})
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectIndented bool
+		expectError    bool
+	}{
+		{name: "annotations indented by default", args: []string{"--json"}, expectIndented: true},
+		{name: "annotations compact", args: []string{"--json", "--json-compact"}, expectIndented: false},
+		{name: "annotations pretty", args: []string{"--json", "--json-pretty"}, expectIndented: true},
+		{name: "counts indented by default", args: []string{"--json", "--count-by-updater"}, expectIndented: true},
+		{name: "counts compact", args: []string{"--json", "--count-by-updater", "--json-compact"}, expectIndented: false},
+		{name: "both toggles", args: []string{"--json", "--json-compact", "--json-pretty"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(t, append([]string{"list", "."}, tt.args...)...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("list error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if !json.Valid([]byte(output)) {
+				t.Fatalf("list output is not valid JSON: %q", output)
+			}
+			if indented := strings.Contains(output, "\n  "); indented != tt.expectIndented {
+				t.Errorf("list output indented = %v, expected %v:\n%s", indented, tt.expectIndented, output)
+			}
+			if !tt.expectIndented && strings.Count(output, "\n") != 1 {
+				t.Errorf("list output = %q, expected a single line", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// manCmd generates troff man pages for depup and all of its subcommands
+// It is hidden, as it is meant for packaging rather than everyday use
+var manCmd = &cobra.Command{
+	Use:    "man DIR",
+	Short:  "Generate man pages for all commands",
+	Long:   `Write a troff man page for depup and every subcommand to DIR, e.g. depup.1 and depup-update.1.`,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := os.MkdirAll(args[0], 0755); err != nil {
+			return fmt.Errorf("failed to create man page directory: %w", err)
+		}
+
+		header := &doc.GenManHeader{
+			Title:   "DEPUP",
+			Section: "1",
+			Source:  "depup " + version,
+		}
+		if err := doc.GenManTree(rootCmd, header, args[0]); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	// Register the man command as a subcommand of the root command
+	rootCmd.AddCommand(manCmd)
+}
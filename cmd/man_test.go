@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManCmd(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "man")
+
+	if _, err := executeCommand(t, "man", dir); err != nil {
+		t.Fatalf("man unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{file: "depup.1", expected: "depup"},
+		{file: "depup-update.1", expected: "depup update"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("failed to read man page: %v", err)
+			}
+			if !strings.Contains(string(content), tt.expected) {
+				t.Errorf("man page %s does not contain %q", tt.file, tt.expected)
+			}
+		})
+	}
+}
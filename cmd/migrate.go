@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd renames a package in depup comments, e.g. after a dependency was renamed
+var migrateCmd = &cobra.Command{
+	Use:   "migrate DIR",
+	Short: "Rename a package in depup comments",
+	Long: `Rewrite the package name --from to --to in every depup comment found in DIR, including comments
+naming several packages. Versions and other attributes of the comments are left unchanged.
+With --dry-run, the comments that would be renamed are only printed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+
+		if from == "" || to == "" {
+			return fmt.Errorf("both --from and --to are required")
+		}
+		if strings.ContainsAny(to, ", \t") {
+			return fmt.Errorf("invalid --to value %q: package names must not contain commas or whitespace", to)
+		}
+		if from == to {
+			return fmt.Errorf("--from and --to are both %q", from)
+		}
+
+		u := updater.NewUpdater(
+			updater.WithDryRun(dryRun),
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+		)
+
+		renamed, err := u.Migrate(args[0], from, to)
+		for _, comment := range renamed {
+			verb := "Renamed"
+			if dryRun {
+				verb = "Would rename"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s:%d %s -> %s\n", verb, displayFile(comment.File), comment.Line, from, to)
+		}
+
+		return err
+	},
+}
+
+func init() {
+	// Register the migrate command as a subcommand of the root command
+	rootCmd.AddCommand(migrateCmd)
+
+	// Flags selecting the package to rename
+	migrateCmd.Flags().String("from", "", "Current package name in depup comments")
+	migrateCmd.Flags().String("to", "", "New package name")
+	migrateCmd.Flags().BoolP("dry-run", "d", false, "Show which comments would be renamed without changing files")
+
+	// Flags affecting which files are processed, mirroring the update command
+	migrateCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	migrateCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	migrateCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateCmd(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"values.yaml":   "# depup package=aws-provider\nversion: 4.0.0\n",
+		"infra/main.tf": "provider \"aws\" {\n  version = \"4.0.0\" # depup package=aws-provider\n}\n",
+	})
+	t.Chdir(tempDir)
+
+	output, err := executeCommand(t, "migrate", ".", "-r", "-e", ".yaml", "-e", ".tf", "--from", "aws-provider", "--to", "aws")
+	if err != nil {
+		t.Fatalf("migrate unexpected error: %v", err)
+	}
+
+	expectedOutput := "Renamed infra/main.tf:2 aws-provider -> aws\nRenamed values.yaml:1 aws-provider -> aws\n"
+	if output != expectedOutput {
+		t.Errorf("migrate output = %q, expected %q", output, expectedOutput)
+	}
+
+	for file, expected := range map[string]string{
+		"values.yaml":   "# depup package=aws\nversion: 4.0.0\n",
+		"infra/main.tf": "provider \"aws\" {\n  version = \"4.0.0\" # depup package=aws\n}\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(tempDir, file))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, expected %q", file, string(content), expected)
+		}
+	}
+
+	// Nothing is left to rename
+	if output, err := executeCommand(t, "migrate", ".", "-r", "-e", ".yaml", "-e", ".tf", "--from", "aws-provider", "--to", "aws"); err != nil || output != "" {
+		t.Errorf("migrate again = %q, %v, expected no renamed comments", output, err)
+	}
+}
+
+func TestMigrateCmd_InvalidFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing to", args: []string{"--from", "aws-provider"}},
+		{name: "same names", args: []string{"--from", "aws", "--to", "aws"}},
+		{name: "list as new name", args: []string{"--from", "aws-provider", "--to", "aws,vpc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := executeCommand(t, append([]string{"migrate", t.TempDir()}, tt.args...)...); err == nil {
+				t.Errorf("migrate %v expected an error", tt.args)
+			}
+		})
+	}
+}
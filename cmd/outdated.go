@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/dtomasi/depup/internal/config"
+	"github.com/dtomasi/depup/internal/resolver"
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// Status of an annotation in the output of the outdated command
+const (
+	outdatedStatusBehind   = "behind"
+	outdatedStatusUpToDate = "up to date"
+	outdatedStatusUnknown  = "unknown"
+)
+
+// outdatedCmd compares the annotated versions of a directory against the latest known versions
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated DIR",
+	Short: "Compare the annotated versions against the latest available versions",
+	Long: `Print every annotated version together with the latest version known for its package and mark
+the annotations that are behind. Latest versions are the versions of the configuration file, with
+--online packages naming a datasource are resolved from it instead. No -p values are needed and
+files are never modified.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Merge settings like the update command: flags > environment > configuration file
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		online, _ := cmd.Flags().GetBool("online")
+		registryAuth, _ := cmd.Flags().GetString("registry-auth")
+
+		u := updater.NewUpdater(
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+		)
+
+		result, err := u.Scan(args[0])
+		if err != nil {
+			return err
+		}
+
+		// Datasources are always queried, cached versions could hide newer releases
+		var versionResolver resolver.VersionResolver
+		if online {
+			if err := configureRegistryAuth(registryAuth); err != nil {
+				return err
+			}
+			versionResolver = datasources
+		}
+
+		latest, err := latestVersions(cmd, cfg, result.Annotations, versionResolver)
+		if err != nil {
+			return err
+		}
+
+		table := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "FILE\tPACKAGE\tCURRENT\tLATEST\tSTATUS")
+		for _, annotation := range result.Annotations {
+			latestVersion, ok := latest[annotation.Package]
+			status := outdatedStatus(annotation.Version, latestVersion, ok)
+			fmt.Fprintf(table, "%s:%d\t%s\t%s\t%s\t%s\n", displayFile(annotation.File), annotation.Line, annotation.Package,
+				valueOrNone(annotation.Version), valueOrNone(latestVersion), status)
+		}
+
+		return table.Flush()
+	},
+}
+
+// latestVersions returns the latest version of each annotated package known from the configuration
+// With a resolver, packages naming a datasource are resolved from it, even if the configuration sets a version
+func latestVersions(cmd *cobra.Command, cfg *config.Config, annotations []updater.Annotation, versionResolver resolver.VersionResolver) (map[string]string, error) {
+	annotated := map[string]bool{}
+	for _, annotation := range annotations {
+		annotated[annotation.Package] = true
+	}
+
+	latest := map[string]string{}
+	for _, pkg := range cfg.Packages {
+		if !annotated[pkg.Name] {
+			continue
+		}
+
+		if versionResolver != nil && pkg.Datasource != "" {
+			version, err := versionResolver.Resolve(cmd.Context(), resolver.Request{Package: pkg.Name, Datasource: pkg.Datasource, Options: pkg.Options})
+			if err != nil {
+				return nil, err
+			}
+			latest[pkg.Name] = version
+			continue
+		}
+
+		if pkg.Version != "" {
+			latest[pkg.Name] = pkg.Version
+		}
+	}
+
+	return latest, nil
+}
+
+// outdatedStatus reports whether the current version is behind the latest version
+// The status is unknown if either version is missing
+func outdatedStatus(current, latest string, known bool) string {
+	if !known || current == "" {
+		return outdatedStatusUnknown
+	}
+	if updater.CompareVersions(current, latest) < 0 {
+		return outdatedStatusBehind
+	}
+	return outdatedStatusUpToDate
+}
+
+func init() {
+	// Register the outdated command as a subcommand of the root command
+	rootCmd.AddCommand(outdatedCmd)
+
+	// Flags affecting which files are processed, mirroring the update command
+	outdatedCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
+	outdatedCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	outdatedCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+
+	// Flags to resolve the latest versions from datasources
+	outdatedCmd.Flags().Bool("online", false, "Resolve the latest versions of configured packages from their datasource")
+	outdatedCmd.Flags().String("registry-auth", "", "Netrc-style file with credentials for private registries, DEPUP_AUTH_<HOST> variables take precedence")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dtomasi/depup/internal/resolver"
+)
+
+func TestOutdatedCmd(t *testing.T) {
+	// The mock datasource returns newer versions for app and db only
+	latest := map[string]string{"app": "2.0.0", "redis": "6.0.0", "db": "v1.5.0"}
+	datasources.Register("outdated-mock", resolver.VersionResolverFunc(func(ctx context.Context, request resolver.Request) (string, error) {
+		return latest[request.Package], nil
+	}))
+
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nimage: app:1.0.0\nredis: 6.0.0 # depup package=redis\n# depup package=db\ndb: v1.4.0\n# depup package=cli\ncli: 3.0.0\n",
+	})
+	configPath := filepath.Join(t.TempDir(), "depup.yaml")
+	config := "packages:\n" +
+		"  - name: app\n    version: 1.0.0\n    datasource: outdated-mock\n" +
+		"  - name: redis\n    datasource: outdated-mock\n" +
+		"  - name: db\n    datasource: outdated-mock\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name: "configured versions",
+			expected: []string{
+				"app.yaml:2 app 1.0.0 1.0.0 up to date",
+				"app.yaml:3 redis 6.0.0 none unknown",
+				"app.yaml:5 db 1.4.0 none unknown",
+				"app.yaml:7 cli 3.0.0 none unknown",
+			},
+		},
+		{
+			name: "online",
+			args: []string{"--online"},
+			expected: []string{
+				"app.yaml:2 app 1.0.0 2.0.0 behind",
+				"app.yaml:3 redis 6.0.0 6.0.0 up to date",
+				"app.yaml:5 db 1.4.0 v1.5.0 behind",
+				"app.yaml:7 cli 3.0.0 none unknown",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"outdated", ".", "--config", configPath}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if err != nil {
+				t.Fatalf("outdated unexpected error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+			if len(lines) != len(tt.expected)+1 || !strings.HasPrefix(lines[0], "FILE") {
+				t.Fatalf("outdated output = %q, expected a header and %d rows", output, len(tt.expected))
+			}
+			for i, expected := range tt.expected {
+				if row := strings.Join(strings.Fields(lines[i+1]), " "); row != expected {
+					t.Errorf("outdated row %d = %q, expected %q", i+1, row, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestOutdatedStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		latest   string
+		known    bool
+		expected string
+	}{
+		{name: "behind", current: "1.0.0", latest: "1.1.0", known: true, expected: outdatedStatusBehind},
+		{name: "equal", current: "1.1.0", latest: "1.1.0", known: true, expected: outdatedStatusUpToDate},
+		{name: "ahead", current: "2.0.0", latest: "1.1.0", known: true, expected: outdatedStatusUpToDate},
+		{name: "prefix ignored", current: "v1.1.0", latest: "1.2.0", known: true, expected: outdatedStatusBehind},
+		{name: "latest unknown", current: "1.0.0", known: false, expected: outdatedStatusUnknown},
+		{name: "no current version", latest: "1.0.0", known: true, expected: outdatedStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := outdatedStatus(tt.current, tt.latest, tt.known); status != tt.expected {
+				t.Errorf("outdatedStatus(%q, %q, %t) = %q, expected %q", tt.current, tt.latest, tt.known, status, tt.expected)
+			}
+		})
+	}
+}
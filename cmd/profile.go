@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/spf13/pflag"
+)
+
+// startProfiling starts the CPU profile requested by --cpuprofile and returns the function ending the profiles
+// The heap profile requested by --memprofile is written when profiling ends, so it reflects the whole run.
+func startProfiling(flags *pflag.FlagSet) (func() error, error) {
+	cpuProfile, _ := flags.GetString("cpuprofile")
+	memProfile, _ := flags.GetString("memprofile")
+
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		var err error
+		cpuFile, err = os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			_ = cpuFile.Close()
+			return nil, fmt.Errorf("cannot start CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		var errs []error
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			errs = append(errs, cpuFile.Close())
+		}
+		if memProfile != "" {
+			errs = append(errs, writeHeapProfile(memProfile))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile of the live objects to the file
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create memory profile: %w", err)
+	}
+	defer file.Close()
+
+	// Collect garbage first, so the profile only holds objects that are still in use
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		return fmt.Errorf("cannot write memory profile: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_Profiles(t *testing.T) {
+	t.Cleanup(func() { resetFlags(rootCmd) })
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{"app.yaml": "version: 1.0.0 # depup package=app\n"})
+	cpuProfile := filepath.Join(tempDir, "cpu.pprof")
+	memProfile := filepath.Join(tempDir, "mem.pprof")
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs([]string{"update", tempDir, "-p", "app=2.0.0", "--cpuprofile", cpuProfile, "--memprofile", memProfile})
+	if err := Execute(); err != nil {
+		t.Fatalf("Execute() error = %v, output %q", err, out.String())
+	}
+
+	for _, profile := range []string{cpuProfile, memProfile} {
+		info, err := os.Stat(profile)
+		if err != nil {
+			t.Fatalf("expected profile %s to be written: %v", filepath.Base(profile), err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("profile %s is empty", filepath.Base(profile))
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// stopProfiling ends the profiles started for the running command, see startProfiling
+var stopProfiling = func() error { return nil }
+
 // rootCmd represents the base command when called without any subcommands.
 // All other commands are added as subcommands to this root command.
 var rootCmd = &cobra.Command{
@@ -11,6 +14,15 @@ var rootCmd = &cobra.Command{
 	Short: "A tool for dependency management", // Displayed in help output
 	Long: `Depup is a CLI tool that helps manage and update dependencies
 in your projects efficiently and reliably.`, // Detailed description for help
+	// Profiling starts once the flags are parsed, Execute stops it after the command
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		stop, err := startProfiling(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		stopProfiling = stop
+		return nil
+	},
 	// No Run function as this command serves as a container for subcommands
 }
 
@@ -18,7 +30,16 @@ in your projects efficiently and reliably.`, // Detailed description for help
 // This function is called by main.main(). It only needs to happen once.
 func Execute() error {
 	// Execute will run the command and return any errors
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+
+	// Profiles cover the whole command, even if it failed
+	stop := stopProfiling
+	stopProfiling = func() error { return nil }
+	if stopErr := stop(); stopErr != nil && err == nil {
+		err = stopErr
+	}
+
+	return err
 }
 
 func init() {
@@ -28,4 +49,10 @@ func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file path")
 	// StringP defines a flag with a string value and a short flag alternative
 	// The arguments are: name, shorthand, default value, and usage/description
+
+	// Hidden flags to profile large runs with go tool pprof
+	rootCmd.PersistentFlags().String("cpuprofile", "", "Write a CPU profile of the run to the file")
+	rootCmd.PersistentFlags().String("memprofile", "", "Write a memory profile at the end of the run to the file")
+	_ = rootCmd.PersistentFlags().MarkHidden("cpuprofile")
+	_ = rootCmd.PersistentFlags().MarkHidden("memprofile")
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// executeCommand runs the root command with the given arguments and returns its output
+// Flags of all commands are reset afterwards so tests don't leak state into each other
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() { resetFlags(rootCmd) })
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+
+	err := rootCmd.Execute()
+
+	return out.String(), err
+}
+
+// resetFlags restores the default value of every flag of the command and its subcommands
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			var defaults []string
+			if trimmed := strings.Trim(flag.DefValue, "[]"); trimmed != "" {
+				defaults = strings.Split(trimmed, ",")
+			}
+			if flag.Value.Type() == "stringArray" {
+				// A replaced string array stays marked as set, so the next flag would append to the defaults
+				fresh := pflag.NewFlagSet(flag.Name, pflag.ContinueOnError)
+				fresh.StringArray(flag.Name, defaults, flag.Usage)
+				flag.Value = fresh.Lookup(flag.Name).Value
+			} else {
+				_ = sliceValue.Replace(defaults)
+			}
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
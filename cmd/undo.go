@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dtomasi/depup/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// backupSuffix is appended to the name of a file to name its backup, e.g. app.yaml.bak
+const backupSuffix = ".bak"
+
+// defaultUndoOlderThan keeps undo from restoring backups left behind by an earlier, unrelated run
+const defaultUndoOlderThan = 24 * time.Hour
+
+// undoCmd restores files from their .bak backups
+var undoCmd = &cobra.Command{
+	Use:   "undo DIR",
+	Short: "Restore files from their " + backupSuffix + " backups",
+	Long: `Find the FILE` + backupSuffix + ` backup of every file in DIR that depup would scan and move it back over FILE,
+reverting the changes made since the backup was taken. Backups of other files, like notes.txt` + backupSuffix + `, and
+backups whose FILE no longer exists are left alone. Backups older than --older-than are considered stale and skipped
+with a warning. With --dry-run, the files that would be restored are only printed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Merge settings like the update command, so undo considers the same files: flags > environment > configuration file
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+		u := updater.NewUpdater(
+			updater.WithRecursive(recursive),
+			updater.WithFileExtensions(resolveExtensions(rawExtensions, defaultExtensions)),
+			updater.WithExcludes(excludes),
+		)
+		backups, err := findBackups(u, args[0])
+		if err != nil {
+			return err
+		}
+
+		restored := 0
+		for _, backup := range backups {
+			original := strings.TrimSuffix(backup, backupSuffix)
+
+			fileInfo, err := os.Stat(backup)
+			if err != nil {
+				return err
+			}
+			if age := time.Since(fileInfo.ModTime()); olderThan > 0 && age > olderThan {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: skipped %s: backup is older than %s\n", displayFile(backup), olderThan)
+				continue
+			}
+
+			if dryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Would restore %s from %s\n", displayFile(original), displayFile(backup))
+				continue
+			}
+			if err := os.Rename(backup, original); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", original, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %s from %s\n", displayFile(original), displayFile(backup))
+			restored++
+		}
+
+		if !dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %d file(s)\n", restored)
+		}
+
+		return nil
+	},
+}
+
+// findBackups returns the backups of the files in dir that the updater would scan, sorted by path
+// Only backups next to an existing file are returned, so backups of unrelated files and of deleted files are ignored.
+func findBackups(u *updater.Updater, dir string) ([]string, error) {
+	files, err := u.Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, file := range files {
+		backup := file + backupSuffix
+		fileInfo, err := os.Stat(backup)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fileInfo.Mode().IsRegular() {
+			backups = append(backups, backup)
+		}
+	}
+
+	return backups, nil
+}
+
+func init() {
+	// Register the undo command as a subcommand of the root command
+	rootCmd.AddCommand(undoCmd)
+
+	// Flags affecting which files are restored, mirroring the update command
+	undoCmd.Flags().BoolP("recursive", "r", false, "Look up backups in subdirectories as well")
+	undoCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+	undoCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+	undoCmd.Flags().BoolP("dry-run", "d", false, "Only print the files that would be restored")
+	undoCmd.Flags().Duration("older-than", defaultUndoOlderThan, "Skip backups older than this duration as stale (0 restores backups of any age)")
+}
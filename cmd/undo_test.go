@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUndoCmd(t *testing.T) {
+	const (
+		original = "# depup package=app\nversion: 1.0.0\n"
+		updated  = "# depup package=app\nversion: 2.0.0\n"
+	)
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput []string
+		expected       map[string]string
+		missing        []string // Files that must not exist after the run
+	}{
+		{
+			name:           "restores backups",
+			expectedOutput: []string{"Restored app.yaml from app.yaml.bak", "Restored 1 file(s)"},
+			expected:       map[string]string{"app.yaml": original, "nested/app.yaml": updated, "stale.yaml": updated},
+		},
+		{
+			name:           "backups of files depup doesn't scan are left alone",
+			args:           []string{"-r", "--older-than", "0"},
+			expectedOutput: []string{"Restored 3 file(s)"},
+			expected:       map[string]string{"notes.txt": "notes\n", "notes.txt.bak": "old notes\n", "deleted.yaml.bak": original, "main.tf.bak": "version = \"1.0.0\"\n"},
+			missing:        []string{"deleted.yaml"},
+		},
+		{
+			name:           "excluded files are left alone",
+			args:           []string{"-r", "-x", "nested"},
+			expectedOutput: []string{"Restored 1 file(s)"},
+			expected:       map[string]string{"app.yaml": original, "nested/app.yaml": updated, "nested/app.yaml.bak": original},
+		},
+		{
+			name:           "extensions select the files",
+			args:           []string{"-e", ".tf"},
+			expectedOutput: []string{"Restored main.tf from main.tf.bak", "Restored 1 file(s)"},
+			expected:       map[string]string{"app.yaml": updated, "main.tf": "version = \"1.0.0\"\n"},
+		},
+		{
+			name:           "recursive",
+			args:           []string{"-r"},
+			expectedOutput: []string{"Restored 2 file(s)"},
+			expected:       map[string]string{"app.yaml": original, "nested/app.yaml": original, "stale.yaml": updated},
+		},
+		{
+			name:           "dry run",
+			args:           []string{"--dry-run"},
+			expectedOutput: []string{"Would restore app.yaml from app.yaml.bak"},
+			expected:       map[string]string{"app.yaml": updated, "app.yaml.bak": original, "stale.yaml": updated},
+		},
+		{
+			name:           "stale backups are skipped",
+			expectedOutput: []string{"Warning: skipped stale.yaml.bak: backup is older than 24h0m0s"},
+			expected:       map[string]string{"stale.yaml": updated, "stale.yaml.bak": original},
+		},
+		{
+			name:           "any age",
+			args:           []string{"--older-than", "0"},
+			expectedOutput: []string{"Restored stale.yaml from stale.yaml.bak", "Restored 2 file(s)"},
+			expected:       map[string]string{"app.yaml": original, "stale.yaml": original},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{
+				"app.yaml":            updated,
+				"app.yaml.bak":        original,
+				"nested/app.yaml":     updated,
+				"nested/app.yaml.bak": original,
+				"stale.yaml":          updated,
+				"stale.yaml.bak":      original,
+				"notes.txt":           "notes\n",
+				"notes.txt.bak":       "old notes\n",
+				"deleted.yaml.bak":    original,
+				"main.tf":             "version = \"2.0.0\"\n",
+				"main.tf.bak":         "version = \"1.0.0\"\n",
+			})
+			staleTime := time.Now().Add(-48 * time.Hour)
+			if err := os.Chtimes(filepath.Join(tempDir, "stale.yaml.bak"), staleTime, staleTime); err != nil {
+				t.Fatalf("failed to age backup: %v", err)
+			}
+			t.Chdir(tempDir)
+
+			output, err := executeCommand(t, append([]string{"undo", "."}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("undo unexpected error: %v", err)
+			}
+			for _, expected := range tt.expectedOutput {
+				if !strings.Contains(output, expected) {
+					t.Errorf("undo output = %q, expected it to contain %q", output, expected)
+				}
+			}
+
+			for name, expected := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(content) != expected {
+					t.Errorf("%s = %q, expected %q", name, string(content), expected)
+				}
+			}
+			for _, name := range tt.missing {
+				if _, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Errorf("%s exists, expected it to be missing (err: %v)", name, err)
+				}
+			}
+		})
+	}
+}
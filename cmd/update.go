@@ -1,13 +1,31 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dtomasi/depup/internal/config"
+	"github.com/dtomasi/depup/internal/resolver"
 	"github.com/dtomasi/depup/internal/updater"
 	"github.com/spf13/cobra"
 )
 
+// datasources holds the datasources configured packages can be resolved from
+var datasources = resolver.NewRegistry()
+
+// defaultExtensions are scanned unless --extension adds other extensions
+var defaultExtensions = []string{".yaml", ".yml"}
+
 // updateCmd represents the update command for updating dependencies
 var updateCmd = &cobra.Command{
 	Use:   "update DIR", // Command syntax showing required directory argument
@@ -17,30 +35,314 @@ var updateCmd = &cobra.Command{
 	// RunE allows returning an error instead of just handling it internally
 	// This provides better error handling and is more testable
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Seed flags that weren't passed explicitly from the config file and DEPUP_* environment variables
+		// Precedence: flags > environment > config file
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		if err := applyEnvDefaults(cmd.Flags(), updateEnvVars); err != nil {
+			return err
+		}
+
 		// Retrieve flag values by name
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		recursive, _ := cmd.Flags().GetBool("recursive")
 		rawPackages, _ := cmd.Flags().GetStringArray("package")
-		fileExtensions, _ := cmd.Flags().GetStringArray("extension")
+		rawExtensions, _ := cmd.Flags().GetStringArray("extension")
+		relativePaths, _ := cmd.Flags().GetBool("relative-paths")
+		respectEditorConfig, _ := cmd.Flags().GetBool("respect-editorconfig")
+		count, _ := cmd.Flags().GetBool("count")
+		readOnlyCheck, _ := cmd.Flags().GetBool("read-only-check")
+		summaryOnly, _ := cmd.Flags().GetBool("dry-run-summary-only")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		quoteStyle, _ := cmd.Flags().GetString("quote-style")
+		forceWrite, _ := cmd.Flags().GetBool("force-write")
+		scheme, _ := cmd.Flags().GetString("scheme")
+		updateMode, _ := cmd.Flags().GetString("update-mode")
+		commentPosition, _ := cmd.Flags().GetString("comment-position")
+		excludes, _ := cmd.Flags().GetStringArray("exclude")
+		rawCommentPrefixes, _ := cmd.Flags().GetStringArray("comment-prefix")
+		rawCommentRegex, _ := cmd.Flags().GetString("comment-regex")
+		printFiles, _ := cmd.Flags().GetBool("print-files")
+		dereference, _ := cmd.Flags().GetBool("dereference-config-packages")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		cacheDir, _ := cmd.Flags().GetString("cache-dir")
+		cacheTTL, _ := cmd.Flags().GetDuration("cache-ttl")
+		noCache, _ := cmd.Flags().GetBool("no-cache")
+		registryAuth, _ := cmd.Flags().GetString("registry-auth")
+		reportFormat, _ := cmd.Flags().GetString("report-format")
+		showSource, _ := cmd.Flags().GetBool("show-version-source")
+		sarifLevel, _ := cmd.Flags().GetString("sarif-level")
+		canonical, _ := cmd.Flags().GetBool("canonical-versions")
+		allowLeadingZeros, _ := cmd.Flags().GetBool("allow-leading-zeros")
+		prefixAuto, _ := cmd.Flags().GetBool("version-prefix-auto")
+		syncCommentVersion, _ := cmd.Flags().GetBool("sync-comment-version")
+		nestedVersions, _ := cmd.Flags().GetBool("nested-versions")
+		errorOnMultipleMatches, _ := cmd.Flags().GetBool("error-on-multiple-matches")
+		quarantineOnConflict, _ := cmd.Flags().GetBool("quarantine-on-conflict")
+		strictSemver, _ := cmd.Flags().GetBool("strict-semver")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		changelog, _ := cmd.Flags().GetString("changelog")
+		progress, _ := cmd.Flags().GetString("progress")
+		rawMaxFileSize, _ := cmd.Flags().GetString("max-file-size")
+		rawFileMode, _ := cmd.Flags().GetString("file-mode")
+		transactional, _ := cmd.Flags().GetBool("no-write-on-partial-failure")
+		verify, _ := cmd.Flags().GetBool("verify")
+		dumpConfig, _ := cmd.Flags().GetBool("dump-effective-config")
+		onUnparseable, _ := cmd.Flags().GetString("on-unparseable")
+		warningsAsErrors, _ := cmd.Flags().GetBool("treat-warnings-as-errors")
+		reportOnlyErrors, _ := cmd.Flags().GetBool("report-only-errors")
+		stepSummary, _ := cmd.Flags().GetBool("step-summary")
+		if !cmd.Flags().Changed("step-summary") {
+			stepSummary = os.Getenv(stepSummaryEnvVar) != ""
+		}
+		if stepSummary && os.Getenv(stepSummaryEnvVar) == "" {
+			return fmt.Errorf("--step-summary requires the %s environment variable", stepSummaryEnvVar)
+		}
 
-		var packages []updater.Package
-		for _, pkg := range rawPackages {
-			// Split the package into name and version
-			parts := strings.Split(pkg, "=")
-			packages = append(packages, updater.Package{Name: parts[0], Version: parts[1]})
+		// Transactional runs only write changed files, so rewriting unchanged files would be silently ignored
+		if forceWrite && transactional && !dryRun {
+			return fmt.Errorf("--force-write cannot be combined with --no-write-on-partial-failure, which only writes changed files")
 		}
 
-		if len(packages) == 0 {
-			return fmt.Errorf("no packages to update")
+		if groupBy != updater.GroupByFile && groupBy != updater.GroupByPackage {
+			return fmt.Errorf("invalid --group-by value %q: expected %q or %q", groupBy, updater.GroupByFile, updater.GroupByPackage)
+		}
+
+		switch quoteStyle {
+		case "", updater.QuoteStyleDouble, updater.QuoteStyleSingle, updater.QuoteStyleNone:
+		default:
+			return fmt.Errorf("invalid --quote-style value %q: expected %q, %q or %q",
+				quoteStyle, updater.QuoteStyleDouble, updater.QuoteStyleSingle, updater.QuoteStyleNone)
+		}
+
+		if scheme != updater.SchemeSemver && scheme != updater.SchemePartial && scheme != updater.SchemeInteger && scheme != updater.SchemeGomod {
+			return fmt.Errorf("invalid --scheme value %q: expected %q, %q, %q or %q", scheme, updater.SchemeSemver, updater.SchemePartial, updater.SchemeInteger, updater.SchemeGomod)
+		}
+
+		if updateMode != updater.UpdateModeRange && updateMode != updater.UpdateModeConstraint && updateMode != updater.UpdateModePin {
+			return fmt.Errorf("invalid --update-mode value %q: expected %q, %q or %q", updateMode, updater.UpdateModeRange, updater.UpdateModeConstraint, updater.UpdateModePin)
+		}
+
+		if commentPosition != updater.CommentPositionAbove && commentPosition != updater.CommentPositionBelow {
+			return fmt.Errorf("invalid --comment-position value %q: expected %q or %q", commentPosition, updater.CommentPositionAbove, updater.CommentPositionBelow)
+		}
+
+		commentPrefixes, err := parseCommentPrefixes(rawCommentPrefixes)
+		if err != nil {
+			return err
+		}
+		commentRegex, err := parseCommentRegex(rawCommentRegex)
+		if err != nil {
+			return err
+		}
+
+		switch reportFormat {
+		case updater.FormatText, updater.FormatJSON, updater.FormatSARIF, updater.FormatLines, updater.FormatJSONLines:
+		default:
+			return fmt.Errorf("invalid --report-format value %q: expected %q, %q, %q, %q or %q",
+				reportFormat, updater.FormatText, updater.FormatJSON, updater.FormatSARIF, updater.FormatLines, updater.FormatJSONLines)
+		}
+		compactJSON, prettyJSON, err := jsonFormat(cmd.Flags())
+		if err != nil {
+			return err
 		}
 
-		updater := updater.NewUpdater(
+		if progress != "" && progress != progressAuto && progress != progressAlways {
+			return fmt.Errorf("invalid --progress value %q: expected %q or %q", progress, progressAuto, progressAlways)
+		}
+
+		if sarifLevel != updater.SARIFLevelError && sarifLevel != updater.SARIFLevelWarning && sarifLevel != updater.SARIFLevelNote {
+			return fmt.Errorf("invalid --sarif-level value %q: expected %q, %q or %q", sarifLevel, updater.SARIFLevelError, updater.SARIFLevelWarning, updater.SARIFLevelNote)
+		}
+
+		if onUnparseable != onUnparseableSkip && onUnparseable != onUnparseableError {
+			return fmt.Errorf("invalid --on-unparseable value %q: expected %q or %q", onUnparseable, onUnparseableSkip, onUnparseableError)
+		}
+
+		maxFileSize, err := parseFileSize(rawMaxFileSize)
+		if err != nil {
+			return fmt.Errorf("invalid --max-file-size value %q: %w", rawMaxFileSize, err)
+		}
+
+		fileMode, err := parseFileMode(rawFileMode)
+		if err != nil {
+			return fmt.Errorf("invalid --file-mode value %q: %w", rawFileMode, err)
+		}
+
+		fileExtensions := resolveExtensions(rawExtensions, defaultExtensions)
+		if len(fileExtensions) == 0 {
+			return fmt.Errorf("invalid --extension values %v: no file extensions left to scan", rawExtensions)
+		}
+
+		// Bound the whole run, including resolving packages, if a timeout is set
+		ctx := cmd.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		// Warnings are counted, so they can fail the run once it is done
+		warnings := &warningCollector{w: cmd.ErrOrStderr()}
+
+		// Count, check and summary modes only need the changes, so nothing is written or reported
+		output := cmd.OutOrStdout()
+		if count || readOnlyCheck || summaryOnly {
+			dryRun = true
+			output = io.Discard
+		}
+		// Quiet runs only print warnings and errors, which go to stderr, so the usage isn't printed on errors either
+		if reportOnlyErrors {
+			output = io.Discard
+			cmd.SilenceUsage = true
+		}
+
+		u := updater.NewUpdater(
 			updater.WithDryRun(dryRun),
 			updater.WithRecursive(recursive),
 			updater.WithFileExtensions(fileExtensions),
+			updater.WithExcludes(excludes),
+			updater.WithRelativePaths(relativePaths),
+			updater.WithRespectEditorConfig(respectEditorConfig),
+			updater.WithOutput(output),
+			updater.WithGroupBy(groupBy),
+			updater.WithReportFormat(reportFormat),
+			updater.WithShowSource(showSource),
+			updater.WithCompactJSON(compactJSON),
+			updater.WithPrettyJSON(prettyJSON),
+			updater.WithSARIFLevel(sarifLevel),
+			updater.WithStrictSemver(strictSemver),
+			updater.WithQuoteStyle(quoteStyle),
+			updater.WithCommentPosition(commentPosition),
+			updater.WithCommentPrefixes(commentPrefixes),
+			updater.WithCommentRegex(commentRegex),
+			updater.WithForceWrite(forceWrite),
+			updater.WithTransactional(transactional),
+			updater.WithVerify(verify),
+			updater.WithScheme(scheme),
+			updater.WithUpdateMode(updateMode),
+			updater.WithCanonicalVersions(canonical),
+			updater.WithAllowLeadingZeros(allowLeadingZeros),
+			updater.WithVersionPrefixAuto(prefixAuto),
+			updater.WithSyncCommentVersion(syncCommentVersion),
+			updater.WithNestedVersions(nestedVersions),
+			updater.WithErrorOnMultipleMatches(errorOnMultipleMatches),
+			updater.WithQuarantineOnConflict(quarantineOnConflict),
+			updater.WithProgress(progressOutput(cmd.ErrOrStderr(), progress)),
+			updater.WithMaxFileSize(maxFileSize),
+			updater.WithFileMode(fileMode),
+			updater.WithWarnings(warnings),
 		)
 
-		return updater.Update(args[0], packages)
+		// Dump mode prints the merged settings for debugging their precedence, nothing is scanned or resolved
+		if dumpConfig {
+			packages, err := parsePackages(rawPackages)
+			if err != nil {
+				return err
+			}
+			content, err := config.Marshal(effectiveConfig(cmd.Flags(), cfg, packages))
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(content)
+			return err
+		}
+
+		// Print files mode only runs the discovery, no packages are needed
+		if printFiles {
+			return printDiscoveredFiles(cmd, u, args[0], relativePaths)
+		}
+
+		packages, err := parsePackages(rawPackages)
+		if err != nil {
+			return err
+		}
+		if err := configureRegistryAuth(registryAuth); err != nil {
+			return err
+		}
+		versionResolver, err := newVersionResolver(cmd, cacheDir, cacheTTL, noCache)
+		if err != nil {
+			return err
+		}
+		if err := dereferenceConfigPackages(ctx, cfg, versionResolver, dereference); err != nil {
+			return timeoutError(err, timeout)
+		}
+		if err := checkResolvedVersions(warnings, cfg, onUnparseable); err != nil {
+			return err
+		}
+		packages = mergeConfigPackages(packages, cfg)
+
+		if len(packages) == 0 {
+			return fmt.Errorf("no packages to update")
+		}
+
+		// Strict mode also flags the versions in the files, before updating replaces them
+		if strictSemver {
+			if err := warnNonStrictVersions(warnings, u, args[0], packages, relativePaths); err != nil {
+				return err
+			}
+		}
+
+		// Interactive mode plans all changes first and only applies the confirmed ones
+		if interactive && !dryRun {
+			plan, err := u.PlanContext(ctx, args[0], packages)
+			if err != nil {
+				return timeoutError(err, timeout)
+			}
+			confirmed, err := confirmChanges(cmd.InOrStdin(), cmd.OutOrStdout(), plan)
+			if err != nil {
+				return err
+			}
+			if err := u.Apply(confirmed); err != nil {
+				return err
+			}
+			if err := writeChangelog(cmd, changelog, u.Changes()); err != nil {
+				return err
+			}
+			if err := writeStepSummary(stepSummary, groupBy, u.Changes(), false); err != nil {
+				return err
+			}
+			return warnings.check(cmd, warningsAsErrors)
+		}
+
+		if err := u.UpdateContext(ctx, args[0], packages); err != nil {
+			return timeoutError(err, timeout)
+		}
+		if err := writeChangelog(cmd, changelog, u.Changes()); err != nil {
+			return err
+		}
+		if err := writeStepSummary(stepSummary, groupBy, u.Changes(), dryRun); err != nil {
+			return err
+		}
+
+		if readOnlyCheck {
+			if err := reportOutdatedAnnotations(cmd.OutOrStdout(), u.Changes()); err != nil {
+				// Outdated annotations are an expected outcome, not a usage mistake
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+
+		if summaryOnly {
+			updater.NewReporter(cmd.OutOrStdout(), updater.ReportOptions{}).ReportPackageTotals(u.Changes())
+		}
+
+		if count {
+			changedFiles := len(u.ChangedFiles())
+			fmt.Fprintln(cmd.OutOrStdout(), changedFiles)
+			if changedFiles > 0 {
+				// Pending updates are an expected outcome, not a usage mistake
+				cmd.SilenceUsage = true
+				return withExitCode(fmt.Errorf("%d file(s) need updates", changedFiles), ExitChangesNeeded)
+			}
+		}
+
+		return warnings.check(cmd, warningsAsErrors)
 	},
 }
 
@@ -51,12 +353,490 @@ func init() {
 	// Flag to specify dry-run mode for update command
 	updateCmd.Flags().BoolP("dry-run", "d", false, "Show what would be updated without making changes")
 
+	// Flag to confirm every change before it is applied
+	updateCmd.Flags().BoolP("interactive", "i", false, "Ask before applying each change (y: apply, n: skip, a: apply all remaining, q: quit)")
+
 	// Flag to specify recursive lookup for files in a directory
 	updateCmd.Flags().BoolP("recursive", "r", false, "Make depup lookup for files recursively, if a directory is passed as argument")
 
 	// Flag to specify packages to update in the format IDENTIFIER=SEMVER_VERSION
 	updateCmd.Flags().StringArrayP("package", "p", []string{}, "Specify dependencies to update in the format IDENTIFIER=SEMVER_VERSION (-p package=1.2.3)")
 
-	// Flag to specify file extensions to include in the search
-	updateCmd.Flags().StringArrayP("extension", "e", []string{".yaml", ".yml"}, "Specify file extensions to include in the search")
+	// Flag to specify file extensions to include in the search, a leading "-" removes an extension
+	updateCmd.Flags().StringArrayP("extension", "e", defaultExtensions, "Specify file extensions to include in the search, prefix with \"-\" to remove one (-e -.yml)")
+
+	// Flag to print paths relative to the working directory in reports
+	updateCmd.Flags().Bool("relative-paths", false, "Report file paths relative to the current working directory")
+
+	// Flag to apply .editorconfig rules (end_of_line, insert_final_newline, charset) to written files
+	updateCmd.Flags().Bool("respect-editorconfig", false, "Apply end_of_line, insert_final_newline and charset from .editorconfig to updated files")
+
+	// Flag to group the change report by file or by package
+	updateCmd.Flags().String("group-by", updater.GroupByFile, "Group the change report by \"file\" or \"package\"")
+
+	// Flag to normalize the quotes of updated YAML versions
+	updateCmd.Flags().String("quote-style", "", "Quote updated YAML versions as \"double\", \"single\" or \"none\" (default: keep existing quotes)")
+
+	// Flag to select the report format
+	updateCmd.Flags().String("report-format", updater.FormatText, "Format of the change report: \"text\", \"json\", \"sarif\", \"lines\" (one \"file:line package old -> new\" per change) or \"jsonl\" (one JSON object per change)")
+
+	// Flag to set the level of results in SARIF reports
+	updateCmd.Flags().String("sarif-level", updater.SARIFLevelWarning, "Level of results in SARIF reports: \"error\", \"warning\" or \"note\"")
+
+	// Flag to write a Markdown changelog of the changes, to stdout or to a file
+	updateCmd.Flags().String("changelog", "", "Write a Markdown changelog of the updates grouped by package to a file, or to stdout if no file is given")
+	updateCmd.Flags().Lookup("changelog").NoOptDefVal = changelogStdout
+
+	// Flag to print per-file progress to stderr on long runs
+	updateCmd.Flags().String("progress", "", "Print the progress of every processed file to stderr if it is a terminal, pass \"always\" to force it")
+	updateCmd.Flags().Lookup("progress").NoOptDefVal = progressAuto
+
+	// Flags to write JSON reports on a single line, e.g. for piping into other tools, or indented for reading
+	addJSONFormatFlags(updateCmd.Flags())
+
+	// Flag to state where each new version came from
+	updateCmd.Flags().Bool("show-version-source", false, "State in the report whether each new version came from a flag, the config file or a resolver")
+
+	// Flag to compare versions by semver precedence instead of their text
+	updateCmd.Flags().Bool("canonical-versions", false, "Treat versions with the same semver precedence as equal, e.g. 1.2 and 1.2.0 or differing build metadata")
+
+	// Flag to find zero-padded versions of legacy systems
+	updateCmd.Flags().Bool("allow-leading-zeros", false, "Find and update versions with zero-padded components like 01.2.3, which aren't semantic versions, dropping the padding")
+
+	// Flag to keep prefixes like "v" or "release-" of annotated versions
+	updateCmd.Flags().Bool("version-prefix-auto", false, "Keep a short prefix of annotated versions like \"v\" or \"release-\" and drop the prefix of package versions")
+
+	// Flag to keep the version recorded in depup comments in sync with the value
+	updateCmd.Flags().Bool("sync-comment-version", false, "Set the version= attribute of depup comments, e.g. \"# depup package=app version=1.2.3\", to the new version")
+
+	// Flag to look for the version of annotated YAML mapping keys in their children
+	updateCmd.Flags().Bool("nested-versions", false, "Update the first version-like child (e.g. version: or tag:) of an annotated YAML mapping key without a value")
+
+	// Flag to refuse guessing which of several versions of a line is annotated
+	updateCmd.Flags().Bool("error-on-multiple-matches", false, "Fail instead of updating the first version of a line holding several versions for a single package")
+
+	// Flag to set aside updates that can't be applied confidently, like patch does with rejected hunks
+	updateCmd.Flags().Bool("quarantine-on-conflict", false, "Leave ambiguous lines and lines kept by a from= or downgrade guard unchanged and write their intended update to FILE.rej")
+
+	// Flag to only print warnings and errors, e.g. for cron jobs
+	updateCmd.Flags().Bool("report-only-errors", false, "Apply updates without printing the change report, only warnings and errors are printed")
+
+	// Flag to append a Markdown table of the changes to the job summary of a GitHub Actions step
+	updateCmd.Flags().Bool("step-summary", false, "Append a Markdown table of the changes to the file named by $GITHUB_STEP_SUMMARY (default: true if it is set)")
+
+	// Flag to only accept strict semantic versions
+	updateCmd.Flags().Bool("strict-semver", false, "Reject package versions that aren't strict semantic versions (MAJOR.MINOR.PATCH without leading zeros)")
+
+	// Flag to rewrite annotated files even if no version changed
+	updateCmd.Flags().Bool("force-write", false, "Rewrite files with annotated versions even if no version changed")
+
+	// Flag to select the version scheme of depup comments without a scheme attribute
+	updateCmd.Flags().String("scheme", updater.SchemeSemver, "Version scheme for depup comments without a scheme attribute: \"semver\", \"partial\" (MAJOR.MINOR), \"integer\" or \"gomod\" (Go module versions)")
+
+	// Flag to select how updates of constraints like ">= 4.0.0, < 5.0.0" treat their operators
+	updateCmd.Flags().String("update-mode", updater.UpdateModeRange, "Treatment of constraints for depup comments without a mode attribute: \"range\" (update the lower bound), \"constraint\" (keep only the lower bound's operator) or \"pin\" (exact version)")
+
+	// Flag to select whether depup comments on their own line annotate the version above or below them
+	updateCmd.Flags().String("comment-position", updater.CommentPositionAbove, "Whether depup comments on their own line annotate the version \"above\" or \"below\" them")
+
+	// Flag to recognize depup comments by other comment prefixes, e.g. ";" in INI-style files
+	updateCmd.Flags().StringArray("comment-prefix", []string{}, commentPrefixUsage)
+
+	// Flag to replace the depup comment syntax with a custom pattern
+	updateCmd.Flags().String("comment-regex", "", commentRegexUsage)
+
+	// Flag to skip files and directories matching glob patterns
+	updateCmd.Flags().StringArrayP("exclude", "x", []string{}, "Skip files and directories matching the glob pattern (relative path or base name)")
+
+	// Flag to only list the files that would be scanned
+	updateCmd.Flags().Bool("print-files", false, "Only print the files that would be scanned, without reading them")
+
+	// Flag to resolve configured packages without a version from their datasource
+	updateCmd.Flags().Bool("dereference-config-packages", false, "Resolve the version of configured packages that name a datasource instead of a version")
+
+	// Flag to choose what happens to packages resolved to a version that can't be written
+	updateCmd.Flags().String("on-unparseable", onUnparseableSkip, "What to do with packages resolved to a version depup can't write, e.g. 1.2.3.post1: \"skip\" with a warning or \"error\"")
+
+	// Flags to reuse versions resolved from datasources between runs
+	updateCmd.Flags().String("cache-dir", "", "Directory of the resolver cache (default: depup in the user cache directory, e.g. $XDG_CACHE_HOME/depup)")
+	updateCmd.Flags().Duration("cache-ttl", resolver.DefaultCacheTTL, "How long resolved versions are reused (0 disables the cache)")
+	updateCmd.Flags().Bool("no-cache", false, "Always resolve versions from their datasource, ignoring the resolver cache")
+
+	// Flag to authenticate datasources against private registries
+	updateCmd.Flags().String("registry-auth", "", "Netrc-style file with credentials for private registries, DEPUP_AUTH_<HOST> variables take precedence")
+
+	// Flag to bound the duration of the whole run
+	updateCmd.Flags().Duration("timeout", 0, "Abort the run if it takes longer than this duration, e.g. 30s (0 disables the timeout)")
+
+	// Flag to write either all updated files or none of them
+	updateCmd.Flags().Bool("no-write-on-partial-failure", false, "Compute all updates before writing and restore written files if any file fails, so no directory is left half-updated")
+
+	// Flag to read written files back as a self-check
+	updateCmd.Flags().Bool("verify", false, "Read every written file back and fail if it differs from the expected content or lacks a new version")
+
+	// Flag to fail strict CI runs on any warning
+	updateCmd.Flags().Bool("treat-warnings-as-errors", false, "Exit with an error after the run if any warning was printed, e.g. for a skipped file or package")
+
+	// Flag to skip huge files matched by accident
+	updateCmd.Flags().String("max-file-size", defaultMaxFileSize, "Skip files larger than this size with a warning, e.g. 512KB or 10MB (0 disables the limit)")
+
+	// Flag to set the permissions of written files instead of keeping them
+	updateCmd.Flags().String("file-mode", "", "Set the permissions of updated files to this octal mode, e.g. 0644 (default: keep the permissions of each file)")
+
+	// Flag to print the settings resolved from flags, environment and config file instead of updating
+	updateCmd.Flags().Bool("dump-effective-config", false, "Print the configuration merged from flags, environment variables and the config file as YAML and exit")
+
+	// Flag to fail with the versions that need updates, e.g. in a pre-commit hook
+	updateCmd.Flags().Bool("read-only-check", false, "Only print one \"file:line\" line per outdated version with the command fixing it (implies --dry-run) and fail if there is any")
+
+	// Flag to only print how many files each package would change, for huge change sets
+	updateCmd.Flags().Bool("dry-run-summary-only", false, "Only print the number of files each package would change, e.g. \"app: 120 files\" (implies --dry-run)")
+
+	// Flag to only print the number of files that would change
+	updateCmd.Flags().Bool("count", false, "Only print the number of files that need updates (implies --dry-run) and fail if it is not zero")
+}
+
+// timeoutError replaces errors caused by an exceeded deadline with a dedicated timeout error
+func timeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
+}
+
+// warnNonStrictVersions writes a warning for each version of the packages in the files that isn't a strict
+// semantic version, e.g. 1.2 or 1.01.0, even though it is updated as usual
+func warnNonStrictVersions(warnings io.Writer, u *updater.Updater, entrypoint string, packages []updater.Package, relativePaths bool) error {
+	result, err := u.Scan(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		updated[pkg.Name] = true
+	}
+	for _, annotation := range result.NonStrict {
+		if !updated[annotation.Package] {
+			continue
+		}
+		file := annotation.File
+		if relativePaths {
+			file = displayFile(file)
+		}
+		fmt.Fprintf(warnings, "Warning: %s:%d version %q of package %s is not a strict semantic version\n", file, annotation.Line, annotation.Version, annotation.Package)
+	}
+
+	return nil
+}
+
+// warningCollector passes warnings through to w and counts them
+// Every warning is written as a single line.
+type warningCollector struct {
+	w     io.Writer
+	count int
+}
+
+func (c *warningCollector) Write(p []byte) (int, error) {
+	c.count += bytes.Count(p, []byte("\n"))
+	return c.w.Write(p)
+}
+
+// check returns an error if warnings were written and they are treated as errors
+// The files were processed as usual, so no usage is printed.
+func (c *warningCollector) check(cmd *cobra.Command, treatAsErrors bool) error {
+	if !treatAsErrors || c.count == 0 {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d warning(s) treated as errors", c.count)
+}
+
+// newVersionResolver returns the resolver of configured packages, caching resolved versions on disk unless disabled
+// Cache hits and misses are logged to stderr
+func newVersionResolver(cmd *cobra.Command, cacheDir string, cacheTTL time.Duration, noCache bool) (resolver.VersionResolver, error) {
+	if noCache || cacheTTL <= 0 {
+		return datasources, nil
+	}
+
+	if cacheDir == "" {
+		var err error
+		cacheDir, err = resolver.DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolver.NewCachedResolver(datasources, cacheDir, cacheTTL, cmd.ErrOrStderr()), nil
+}
+
+// configureRegistryAuth sets the credentials datasources send to registries
+// DEPUP_AUTH_<HOST> environment variables are always consulted, before the netrc-style file if one is given
+func configureRegistryAuth(path string) error {
+	if path == "" {
+		datasources.SetAuth(resolver.NewEnvAuth())
+		return nil
+	}
+
+	netrc, err := resolver.LoadNetrcAuth(path)
+	if err != nil {
+		return err
+	}
+	datasources.SetAuth(resolver.ChainAuth{resolver.NewEnvAuth(), netrc})
+
+	return nil
+}
+
+// Values of --progress
+const (
+	progressAuto   = "auto"   // Print progress if stderr is a terminal
+	progressAlways = "always" // Print progress even if stderr is redirected
+)
+
+// progressOutput returns where progress is written for the --progress value, nil if it is disabled
+func progressOutput(stderr io.Writer, progress string) io.Writer {
+	switch progress {
+	case progressAlways:
+		return stderr
+	case progressAuto:
+		if file, ok := stderr.(*os.File); ok {
+			if fileInfo, err := file.Stat(); err == nil && fileInfo.Mode()&os.ModeCharDevice != 0 {
+				return stderr
+			}
+		}
+	}
+	return nil
+}
+
+// defaultMaxFileSize is large enough for any hand-written file but stops reading e.g. database dumps
+const defaultMaxFileSize = "10MB"
+
+// fileSizeUnits are the units of --max-file-size, multiples of 1024 bytes
+var /* const */ fileSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{suffix: "GB", bytes: 1 << 30},
+	{suffix: "MB", bytes: 1 << 20},
+	{suffix: "KB", bytes: 1 << 10},
+	{suffix: "B", bytes: 1},
+}
+
+// parseFileSize parses a size in bytes with an optional unit, e.g. "1024", "512KB" or "10MB"
+func parseFileSize(value string) (int64, error) {
+	number, multiplier := strings.ToUpper(strings.TrimSpace(value)), int64(1)
+	for _, unit := range fileSizeUnits {
+		if trimmed, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, multiplier = strings.TrimSpace(trimmed), unit.bytes
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.New("expected a non-negative number of bytes with an optional unit B, KB, MB or GB")
+	}
+	return size * multiplier, nil
+}
+
+// parseFileMode parses the octal permissions of --file-mode, e.g. "0644" or "644"
+// An empty value returns 0, which keeps the permissions of each file.
+func parseFileMode(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > 0o777 {
+		return 0, errors.New("expected octal permissions between 0001 and 0777")
+	}
+	return os.FileMode(mode), nil
+}
+
+// changelogStdout is the value of --changelog without a file, printing the changelog to stdout
+const changelogStdout = "-"
+
+// writeChangelog writes the Markdown changelog of the changes to stdout or a file, if requested
+func writeChangelog(cmd *cobra.Command, target string, changes []updater.Change) error {
+	if target == "" {
+		return nil
+	}
+	if target == changelogStdout {
+		updater.NewReporter(cmd.OutOrStdout(), updater.ReportOptions{}).ReportChangelog(changes)
+		return nil
+	}
+
+	var changelog bytes.Buffer
+	updater.NewReporter(&changelog, updater.ReportOptions{}).ReportChangelog(changes)
+	if err := os.WriteFile(target, changelog.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write changelog to %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// reportOutdatedAnnotations prints one line per change a read-only check found, with the command applying it
+// It returns an error with ExitChangesNeeded if there are any.
+func reportOutdatedAnnotations(w io.Writer, changes []updater.Change) error {
+	for _, change := range changes {
+		file := displayFile(change.File)
+		fmt.Fprintf(w, "%s:%d: %s is %s, expected %s (fix: depup update %s -p %s=%s)\n",
+			file, change.Line, change.Package, change.OldVersion, change.NewVersion, file, change.Package, change.NewVersion)
+	}
+	if len(changes) > 0 {
+		return withExitCode(fmt.Errorf("%d outdated version(s) found", len(changes)), ExitChangesNeeded)
+	}
+	return nil
+}
+
+// stepSummaryEnvVar names the job summary file of a GitHub Actions step
+const stepSummaryEnvVar = "GITHUB_STEP_SUMMARY"
+
+// writeStepSummary appends a Markdown table of the changes to the job summary file, if requested
+// Paths are relative to the working directory, which is the checkout of the repository in GitHub Actions.
+func writeStepSummary(enabled bool, groupBy string, changes []updater.Change, dryRun bool) error {
+	if !enabled {
+		return nil
+	}
+	target := os.Getenv(stepSummaryEnvVar)
+	options := updater.ReportOptions{GroupBy: groupBy}
+	if workingDir, err := os.Getwd(); err == nil {
+		options.BaseDir = workingDir
+	}
+	var summary bytes.Buffer
+	updater.NewReporter(&summary, options).ReportStepSummary(changes, dryRun)
+
+	file, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open step summary %s: %w", target, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(summary.Bytes()); err != nil {
+		return fmt.Errorf("failed to write step summary to %s: %w", target, err)
+	}
+	return file.Close()
+}
+
+// printDiscoveredFiles prints the files an update of the entrypoint would scan, one per line
+func printDiscoveredFiles(cmd *cobra.Command, u *updater.Updater, entrypoint string, relativePaths bool) error {
+	files, err := u.Discover(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	workingDir := ""
+	if relativePaths {
+		if workingDir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("cannot determine working directory: %w", err)
+		}
+	}
+
+	for _, file := range files {
+		if workingDir != "" {
+			if relFile, err := filepath.Rel(workingDir, file); err == nil {
+				file = relFile
+			}
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), file)
+	}
+
+	return nil
+}
+
+// commentPrefixUsage is the help of the --comment-prefix flag shared by the commands reading depup comments
+const commentPrefixUsage = "Recognize depup comments of an updater by other comment prefixes as UPDATER=PREFIX[,PREFIX], e.g. dotenv=\";\" (the first prefix starts written comments)"
+
+// parseCommentPrefixes parses UPDATER=PREFIX[,PREFIX] entries of --comment-prefix into prefixes keyed by updater name
+func parseCommentPrefixes(entries []string) (map[string][]string, error) {
+	names := map[string]bool{}
+	for _, fileUpdater := range updater.NewUpdater().Updaters() {
+		names[fileUpdater.Name()] = true
+	}
+
+	prefixes := map[string][]string{}
+	for _, entry := range entries {
+		name, list, ok := strings.Cut(entry, "=")
+		if !ok || list == "" {
+			return nil, fmt.Errorf("invalid --comment-prefix value %q: expected UPDATER=PREFIX[,PREFIX]", entry)
+		}
+		if !names[name] {
+			return nil, fmt.Errorf("invalid --comment-prefix value %q: unknown updater %q", entry, name)
+		}
+		for _, prefix := range strings.Split(list, ",") {
+			if strings.TrimSpace(prefix) == "" {
+				return nil, fmt.Errorf("invalid --comment-prefix value %q: empty prefix", entry)
+			}
+			prefixes[name] = append(prefixes[name], strings.TrimSpace(prefix))
+		}
+	}
+
+	return prefixes, nil
+}
+
+// commentRegexUsage is the help of the --comment-regex flag shared by the commands reading depup comments
+const commentRegexUsage = "Recognize depup comments by a custom regular expression whose first capture group is the package name, e.g. '@dep\\{(\\S+)\\}'"
+
+// parseCommentRegex compiles the --comment-regex pattern, which must capture the package name
+// An empty pattern returns nil, keeping the built-in syntax.
+func parseCommentRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --comment-regex value %q: %w", expr, err)
+	}
+	if pattern.NumSubexp() == 0 {
+		return nil, fmt.Errorf("invalid --comment-regex value %q: expected a capture group for the package name", expr)
+	}
+
+	return pattern, nil
+}
+
+// resolveExtensions computes the extensions to scan from additive and subtractive ("-.yml") entries
+// Additive entries replace the defaults, subtractive entries alone remove from them
+func resolveExtensions(entries []string, defaults []string) []string {
+	var added []string
+	removed := map[string]struct{}{}
+	for _, entry := range entries {
+		if ext, ok := strings.CutPrefix(entry, "-"); ok {
+			removed[ext] = struct{}{}
+			continue
+		}
+		added = append(added, entry)
+	}
+
+	if len(added) == 0 {
+		added = defaults
+	}
+
+	extensions := []string{}
+	for _, ext := range added {
+		if _, ok := removed[ext]; !ok && !slices.Contains(extensions, ext) {
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+// parsePackages parses package flags in the format IDENTIFIER=SEMVER_VERSION
+// The npm style IDENTIFIER@SEMVER_VERSION is accepted as well, e.g. left-pad@2.0.0 or @types/node@20.1.0
+func parsePackages(rawPackages []string) ([]updater.Package, error) {
+	var packages []updater.Package
+	for _, pkg := range rawPackages {
+		// Split the package into name and version
+		name, version, found := strings.Cut(pkg, "=")
+		if !found {
+			// A leading @ belongs to the scope of npm packages, so split at the last @
+			if i := strings.LastIndex(pkg, "@"); i > 0 {
+				name, version, found = pkg[:i], pkg[i+1:], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid package %q: expected format IDENTIFIER=SEMVER_VERSION", pkg)
+		}
+		packages = append(packages, updater.Package{Name: name, Version: version, Source: updater.SourceFlag})
+	}
+
+	return packages, nil
 }
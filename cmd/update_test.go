@@ -0,0 +1,1336 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dtomasi/depup/internal/resolver"
+)
+
+func writeFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+}
+
+func TestUpdateCmd_Count(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":     "# depup package=app\nversion: 1.0.0\n",
+		"worker.yml":   "image: worker:1.0.0 # depup package=app\n",
+		"current.yaml": "# depup package=app\nversion: 2.0.0\n",
+		"other.yaml":   "# depup package=other\nversion: 1.0.0\n",
+	})
+
+	tests := []struct {
+		name        string
+		packages    []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:        "files need updates",
+			packages:    []string{"-p", "app=2.0.0"},
+			expected:    "2\n",
+			expectError: true,
+		},
+		{
+			name:        "all files up to date",
+			packages:    []string{"-p", "other=1.0.0"},
+			expected:    "0\n",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"update", tempDir, "--count"}, tt.packages...)
+			output, err := executeCommand(t, args...)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("update --count error = %v, expectError %v", err, tt.expectError)
+			}
+
+			// The error is printed by cobra after the count, only the first line is the count
+			if len(output) < len(tt.expected) || output[:len(tt.expected)] != tt.expected {
+				t.Errorf("update --count output = %q, expected %q", output, tt.expected)
+			}
+		})
+	}
+
+	// Count mode must not modify files
+	content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if string(content) != "# depup package=app\nversion: 1.0.0\n" {
+		t.Errorf("file was modified in count mode: %q", string(content))
+	}
+}
+
+func TestUpdateCmd_DryRunSummaryOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":     "# depup package=app\nversion: 1.0.0\n# depup package=db\ndb: 5.0.0\n",
+		"worker.yml":   "image: worker:1.0.0 # depup package=app\n",
+		"nested/x.yml": "# depup package=app\nversion: 1.0.0\n# depup package=app\nsidecar: 1.0.0\n",
+		"current.yaml": "# depup package=app\nversion: 2.0.0\n",
+	})
+
+	output, err := executeCommand(t, "update", tempDir, "-r", "--dry-run-summary-only", "-p", "app=2.0.0", "-p", "db=6.0.0")
+	if err != nil {
+		t.Fatalf("update --dry-run-summary-only unexpected error: %v", err)
+	}
+
+	expected := "app: 3 files\n" +
+		"db: 1 file\n"
+	if output != expected {
+		t.Errorf("update --dry-run-summary-only output = %q, expected %q", output, expected)
+	}
+
+	// Summary mode is a dry run and must not modify files
+	content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if string(content) != "# depup package=app\nversion: 1.0.0\n# depup package=db\ndb: 5.0.0\n" {
+		t.Errorf("file was modified in summary mode: %q", string(content))
+	}
+}
+
+func TestUpdateCmd_EnvDefaults(t *testing.T) {
+	const original = "# depup package=app\nversion: 1.0.0\n"
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		args        []string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name: "dry run from environment",
+			env:  map[string]string{"DEPUP_DRY_RUN": "true"},
+			expected: map[string]string{
+				"app.yaml":        original,
+				"nested/app.yaml": original,
+			},
+		},
+		{
+			name: "flag takes precedence over environment",
+			env:  map[string]string{"DEPUP_DRY_RUN": "true"},
+			args: []string{"--dry-run=false"},
+			expected: map[string]string{
+				"app.yaml":        "# depup package=app\nversion: 2.0.0\n",
+				"nested/app.yaml": original,
+			},
+		},
+		{
+			name: "recursive from environment",
+			env:  map[string]string{"DEPUP_RECURSIVE": "true"},
+			expected: map[string]string{
+				"app.yaml":        "# depup package=app\nversion: 2.0.0\n",
+				"nested/app.yaml": "# depup package=app\nversion: 2.0.0\n",
+			},
+		},
+		{
+			name: "extensions from environment",
+			env:  map[string]string{"DEPUP_EXTENSIONS": ".yml, .tf"},
+			expected: map[string]string{
+				"app.yaml":        original,
+				"nested/app.yaml": original,
+			},
+		},
+		{
+			name:        "invalid boolean in environment",
+			env:         map[string]string{"DEPUP_RECURSIVE": "maybe"},
+			expectError: true,
+			expected: map[string]string{
+				"app.yaml":        original,
+				"nested/app.yaml": original,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{
+				"app.yaml":        original,
+				"nested/app.yaml": original,
+			})
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			args := append([]string{"update", tempDir, "-p", "app=2.0.0"}, tt.args...)
+			_, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update error = %v, expectError %v", err, tt.expectError)
+			}
+
+			for name, expected := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(content) != expected {
+					t.Errorf("%s = %q, expected %q", name, string(content), expected)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_PrintFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":              "# depup package=app\nversion: 1.0.0\n",
+		"deploy/service.yml":    "version: 1.0.0\n",
+		"vendor/chart.yaml":     "version: 1.0.0\n",
+		"generated.gen.yaml":    "version: 1.0.0\n",
+		"deploy/terraform.tf":   "version = \"1.0.0\"\n",
+		"deploy/unrelated.json": "{}\n",
+	})
+	t.Chdir(tempDir)
+
+	output, err := executeCommand(t, "update", ".", "-r", "--print-files", "--relative-paths", "-x", "vendor", "-x", "*.gen.yaml")
+	if err != nil {
+		t.Fatalf("update --print-files unexpected error: %v", err)
+	}
+
+	expected := "app.yaml\n" + filepath.Join("deploy", "service.yml") + "\n"
+	if output != expected {
+		t.Errorf("update --print-files output = %q, expected %q", output, expected)
+	}
+}
+
+func TestResolveExtensions(t *testing.T) {
+	defaults := []string{".yaml", ".yml"}
+	tests := []struct {
+		name     string
+		entries  []string
+		expected []string
+	}{
+		{name: "defaults", entries: defaults, expected: []string{".yaml", ".yml"}},
+		{name: "additive replaces defaults", entries: []string{".tf", ".env"}, expected: []string{".tf", ".env"}},
+		{name: "subtractive removes from defaults", entries: []string{"-.yml"}, expected: []string{".yaml"}},
+		{name: "additive and subtractive", entries: []string{".tf", ".yaml", "-.tf"}, expected: []string{".yaml"}},
+		{name: "subtractive before additive", entries: []string{"-.yml", ".yml", ".tf"}, expected: []string{".tf"}},
+		{name: "duplicates", entries: []string{".tf", ".tf"}, expected: []string{".tf"}},
+		{name: "everything removed", entries: []string{"-.yaml", "-.yml"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extensions := resolveExtensions(tt.entries, defaults)
+			if !reflect.DeepEqual(extensions, tt.expected) {
+				t.Errorf("resolveExtensions(%v) = %v, expected %v", tt.entries, extensions, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_ExtensionNegation(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml":    "version: 1.0.0\n",
+		"service.yml": "version: 1.0.0\n",
+		"main.tf":     "version = \"1.0.0\"\n",
+	})
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name        string
+		extensions  []string
+		expected    string
+		expectError bool
+	}{
+		{name: "remove from defaults", extensions: []string{"-.yml"}, expected: "app.yaml\n"},
+		{name: "add and remove", extensions: []string{".tf", ".yml", "-.tf"}, expected: "service.yml\n"},
+		{name: "remove everything", extensions: []string{"-.yaml", "-.yml"}, expectError: true},
+		{name: "unsupported extension", extensions: []string{".toml", ".ini"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"update", ".", "--print-files", "--relative-paths"}
+			for _, ext := range tt.extensions {
+				args = append(args, "-e", ext)
+			}
+
+			output, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update %v error = %v, expectError %v", tt.extensions, err, tt.expectError)
+			}
+			if !tt.expectError && output != tt.expected {
+				t.Errorf("update %v output = %q, expected %q", tt.extensions, output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePackages(t *testing.T) {
+	tests := []struct {
+		raw             string
+		expectedName    string
+		expectedVersion string
+		expectError     bool
+	}{
+		{raw: "app=1.2.3", expectedName: "app", expectedVersion: "1.2.3"},
+		{raw: "left-pad@2.0.0", expectedName: "left-pad", expectedVersion: "2.0.0"},
+		{raw: "@types/node@20.1.0", expectedName: "@types/node", expectedVersion: "20.1.0"},
+		{raw: "@types/node=20.1.0", expectedName: "@types/node", expectedVersion: "20.1.0"},
+		{raw: "@types/node", expectError: true},
+		{raw: "app", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			packages, err := parsePackages([]string{tt.raw})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parsePackages(%q) error = %v, expectError %v", tt.raw, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if packages[0].Name != tt.expectedName || packages[0].Version != tt.expectedVersion {
+				t.Errorf("parsePackages(%q) = %+v, expected %s %s", tt.raw, packages[0], tt.expectedName, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_Timeout(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("dir%d/app%d.yaml", i%20, i)] = "# depup package=app\nversion: 1.0.0\n"
+	}
+	writeFixture(t, tempDir, files)
+
+	_, err := executeCommand(t, "update", tempDir, "-r", "-p", "app=2.0.0", "--timeout", "1ns")
+	if err == nil {
+		t.Fatal("update --timeout expected error")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.HasPrefix(err.Error(), "timed out after 1ns") {
+		t.Errorf("update --timeout error = %v, expected timeout error", err)
+	}
+}
+
+func TestUpdateCmd_VersionSource(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nversion: 1.0.0\n" +
+			"# depup package=db\nversion: 1.0.0\n" +
+			"# depup package=cache\nversion: 1.0.0\n",
+	})
+
+	configPath := filepath.Join(t.TempDir(), "depup.yaml")
+	configContent := "packages:\n" +
+		"  - name: db\n    version: 2.0.0\n" +
+		"  - name: cache\n    datasource: env\n    env: CACHE_VERSION\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("CACHE_VERSION", "3.0.0")
+
+	output, err := executeCommand(t, "update", filepath.Join(tempDir, "app.yaml"), "-p", "app=2.0.0",
+		"--config", configPath, "--dereference-config-packages", "--report-format", "json")
+	if err != nil {
+		t.Fatalf("update unexpected error: %v", err)
+	}
+
+	var report struct {
+		Changes []struct {
+			Package string `json:"package"`
+			Source  string `json:"source"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("update emitted invalid JSON %q: %v", output, err)
+	}
+
+	expected := map[string]string{
+		"app":   "flag",
+		"db":    "config",
+		"cache": "resolver:env",
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("update reported %d changes, expected %d", len(report.Changes), len(expected))
+	}
+	for _, change := range report.Changes {
+		if change.Source != expected[change.Package] {
+			t.Errorf("change of %s source = %q, expected %q", change.Package, change.Source, expected[change.Package])
+		}
+	}
+}
+
+func TestUpdateCmd_CommentPosition(t *testing.T) {
+	tests := []struct {
+		name        string
+		position    string
+		expected    string
+		expectError bool
+	}{
+		{name: "below", position: "below", expected: "version: 2.0.0\n# depup package=app\n"},
+		{name: "above", position: "above", expected: "version: 1.0.0\n# depup package=app\n"},
+		{name: "invalid", position: "beside", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "version: 1.0.0\n# depup package=app\n"})
+			filePath := filepath.Join(tempDir, "app.yaml")
+
+			_, err := executeCommand(t, "update", filePath, "-p", "app=2.0.0", "--comment-position", tt.position)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update --comment-position %s error = %v, expectError %v", tt.position, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", filePath, err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("app.yaml = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_CommentPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		prefix      string
+		expected    string
+		expectError bool
+	}{
+		{name: "semicolon", prefix: "dotenv=;", expected: "; depup package=app\nAPP_VERSION=2.0.0\n"},
+		{name: "unknown updater", prefix: "ini=;", expectError: true},
+		{name: "missing prefix", prefix: "dotenv=", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.env": "; depup package=app\nAPP_VERSION=1.0.0\n"})
+			filePath := filepath.Join(tempDir, "app.env")
+
+			_, err := executeCommand(t, "update", filePath, "-e", ".env", "-p", "app=2.0.0", "--comment-prefix", tt.prefix)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update --comment-prefix %s error = %v, expectError %v", tt.prefix, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", filePath, err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("app.env = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCommentRegex(t *testing.T) {
+	tests := []struct {
+		expr        string
+		expectNil   bool
+		expectError bool
+	}{
+		{expr: "", expectNil: true},
+		{expr: `@dep\{([^}]+)\}`},
+		{expr: `@dep\{`, expectError: true},
+		{expr: `@dep`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			pattern, err := parseCommentRegex(tt.expr)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseCommentRegex(%q) error = %v, expectError %v", tt.expr, err, tt.expectError)
+			}
+			if !tt.expectError && (pattern == nil) != tt.expectNil {
+				t.Errorf("parseCommentRegex(%q) = %v, expectNil %v", tt.expr, pattern, tt.expectNil)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_StrictSemver(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		strict      bool
+		expectError bool
+	}{
+		{name: "lenient", version: "1.2.3.4", strict: false, expectError: false},
+		{name: "strict rejects extra component", version: "1.2.3.4", strict: true, expectError: true},
+		{name: "strict accepts semver", version: "1.2.3", strict: true, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: 1.0.0\n"})
+
+			args := []string{"update", filepath.Join(tempDir, "app.yaml"), "-p", "app=" + tt.version}
+			if tt.strict {
+				args = append(args, "--strict-semver")
+			}
+
+			_, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Errorf("update %v error = %v, expectError %v", args, err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_StrictSemverFileValues(t *testing.T) {
+	tests := []struct {
+		name            string
+		value           string
+		args            []string
+		expectedWarning string
+		expectError     bool
+	}{
+		{name: "leading zero", value: "1.01.0", expectedWarning: `Warning: app.yaml:2 version "1.01.0" of package app is not a strict semantic version`},
+		{name: "two components", value: "1.2", expectedWarning: `Warning: app.yaml:2 version "1.2" of package app is not a strict semantic version`},
+		{name: "strict semver", value: "1.2.3"},
+		{name: "warning fails the run", value: "1.2", args: []string{"--treat-warnings-as-errors"}, expectError: true},
+		{name: "lenient without strict mode", value: "1.2", args: []string{"--strict-semver=false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: " + tt.value + "\n"})
+			t.Chdir(tempDir)
+
+			args := append([]string{"update", ".", "-d", "--relative-paths", "--strict-semver", "-p", "app=2.0.0"}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update %v error = %v, expectError %v", args, err, tt.expectError)
+			}
+
+			if tt.expectedWarning != "" && !strings.Contains(output, tt.expectedWarning) {
+				t.Errorf("update %v output = %q, expected warning %q", args, output, tt.expectedWarning)
+			}
+			if tt.expectedWarning == "" && !tt.expectError && strings.Contains(output, "Warning:") {
+				t.Errorf("update %v output = %q, expected no warning", args, output)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_ResolverCache(t *testing.T) {
+	calls := 0
+	datasources.Register("counting", resolver.VersionResolverFunc(func(ctx context.Context, request resolver.Request) (string, error) {
+		calls++
+		return "2.0.0", nil
+	}))
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "depup.yaml")
+	if err := os.WriteFile(configPath, []byte("packages:\n  - name: app\n    datasource: counting\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cacheDir := t.TempDir()
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedCalls int
+		expectedLog   string
+	}{
+		{name: "miss", args: nil, expectedCalls: 1, expectedLog: "Resolver cache miss for app from counting"},
+		{name: "hit", args: nil, expectedCalls: 1, expectedLog: "Resolver cache hit for app from counting: 2.0.0"},
+		{name: "no cache", args: []string{"--no-cache"}, expectedCalls: 2},
+		{name: "expired", args: []string{"--cache-ttl", "1ns"}, expectedCalls: 3, expectedLog: "Resolver cache miss"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: 1.0.0\n"})
+
+			args := append([]string{"update", tempDir, "--config", configPath, "--dereference-config-packages", "--cache-dir", cacheDir}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if err != nil {
+				t.Fatalf("update unexpected error: %v", err)
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("datasource called %d times, expected %d", calls, tt.expectedCalls)
+			}
+			if !strings.Contains(output, tt.expectedLog) {
+				t.Errorf("update output = %q, expected it to contain %q", output, tt.expectedLog)
+			}
+			if tt.expectedLog == "" && strings.Contains(output, "Resolver cache") {
+				t.Errorf("update output = %q, expected no cache log", output)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_Interactive(t *testing.T) {
+	content := "# depup package=app\nversion: 1.0.0\n# depup package=db\nversion: 1.0.0\n# depup package=cache\nversion: 1.0.0\n"
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "yes and no", input: "y\nn\ny\n", expected: "# depup package=app\nversion: 2.0.0\n# depup package=db\nversion: 1.0.0\n# depup package=cache\nversion: 2.0.0\n"},
+		{name: "all", input: "n\na\n", expected: "# depup package=app\nversion: 1.0.0\n# depup package=db\nversion: 2.0.0\n# depup package=cache\nversion: 2.0.0\n"},
+		{name: "quit", input: "y\nq\n", expected: "# depup package=app\nversion: 2.0.0\n# depup package=db\nversion: 1.0.0\n# depup package=cache\nversion: 1.0.0\n"},
+		{name: "invalid answer is asked again", input: "maybe\ny\nn\nn\n", expected: "# depup package=app\nversion: 2.0.0\n# depup package=db\nversion: 1.0.0\n# depup package=cache\nversion: 1.0.0\n"},
+		{name: "end of input", input: "", expected: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": content})
+			filePath := filepath.Join(tempDir, "app.yaml")
+
+			rootCmd.SetIn(strings.NewReader(tt.input))
+			t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+			output, err := executeCommand(t, "update", filePath, "-i", "-p", "app=2.0.0", "-p", "db=2.0.0", "-p", "cache=2.0.0")
+			if err != nil {
+				t.Fatalf("update -i unexpected error: %v", err)
+			}
+			if !strings.Contains(output, "Apply "+displayFile(filePath)+":2 app 1.0.0 -> 2.0.0? [y,n,a,q] ") {
+				t.Errorf("update -i output = %q, expected a prompt for app", output)
+			}
+
+			written, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", filePath, err)
+			}
+			if string(written) != tt.expected {
+				t.Errorf("app.yaml = %q, expected %q", string(written), tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_RegistryAuth(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": "# depup package=app\nversion: 1.0.0\n",
+		"netrc":    "machine registry.example.com login user password secret\n",
+		"invalid":  "machine registry.example.com token secret\n",
+	})
+	filePath := filepath.Join(tempDir, "app.yaml")
+
+	if _, err := executeCommand(t, "update", filePath, "-p", "app=2.0.0", "--registry-auth", filepath.Join(tempDir, "netrc")); err != nil {
+		t.Fatalf("update --registry-auth unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"missing", "invalid"} {
+		_, err := executeCommand(t, "update", filePath, "-p", "app=2.0.0", "--registry-auth", filepath.Join(tempDir, name))
+		if err == nil || !strings.Contains(err.Error(), "registry auth file") {
+			t.Errorf("update --registry-auth %s error = %v, expected a registry auth file error", name, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("update --registry-auth %s error leaks credentials: %v", name, err)
+		}
+	}
+}
+
+func TestUpdateCmd_SARIF(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError bool
+	}{
+		{name: "default level", args: []string{"--report-format", "sarif"}, expected: `"level": "warning"`},
+		{name: "error level", args: []string{"--report-format", "sarif", "--sarif-level", "error"}, expected: `"level": "error"`},
+		{name: "invalid level", args: []string{"--report-format", "sarif", "--sarif-level", "fatal"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: 1.0.0\n"})
+			t.Chdir(tempDir)
+
+			output, err := executeCommand(t, append([]string{"update", ".", "-d", "--relative-paths", "-p", "app=2.0.0"}, tt.args...)...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update %v error = %v, expectError %v", tt.args, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			for _, expected := range []string{tt.expected, `"uri": "app.yaml"`, `"startLine": 2`} {
+				if !strings.Contains(output, expected) {
+					t.Errorf("update %v output missing %s, got:\n%s", tt.args, expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_JSONFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "json lines are compact by default",
+			args:     []string{"--report-format", "jsonl"},
+			expected: `{"file":"app.yaml","line":2,"package":"app","old_version":"1.0.0","new_version":"2.0.0","source":"flag","mode":"0644"}` + "\n",
+		},
+		{
+			name: "pretty json lines",
+			args: []string{"--report-format", "jsonl", "--json-pretty"},
+			expected: "{\n  \"file\": \"app.yaml\",\n  \"line\": 2,\n  \"package\": \"app\",\n  \"old_version\": \"1.0.0\",\n" +
+				"  \"new_version\": \"2.0.0\",\n  \"source\": \"flag\",\n  \"mode\": \"0644\"\n}\n",
+		},
+		{
+			name:     "compact json document",
+			args:     []string{"--report-format", "json", "--json-compact"},
+			expected: `{"dry_run":true,"changes":[{"file":"app.yaml","line":2,"package":"app","old_version":"1.0.0","new_version":"2.0.0","source":"flag","mode":"0644"}]}` + "\n",
+		},
+		{
+			name:        "both toggles",
+			args:        []string{"--report-format", "json", "--json-compact", "--json-pretty"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: 1.0.0\n"})
+			t.Chdir(tempDir)
+
+			output, err := executeCommand(t, append([]string{"update", ".", "-d", "--relative-paths", "-p", "app=2.0.0"}, tt.args...)...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update %v error = %v, expectError %v", tt.args, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if output != tt.expected {
+				t.Errorf("update %v output = %q, expected %q", tt.args, output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_Changelog(t *testing.T) {
+	fixture := map[string]string{
+		"app.yaml":   "# depup package=app\nversion: 1.0.0\n# depup package=db\ndb: 5.0.0\n",
+		"other.yaml": "# depup package=app\nversion: 1.0.0\n",
+	}
+	expected := "- Bump app from 1.0.0 to 2.0.0 (2 files)\n- Bump db from 5.0.0 to 5.1.0 (1 file)\n"
+
+	t.Run("stdout", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeFixture(t, tempDir, fixture)
+
+		output, err := executeCommand(t, "update", tempDir, "--report-format", "json", "--changelog", "-p", "app=2.0.0", "-p", "db=5.1.0")
+		if err != nil {
+			t.Fatalf("update error = %v", err)
+		}
+		if !strings.HasSuffix(output, expected) {
+			t.Errorf("update output = %q, expected changelog %q at the end", output, expected)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeFixture(t, tempDir, fixture)
+		changelogFile := filepath.Join(t.TempDir(), "CHANGES.md")
+
+		output, err := executeCommand(t, "update", tempDir, "--changelog="+changelogFile, "-p", "app=2.0.0", "-p", "db=5.1.0")
+		if err != nil {
+			t.Fatalf("update error = %v", err)
+		}
+		if strings.Contains(output, "- Bump") {
+			t.Errorf("update output = %q, expected no changelog on stdout", output)
+		}
+
+		content, err := os.ReadFile(changelogFile)
+		if err != nil {
+			t.Fatalf("Failed to read changelog: %v", err)
+		}
+		if string(content) != expected {
+			t.Errorf("changelog = %q, expected %q", string(content), expected)
+		}
+	})
+}
+
+func TestUpdateCmd_Progress(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "disabled", args: nil, expected: ""},
+		{name: "auto without terminal", args: []string{"--progress"}, expected: ""},
+		{name: "always", args: []string{"--progress=always"}, expected: "[1/2] a.yaml\n[2/2] b.yaml\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{
+				"a.yaml": "# depup package=app\nversion: 1.0.0\n",
+				"b.yaml": "version: 1.0.0\n",
+			})
+			t.Chdir(tempDir)
+			t.Cleanup(func() { resetFlags(rootCmd) })
+
+			// Progress goes to stderr and must not mix with the report on stdout
+			var stdout, stderr bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(append([]string{"update", ".", "--relative-paths", "-p", "app=2.0.0"}, tt.args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("update %v error = %v", tt.args, err)
+			}
+
+			if stderr.String() != tt.expected {
+				t.Errorf("update %v stderr = %q, expected %q", tt.args, stderr.String(), tt.expected)
+			}
+			if expected := "Updated a.yaml:2 app 1.0.0 -> 2.0.0\n"; stdout.String() != expected {
+				t.Errorf("update %v stdout = %q, expected %q", tt.args, stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    int64
+		expectError bool
+	}{
+		{value: "1024", expected: 1024},
+		{value: "0", expected: 0},
+		{value: "100B", expected: 100},
+		{value: "512KB", expected: 512 << 10},
+		{value: "10MB", expected: 10 << 20},
+		{value: "2gb", expected: 2 << 30},
+		{value: " 1 MB ", expected: 1 << 20},
+		{value: "MB", expectError: true},
+		{value: "-1KB", expectError: true},
+		{value: "1.5MB", expectError: true},
+		{value: "10TB", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := parseFileSize(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseFileSize(%q) error = %v, expectError %v", tt.value, err, tt.expectError)
+			}
+			if size != tt.expected {
+				t.Errorf("parseFileSize(%q) = %d, expected %d", tt.value, size, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    os.FileMode
+		expectError bool
+	}{
+		{value: "", expected: 0},
+		{value: "0644", expected: 0644},
+		{value: "600", expected: 0600},
+		{value: "0755", expected: 0755},
+		{value: "0", expectError: true},
+		{value: "0888", expectError: true},
+		{value: "1777", expectError: true},
+		{value: "rw-r--r--", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mode, err := parseFileMode(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseFileMode(%q) error = %v, expectError %v", tt.value, err, tt.expectError)
+			}
+			if mode != tt.expected {
+				t.Errorf("parseFileMode(%q) = %04o, expected %04o", tt.value, mode, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_MaxFileSize(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"small.yaml": "# depup package=app\nversion: 1.0.0\n",
+		"large.yaml": "# depup package=app\nversion: 1.0.0\n" + strings.Repeat("# padding\n", 200),
+	})
+	t.Chdir(tempDir)
+
+	output, err := executeCommand(t, "update", ".", "--relative-paths", "--max-file-size", "1KB", "-p", "app=2.0.0")
+	if err != nil {
+		t.Fatalf("update unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "Updated small.yaml:2 app 1.0.0 -> 2.0.0") {
+		t.Errorf("update output = %q, expected small.yaml to be updated", output)
+	}
+	if !strings.Contains(output, "Warning: skipped "+filepath.Join(tempDir, "large.yaml")) {
+		t.Errorf("update output = %q, expected a warning for large.yaml", output)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "large.yaml")); !strings.Contains(string(content), "version: 1.0.0") {
+		t.Errorf("large.yaml was updated despite exceeding --max-file-size")
+	}
+
+	if _, err := executeCommand(t, "update", ".", "--max-file-size", "big", "-p", "app=2.0.0"); err == nil {
+		t.Errorf("update with an invalid --max-file-size expected an error")
+	}
+}
+
+func TestUpdateCmd_ForceWriteTransactional(t *testing.T) {
+	const original = "# depup package=app\nversion: 2.0.0\n"
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{"app.yaml": original})
+
+	_, err := executeCommand(t, "update", tempDir, "--force-write", "--no-write-on-partial-failure", "-p", "app=2.0.0")
+	if err == nil || !strings.Contains(err.Error(), "--force-write cannot be combined with --no-write-on-partial-failure") {
+		t.Errorf("update error = %v, expected the flag combination to be rejected", err)
+	}
+
+	// A dry run writes nothing, so the combination is accepted
+	if _, err := executeCommand(t, "update", tempDir, "--force-write", "--no-write-on-partial-failure", "--dry-run", "-p", "app=2.0.0"); err != nil {
+		t.Errorf("update --dry-run unexpected error: %v", err)
+	}
+}
+
+func TestUpdateCmd_DumpEffectiveConfig(t *testing.T) {
+	const original = "# depup package=app\nversion: 1.0.0\n"
+
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, map[string]string{
+		"app.yaml": original,
+		".depup.yaml": "group_by: package\nscheme: partial\n" +
+			"packages:\n  - name: app\n    version: 1.5.0\n  - name: db\n    datasource: env\n    env: DB_VERSION\n",
+	})
+	t.Chdir(tempDir)
+	t.Setenv("DEPUP_RECURSIVE", "true")
+
+	output, err := executeCommand(t, "update", ".", "--dump-effective-config", "--group-by", "file", "-p", "app=2.0.0")
+	if err != nil {
+		t.Fatalf("update unexpected error: %v", err)
+	}
+
+	// Flags override the config file, the environment and the config file fill in the rest
+	for _, expected := range []string{
+		"group_by: file\n",
+		"scheme: partial\n",
+		"recursive: true\n",
+		"dry_run: false\n",
+		"  - name: app\n    version: 2.0.0\n",
+		"  - name: db\n    datasource: env\n    env: DB_VERSION\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("update output = %q, expected it to contain %q", output, expected)
+		}
+	}
+	if strings.Contains(output, "1.5.0") {
+		t.Errorf("update output = %q, expected the configured version of app to be overridden", output)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "app.yaml")); string(content) != original {
+		t.Errorf("app.yaml = %q, expected it to be unchanged", string(content))
+	}
+}
+
+func TestUpdateCmd_OnUnparseable(t *testing.T) {
+	// The datasource returns a PEP 440 post-release for db, which can't be written as a semantic version
+	datasources.Register("unparseable-mock", resolver.VersionResolverFunc(func(ctx context.Context, request resolver.Request) (string, error) {
+		return map[string]string{"app": "2.0.0", "db": "1.2.3.post1"}[request.Package], nil
+	}))
+
+	const original = "# depup package=app\napp: 1.0.0\n# depup package=db\ndb: 1.0.0\n"
+	configPath := filepath.Join(t.TempDir(), "depup.yaml")
+	configContent := "packages:\n" +
+		"  - name: app\n    datasource: unparseable-mock\n" +
+		"  - name: db\n    datasource: unparseable-mock\n"
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedOutput string
+		expected       string
+	}{
+		{
+			name:           "skip by default",
+			expectedOutput: `Warning: skipped package db: datasource "unparseable-mock" returned unparseable version "1.2.3.post1"`,
+			expected:       "# depup package=app\napp: 2.0.0\n# depup package=db\ndb: 1.0.0\n",
+		},
+		{
+			name:           "error",
+			args:           []string{"--on-unparseable", "error"},
+			expectError:    true,
+			expectedOutput: `package db resolved to unparseable version "1.2.3.post1"`,
+			expected:       original,
+		},
+		{
+			name:        "invalid mode",
+			args:        []string{"--on-unparseable", "ignore"},
+			expectError: true,
+			expected:    original,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": original})
+
+			args := append([]string{"update", tempDir, "--config", configPath, "--dereference-config-packages", "--no-cache"}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update error = %v, expectError %v", err, tt.expectError)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("update output = %q, expected it to contain %q", output, tt.expectedOutput)
+			}
+
+			content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+			if err != nil {
+				t.Fatalf("failed to read app.yaml: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("app.yaml = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_TreatWarningsAsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+	}{
+		{name: "warnings only by default"},
+		{name: "warning fails the run", args: []string{"--max-file-size", "40", "--treat-warnings-as-errors"}, expectError: true},
+		{name: "no warnings", args: []string{"--max-file-size", "1KB", "--treat-warnings-as-errors"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{
+				"app.yaml":   "# depup package=app\napp: 1.0.0\n",
+				"large.yaml": "# depup package=app\napp: 1.0.0\n# padding to exceed the limit\n",
+			})
+
+			args := append([]string{"update", tempDir, "-p", "app=2.0.0", "--max-file-size", "40"}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError && !strings.Contains(err.Error(), "1 warning(s) treated as errors") {
+				t.Errorf("update error = %v, expected the number of warnings", err)
+			}
+			if tt.expectError && strings.Contains(output, "Usage:") {
+				t.Errorf("update output = %q, expected no usage", output)
+			}
+
+			// The run completes before the warnings fail it
+			content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+			if err != nil {
+				t.Fatalf("failed to read app.yaml: %v", err)
+			}
+			if string(content) != "# depup package=app\napp: 2.0.0\n" {
+				t.Errorf("app.yaml = %q, expected it to be updated", string(content))
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_ReportOnlyErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          map[string]string
+		args           []string
+		expectError    bool
+		expectedStderr string
+	}{
+		{
+			name:  "successful run is silent",
+			files: map[string]string{"app.yaml": "# depup package=app\napp: 1.0.0\n"},
+		},
+		{
+			name: "warnings are printed",
+			files: map[string]string{
+				"app.yaml":   "# depup package=app\napp: 1.0.0\n",
+				"large.yaml": "# depup package=app\napp: 1.0.0\n# padding to exceed the limit\n",
+			},
+			args:           []string{"--max-file-size", "40"},
+			expectedStderr: "Warning: skipped",
+		},
+		{
+			name:           "errors are printed",
+			files:          map[string]string{"app.yaml": "app: 1.0.0 1.0.0 # depup package=app\n"},
+			args:           []string{"--error-on-multiple-matches"},
+			expectError:    true,
+			expectedStderr: "Error:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { resetFlags(rootCmd) })
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, tt.files)
+
+			var stdout, stderr bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(append([]string{"update", tempDir, "-p", "app=2.0.0", "--report-only-errors"}, tt.args...))
+
+			err := rootCmd.Execute()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update error = %v, expectError %v", err, tt.expectError)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("update stdout = %q, expected no output", stdout.String())
+			}
+			if tt.expectedStderr == "" && stderr.Len() != 0 {
+				t.Errorf("update stderr = %q, expected no output", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.expectedStderr) {
+				t.Errorf("update stderr = %q, expected it to contain %q", stderr.String(), tt.expectedStderr)
+			}
+
+			// Changes are still applied
+			if !tt.expectError {
+				content, err := os.ReadFile(filepath.Join(tempDir, "app.yaml"))
+				if err != nil {
+					t.Fatalf("failed to read app.yaml: %v", err)
+				}
+				if !strings.Contains(string(content), "app: 2.0.0") {
+					t.Errorf("app.yaml = %q, expected it to be updated", string(content))
+				}
+			}
+		})
+	}
+}
+
+// TestUpdateCmd_DryRunDowngrade previews setting versions to an older target, e.g. to roll back a bad bump
+func TestUpdateCmd_DryRunDowngrade(t *testing.T) {
+	files := map[string]string{
+		"app.yaml": "image: app:2.0.0 # depup package=app\n",
+		"main.tf":  "version = \">= 2.0.0, < 3.0.0\" # depup package=app\n",
+	}
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, files)
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "report lists old and new versions",
+			args:     []string{"--report-format", "lines"},
+			expected: []string{"app.yaml:1 app 2.0.0 -> 1.5.0", "main.tf:1 app 2.0.0 -> 1.5.0"},
+		},
+		{
+			name:     "content holds the older versions",
+			expected: []string{"image: app:1.5.0 # depup package=app", "version = \">= 1.5.0, < 3.0.0\" # depup package=app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"update", ".", "-d", "--relative-paths", "-e", ".yaml", "-e", ".tf", "-p", "app=1.5.0"}, tt.args...)
+			output, err := executeCommand(t, args...)
+			if err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("update output = %q, expected it to contain %q", output, expected)
+				}
+			}
+		})
+	}
+
+	// The preview leaves the files alone
+	for name, expected := range files {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, expected it to be unchanged", name, string(content))
+		}
+	}
+}
+
+func TestUpdateCmd_StepSummary(t *testing.T) {
+	expected := "# Previous step\n" +
+		"### depup\n\n" +
+		"| Package | Old version | New version | File | Line |\n" +
+		"| --- | --- | --- | --- | ---: |\n" +
+		"| app | 1.0.0 | 2.0.0 | app.yaml | 2 |\n\n"
+
+	tests := []struct {
+		name        string
+		args        []string
+		setEnv      bool
+		expected    string
+		expectError bool
+	}{
+		{name: "enabled by the environment variable", setEnv: true, expected: expected},
+		{name: "explicitly enabled", args: []string{"--step-summary"}, setEnv: true, expected: expected},
+		{name: "explicitly disabled", args: []string{"--step-summary=false"}, setEnv: true, expected: "# Previous step\n"},
+		{name: "enabled without the environment variable", args: []string{"--step-summary"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeFixture(t, tempDir, map[string]string{"app.yaml": "# depup package=app\nversion: 1.0.0\n"})
+			t.Chdir(tempDir)
+
+			summaryFile := filepath.Join(t.TempDir(), "summary.md")
+			if err := os.WriteFile(summaryFile, []byte("# Previous step\n"), 0644); err != nil {
+				t.Fatalf("failed to create summary file: %v", err)
+			}
+			t.Setenv("GITHUB_STEP_SUMMARY", "")
+			if tt.setEnv {
+				t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+			}
+
+			_, err := executeCommand(t, append([]string{"update", ".", "-p", "app=2.0.0"}, tt.args...)...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("update error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			content, err := os.ReadFile(summaryFile)
+			if err != nil {
+				t.Fatalf("failed to read summary file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("summary file = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateCmd_ReadOnlyCheck(t *testing.T) {
+	files := map[string]string{
+		"app.yaml":       "# depup package=app\nversion: 1.0.0\n",
+		"config/db.yaml": "image: db:5.0.0 # depup package=db\n",
+		"current.yaml":   "# depup package=app\nversion: 2.0.0\n",
+		"unrelated.yaml": "version: 1.0.0\n",
+	}
+	tempDir := t.TempDir()
+	writeFixture(t, tempDir, files)
+	t.Chdir(tempDir)
+
+	tests := []struct {
+		name         string
+		packages     []string
+		expected     []string
+		expectedCode ExitCode
+	}{
+		{
+			name:     "outdated versions",
+			packages: []string{"-p", "app=2.0.0", "-p", "db=5.1.0"},
+			expected: []string{
+				"app.yaml:2: app is 1.0.0, expected 2.0.0 (fix: depup update app.yaml -p app=2.0.0)\n",
+				filepath.Join("config", "db.yaml") + ":1: db is 5.0.0, expected 5.1.0 (fix: depup update " + filepath.Join("config", "db.yaml") + " -p db=5.1.0)\n",
+			},
+			expectedCode: ExitChangesNeeded,
+		},
+		{
+			name:         "single package",
+			packages:     []string{"-p", "app=2.0.0"},
+			expected:     []string{"app.yaml:2: app is 1.0.0, expected 2.0.0"},
+			expectedCode: ExitChangesNeeded,
+		},
+		{
+			name:         "nothing to fix",
+			packages:     []string{"-p", "db=5.0.0"},
+			expectedCode: ExitOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(t, append([]string{"update", ".", "-r", "--read-only-check"}, tt.packages...)...)
+			if code := ExitCodeOf(err); code != tt.expectedCode {
+				t.Fatalf("update --read-only-check exit code = %d (%v), expected %d", code, err, tt.expectedCode)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("update --read-only-check output = %q, expected it to contain %q", output, expected)
+				}
+			}
+			if len(tt.expected) == 0 && output != "" {
+				t.Errorf("update --read-only-check output = %q, expected no output", output)
+			}
+			if strings.Contains(output, "current.yaml") || strings.Contains(output, "Usage:") {
+				t.Errorf("update --read-only-check output = %q, expected only outdated versions", output)
+			}
+		})
+	}
+
+	// The check never writes files
+	for name, expected := range files {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s = %q, expected it to be unchanged", name, string(content))
+		}
+	}
+}
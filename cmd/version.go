@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"runtime/debug"
 
 	"github.com/spf13/cobra"
 )
@@ -14,21 +15,53 @@ var (
 	date    = "unknown" // Build timestamp
 )
 
+// readBuildInfo returns the build information embedded by the go tool, replaced in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// buildVersion returns the version, commit and build time of the binary
+// Values not set with ldflags fall back to the build information, so binaries built with go install
+// report the module version and the VCS revision and time they were built from.
+func buildVersion() (string, string, string) {
+	v, c, d := version, commit, date
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return v, c, d
+	}
+
+	// Binaries built from a checkout report "(devel)" as module version
+	if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && c == "none":
+			c = setting.Value
+		case setting.Key == "vcs.time" && d == "unknown":
+			d = setting.Value
+		}
+	}
+
+	return v, c, d
+}
+
 // versionCmd represents the version command that prints version information
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version of depup",
 	// Run defines the command's behavior
 	Run: func(cmd *cobra.Command, args []string) {
+		version, commit, date := buildVersion()
+
 		if short, _ := cmd.Flags().GetBool("short"); short {
 			// Print only the version number
-			fmt.Println(version)
+			fmt.Fprintln(cmd.OutOrStdout(), version)
 
 			return
 		}
 
 		// Print version information in a standardized format
-		fmt.Printf("depup version %s (commit: %s, built at: %s)\n", version, commit, date)
+		fmt.Fprintf(cmd.OutOrStdout(), "depup version %s (commit: %s, built at: %s)\n", version, commit, date)
 	},
 }
 
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestVersionCmd_BuildInfo(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		Main: debug.Module{Path: "github.com/dtomasi/depup", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abc"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		version   string
+		commit    string
+		date      string
+		buildInfo *debug.BuildInfo
+		args      []string
+		expected  string
+	}{
+		{
+			name:      "go install without ldflags",
+			version:   "dev",
+			commit:    "none",
+			date:      "unknown",
+			buildInfo: buildInfo,
+			expected:  "depup version v1.4.0 (commit: 0123abc, built at: 2024-05-01T10:00:00Z)\n",
+		},
+		{
+			name:      "short version",
+			version:   "dev",
+			commit:    "none",
+			date:      "unknown",
+			buildInfo: buildInfo,
+			args:      []string{"--short"},
+			expected:  "v1.4.0\n",
+		},
+		{
+			name:      "ldflags take precedence",
+			version:   "1.5.0",
+			commit:    "fedcba9",
+			date:      "2024-06-01",
+			buildInfo: buildInfo,
+			expected:  "depup version 1.5.0 (commit: fedcba9, built at: 2024-06-01)\n",
+		},
+		{
+			name:      "build from a checkout",
+			version:   "dev",
+			commit:    "none",
+			date:      "unknown",
+			buildInfo: &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+			expected:  "depup version dev (commit: none, built at: unknown)\n",
+		},
+		{
+			name:     "no build info",
+			version:  "dev",
+			commit:   "none",
+			date:     "unknown",
+			expected: "depup version dev (commit: none, built at: unknown)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalVersion, originalCommit, originalDate, originalReadBuildInfo := version, commit, date, readBuildInfo
+			t.Cleanup(func() {
+				version, commit, date, readBuildInfo = originalVersion, originalCommit, originalDate, originalReadBuildInfo
+			})
+			version, commit, date = tt.version, tt.commit, tt.date
+			readBuildInfo = func() (*debug.BuildInfo, bool) {
+				return tt.buildInfo, tt.buildInfo != nil
+			}
+
+			output, err := executeCommand(t, append([]string{"version"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("version unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("version output = %q, expected %q", output, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package config implements loading of the .depup.yaml configuration file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file looked up in the working directory
+const FileName = ".depup.yaml"
+
+// Config holds the settings that can be provided by a configuration file
+// Flags and environment variables take precedence over these values
+// The struct tags drive the generated JSON Schema (see Schema)
+type Config struct {
+	DryRun                 *bool     `yaml:"dry_run,omitempty" default:"false" description:"Show what would be updated without making changes"`
+	Recursive              *bool     `yaml:"recursive,omitempty" default:"false" description:"Look up files recursively if a directory is passed"`
+	Extensions             []string  `yaml:"extensions,omitempty" default:".yaml,.yml" description:"File extensions to include in the search"`
+	Excludes               []string  `yaml:"excludes,omitempty" description:"Glob patterns of files and directories to skip (relative path or base name)"`
+	RelativePaths          *bool     `yaml:"relative_paths,omitempty" default:"false" description:"Report file paths relative to the current working directory"`
+	RespectEditorConfig    *bool     `yaml:"respect_editorconfig,omitempty" default:"false" description:"Apply end_of_line, insert_final_newline and charset from .editorconfig to updated files"`
+	GroupBy                string    `yaml:"group_by,omitempty" default:"file" enum:"file,package" description:"Grouping of the change report"`
+	QuoteStyle             string    `yaml:"quote_style,omitempty" enum:"double,single,none" description:"Quoting of updated YAML versions, existing quotes are kept if empty"`
+	ForceWrite             *bool     `yaml:"force_write,omitempty" default:"false" description:"Rewrite files with annotated versions even if no version changed"`
+	Scheme                 string    `yaml:"scheme,omitempty" default:"semver" enum:"semver,partial,integer,gomod" description:"Version scheme for depup comments without a scheme attribute"`
+	UpdateMode             string    `yaml:"update_mode,omitempty" default:"range" enum:"range,constraint,pin" description:"Treatment of constraints for depup comments without a mode attribute"`
+	CommentPosition        string    `yaml:"comment_position,omitempty" default:"above" enum:"above,below" description:"Whether depup comments on their own line annotate the version above or below them"`
+	CommentPrefixes        []string  `yaml:"comment_prefixes,omitempty" description:"Comment prefixes depup comments of an updater are recognized by, as UPDATER=PREFIX[,PREFIX], e.g. dotenv=;"`
+	CommentRegex           string    `yaml:"comment_regex,omitempty" description:"Regular expression replacing the depup comment syntax, its first capture group is the package name"`
+	ReportFormat           string    `yaml:"report_format,omitempty" default:"text" enum:"text,json,sarif,lines,jsonl" description:"Format of the change report"`
+	SARIFLevel             string    `yaml:"sarif_level,omitempty" default:"warning" enum:"error,warning,note" description:"Level of results in SARIF reports"`
+	JSONCompact            *bool     `yaml:"json_compact,omitempty" default:"false" description:"Write JSON output on a single line instead of indented"`
+	ShowVersionSource      *bool     `yaml:"show_version_source,omitempty" default:"false" description:"State where each new version came from in text reports"`
+	CanonicalVersions      *bool     `yaml:"canonical_versions,omitempty" default:"false" description:"Treat versions with the same semver precedence as equal, e.g. 1.2 and 1.2.0"`
+	AllowLeadingZeros      *bool     `yaml:"allow_leading_zeros,omitempty" default:"false" description:"Find and update versions with zero-padded components like 01.2.3, dropping the padding"`
+	VersionPrefixAuto      *bool     `yaml:"version_prefix_auto,omitempty" default:"false" description:"Keep a short prefix of annotated versions like v or release- for new versions"`
+	SyncCommentVersion     *bool     `yaml:"sync_comment_version,omitempty" default:"false" description:"Set the version= attribute of depup comments to the new version"`
+	NestedVersions         *bool     `yaml:"nested_versions,omitempty" default:"false" description:"Update the first version-like child of an annotated YAML mapping key without a value"`
+	ErrorOnMultipleMatches *bool     `yaml:"error_on_multiple_matches,omitempty" default:"false" description:"Fail instead of updating the first of several versions on a line annotated with a single package"`
+	QuarantineOnConflict   *bool     `yaml:"quarantine_on_conflict,omitempty" default:"false" description:"Write updates of ambiguous lines and lines kept by a guard to FILE.rej instead of applying them"`
+	StrictSemver           *bool     `yaml:"strict_semver,omitempty" default:"false" description:"Reject versions that aren't strict semantic versions (MAJOR.MINOR.PATCH without leading zeros)"`
+	Transactional          *bool     `yaml:"no_write_on_partial_failure,omitempty" default:"false" description:"Compute all updates before writing and restore written files if any file fails"`
+	Verify                 *bool     `yaml:"verify,omitempty" default:"false" description:"Read written files back and fail if they differ from the expected content"`
+	MaxFileSize            string    `yaml:"max_file_size,omitempty" default:"10MB" description:"Skip files larger than this size with a warning, e.g. 512KB, 0 disables the limit"`
+	FileMode               string    `yaml:"file_mode,omitempty" description:"Octal permissions of updated files, e.g. 0644, the permissions of each file are kept if empty"`
+	Timeout                string    `yaml:"timeout,omitempty" description:"Abort the run if it takes longer than this duration, e.g. 30s"`
+	CacheDir               string    `yaml:"cache_dir,omitempty" description:"Directory of the resolver cache, depup in the user cache directory if empty"`
+	CacheTTL               string    `yaml:"cache_ttl,omitempty" default:"10m" description:"How long versions resolved from datasources are reused, 0 disables the cache"`
+	RegistryAuth           string    `yaml:"registry_auth,omitempty" description:"Netrc-style file with credentials for private registries"`
+	Packages               []Package `yaml:"packages,omitempty" description:"Packages to update, merged with the packages passed on the command line"`
+	Dereference            *bool     `yaml:"dereference_packages,omitempty" default:"false" description:"Resolve the version of packages that name a datasource instead of a version"`
+	WarningsAsErrors       *bool     `yaml:"treat_warnings_as_errors,omitempty" default:"false" description:"Exit with an error after the run if any warning was printed"`
+	ReportOnlyErrors       *bool     `yaml:"report_only_errors,omitempty" default:"false" description:"Apply updates without printing the change report, only warnings and errors are printed"`
+	OnUnparseable          string    `yaml:"on_unparseable,omitempty" default:"skip" enum:"skip,error" description:"Whether packages resolved to a version depup can't write are skipped with a warning or fail the run"`
+}
+
+// Package is a package entry of the configuration file
+// Instead of a literal version, an entry may name a datasource the version is resolved from
+type Package struct {
+	Name       string            `yaml:"name" required:"true" description:"Package name as used in depup comments"`
+	Version    string            `yaml:"version,omitempty" description:"Version to update to"`
+	File       string            `yaml:"file,omitempty" description:"Glob restricting the package to matching files (relative path or base name)"`
+	Datasource string            `yaml:"datasource,omitempty" description:"Datasource the version is resolved from if no version is set, e.g. env"`
+	Options    map[string]string `yaml:",inline" description:"Datasource specific options, e.g. env: APP_VERSION"`
+
+	Resolved bool `yaml:"-"` // Set when Version was resolved from the datasource
+}
+
+// Load reads the configuration file at the given path
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config %s: %w", path, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Find loads the configuration from the given path, or from FileName in the working directory if path is empty
+// Returns an empty configuration if no path is given and the default file doesn't exist
+func Find(path string) (*Config, error) {
+	if path != "" {
+		return Load(path)
+	}
+
+	if _, err := os.Stat(FileName); errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+
+	return Load(FileName)
+}
+
+// Marshal encodes the configuration as the YAML content of a configuration file
+// Unset settings are omitted, so the defaults of flags and environment variables still apply
+func Marshal(cfg *Config) ([]byte, error) {
+	var content bytes.Buffer
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	return content.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, `recursive: true
+extensions: [.yaml, .tf]
+group_by: package
+packages:
+  - name: app
+    version: 2.0.0
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if cfg.Recursive == nil || !*cfg.Recursive {
+		t.Errorf("Load() recursive = %v, expected true", cfg.Recursive)
+	}
+	if cfg.DryRun != nil {
+		t.Errorf("Load() dry_run = %v, expected unset", *cfg.DryRun)
+	}
+	if !reflect.DeepEqual(cfg.Extensions, []string{".yaml", ".tf"}) {
+		t.Errorf("Load() extensions = %v", cfg.Extensions)
+	}
+	if cfg.GroupBy != "package" {
+		t.Errorf("Load() group_by = %q, expected %q", cfg.GroupBy, "package")
+	}
+	if !reflect.DeepEqual(cfg.Packages, []Package{{Name: "app", Version: "2.0.0"}}) {
+		t.Errorf("Load() packages = %v", cfg.Packages)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, "recursive: [not a bool\n")
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() expected error for invalid YAML")
+	}
+}
+
+func TestFind_NoDefaultFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg, err := Find("")
+	if err != nil {
+		t.Fatalf("Find() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("Find() = %+v, expected empty config", cfg)
+	}
+}
+
+func TestFind_DefaultFile(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, filepath.Join(tempDir, FileName), "quote_style: double\n")
+	t.Chdir(tempDir)
+
+	cfg, err := Find("")
+	if err != nil {
+		t.Fatalf("Find() unexpected error: %v", err)
+	}
+	if cfg.QuoteStyle != "double" {
+		t.Errorf("Find() quote_style = %q, expected %q", cfg.QuoteStyle, "double")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	recursive := true
+	cfg := &Config{
+		Recursive:  &recursive,
+		Extensions: []string{".yaml", ".tf"},
+		Packages:   []Package{{Name: "app", Version: "2.0.0"}, {Name: "db"}},
+	}
+
+	content, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+
+	expected := "recursive: true\nextensions:\n  - .yaml\n  - .tf\npackages:\n  - name: app\n    version: 2.0.0\n  - name: db\n"
+	if string(content) != expected {
+		t.Errorf("Marshal() = %q, expected %q", string(content), expected)
+	}
+
+	// The content must load back into the same configuration
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, string(content))
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load(Marshal()) = %+v, expected %+v", loaded, cfg)
+	}
+}
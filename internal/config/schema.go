@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// schemaURI identifies the JSON Schema draft the generated schema conforms to
+const schemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns the JSON Schema describing the configuration file
+// The schema is generated from the yaml, description, default, enum and required tags of Config
+func Schema() ([]byte, error) {
+	schema := schemaForType(reflect.TypeOf(Config{}))
+	schema["$schema"] = schemaURI
+	schema["title"] = "depup configuration"
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaForType returns the schema of a Go type
+func schemaForType(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Struct:
+		return schemaForStruct(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// schemaForStruct returns the object schema of a struct, one property per yaml tagged field
+func schemaForStruct(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	var additionalProperties any = false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		// Inline maps collect all keys without a field of their own
+		if flags == "inline" && field.Type.Kind() == reflect.Map {
+			additionalProperties = schemaForType(field.Type.Elem())
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := schemaForType(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+		if value, ok := field.Tag.Lookup("default"); ok {
+			property["default"] = defaultValue(property["type"], value)
+		}
+		if field.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+
+		properties[name] = property
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": additionalProperties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// defaultValue converts a default tag to a value of the schema type
+func defaultValue(schemaType any, value string) any {
+	switch schemaType {
+	case "boolean":
+		b, _ := strconv.ParseBool(value)
+		return b
+	case "integer":
+		n, _ := strconv.Atoi(value)
+		return n
+	case "array":
+		return strings.Split(value, ",")
+	default:
+		return value
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	raw, err := Schema()
+	if err != nil {
+		t.Fatalf("Schema() unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Schema               string                    `json:"$schema"`
+		Type                 string                    `json:"type"`
+		AdditionalProperties bool                      `json:"additionalProperties"`
+		Properties           map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("Schema() emitted invalid JSON: %v", err)
+	}
+
+	if schema.Schema != schemaURI || schema.Type != "object" || schema.AdditionalProperties {
+		t.Errorf("Schema() header = %q %q %v", schema.Schema, schema.Type, schema.AdditionalProperties)
+	}
+
+	tests := []struct {
+		property string
+		key      string
+		expected any
+	}{
+		{property: "dry_run", key: "type", expected: "boolean"},
+		{property: "dry_run", key: "default", expected: false},
+		{property: "recursive", key: "type", expected: "boolean"},
+		{property: "extensions", key: "type", expected: "array"},
+		{property: "extensions", key: "default", expected: []any{".yaml", ".yml"}},
+		{property: "group_by", key: "enum", expected: []any{"file", "package"}},
+		{property: "group_by", key: "default", expected: "file"},
+		{property: "quote_style", key: "enum", expected: []any{"double", "single", "none"}},
+		{property: "packages", key: "type", expected: "array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.property+"/"+tt.key, func(t *testing.T) {
+			property, ok := schema.Properties[tt.property]
+			if !ok {
+				t.Fatalf("Schema() is missing property %q", tt.property)
+			}
+			if !reflect.DeepEqual(property[tt.key], tt.expected) {
+				t.Errorf("Schema() %s.%s = %v, expected %v", tt.property, tt.key, property[tt.key], tt.expected)
+			}
+		})
+	}
+
+	// Package entries are objects requiring a name
+	items, _ := schema.Properties["packages"]["items"].(map[string]any)
+	if !reflect.DeepEqual(items["required"], []any{"name"}) {
+		t.Errorf("Schema() packages.items.required = %v, expected [name]", items["required"])
+	}
+}
@@ -0,0 +1,209 @@
+// Package editorconfig implements a minimal reader for .editorconfig files.
+// See https://editorconfig.org for the file format specification.
+package editorconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the files looked up when resolving properties
+const FileName = ".editorconfig"
+
+// Properties holds the editorconfig properties that apply to a file
+// Keys and values are lower-cased as defined by the specification
+type Properties map[string]string
+
+// section is a glob pattern together with the properties it defines
+type section struct {
+	pattern    *regexp.Regexp
+	properties Properties
+}
+
+// file is a parsed .editorconfig file
+type file struct {
+	root     bool
+	sections []section
+}
+
+// Resolve returns the properties that apply to the given file
+// All .editorconfig files from the file's directory up to the nearest root file are
+// considered, with properties from files closer to the target taking precedence
+func Resolve(filePath string) (Properties, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect config files from the nearest directory upwards
+	var files []*file
+	for dir := filepath.Dir(absPath); ; {
+		configFile, err := parseFile(filepath.Join(dir, FileName))
+		if err != nil {
+			return nil, err
+		}
+
+		if configFile != nil {
+			files = append(files, configFile)
+			if configFile.root {
+				break
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Apply from the outermost file to the innermost one so closer files win
+	properties := Properties{}
+	target := filepath.ToSlash(absPath)
+	for i := len(files) - 1; i >= 0; i-- {
+		for _, s := range files[i].sections {
+			if !s.pattern.MatchString(target) {
+				continue
+			}
+			for key, value := range s.properties {
+				properties[key] = value
+			}
+		}
+	}
+
+	return properties, nil
+}
+
+// parseFile parses the .editorconfig file at the given path
+// Returns nil without error if the file does not exist
+func parseFile(path string) (*file, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	dir := filepath.ToSlash(filepath.Dir(path))
+	result := &file{}
+	var current *section
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank lines and comments
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		// Section header starts a new glob section
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			pattern, err := compileGlob(dir, line[1:len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid section %s in %s: %w", line, path, err)
+			}
+			result.sections = append(result.sections, section{pattern: pattern, properties: Properties{}})
+			current = &result.sections[len(result.sections)-1]
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		// Properties before the first section belong to the preamble
+		if current == nil {
+			if key == "root" {
+				result.root = value == "true"
+			}
+			continue
+		}
+
+		current.properties[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	return result, nil
+}
+
+// compileGlob translates an editorconfig section glob into a regular expression
+// matching slash-separated absolute paths
+func compileGlob(dir string, glob string) (*regexp.Regexp, error) {
+	// Globs without a slash match files in any directory below the config file
+	switch {
+	case strings.HasPrefix(glob, "/"):
+		glob = glob[1:]
+	case !strings.Contains(glob, "/"):
+		glob = "**/" + glob
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	expr.WriteString(regexp.QuoteMeta(strings.TrimSuffix(dir, "/") + "/"))
+
+	braceDepth := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			// "**/" also matches zero directories
+			if i+1 < len(glob) && glob[i+1] == '/' {
+				i++
+				expr.WriteString("(?:.*/)?")
+			} else {
+				expr.WriteString(".*")
+			}
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			braceDepth++
+			expr.WriteString("(?:")
+		case c == '}' && braceDepth > 0:
+			braceDepth--
+			expr.WriteString(")")
+		case c == ',' && braceDepth > 0:
+			expr.WriteString("|")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if braceDepth > 0 {
+		return nil, fmt.Errorf("unbalanced braces in glob %q", glob)
+	}
+
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
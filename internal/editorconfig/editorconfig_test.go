@@ -0,0 +1,103 @@
+package editorconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFile(t, filepath.Join(tempDir, FileName), `root = true
+
+[*]
+end_of_line = lf
+insert_final_newline = true
+
+[*.{yaml,yml}]
+end_of_line = CRLF
+
+[/infra/**.tf]
+charset = utf-8-bom
+`)
+	writeFile(t, filepath.Join(tempDir, "nested", FileName), `# nested config without root
+[*.yaml]
+insert_final_newline = false
+`)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected Properties
+	}{
+		{
+			name:     "wildcard section",
+			path:     filepath.Join(tempDir, "README.md"),
+			expected: Properties{"end_of_line": "lf", "insert_final_newline": "true"},
+		},
+		{
+			name:     "brace alternatives override earlier section",
+			path:     filepath.Join(tempDir, "deploy", "app.yml"),
+			expected: Properties{"end_of_line": "crlf", "insert_final_newline": "true"},
+		},
+		{
+			name:     "anchored double star glob",
+			path:     filepath.Join(tempDir, "infra", "modules", "main.tf"),
+			expected: Properties{"end_of_line": "lf", "insert_final_newline": "true", "charset": "utf-8-bom"},
+		},
+		{
+			name:     "anchored glob does not match elsewhere",
+			path:     filepath.Join(tempDir, "other", "main.tf"),
+			expected: Properties{"end_of_line": "lf", "insert_final_newline": "true"},
+		},
+		{
+			name:     "closer file takes precedence",
+			path:     filepath.Join(tempDir, "nested", "app.yaml"),
+			expected: Properties{"end_of_line": "crlf", "insert_final_newline": "false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties, err := Resolve(tt.path)
+			if err != nil {
+				t.Fatalf("Resolve() unexpected error: %v", err)
+			}
+
+			if len(properties) != len(tt.expected) {
+				t.Errorf("Resolve() = %v, expected %v", properties, tt.expected)
+			}
+			for key, value := range tt.expected {
+				if properties[key] != value {
+					t.Errorf("Resolve()[%q] = %q, expected %q", key, properties[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve_NoConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, filepath.Join(tempDir, FileName), "root = true\n")
+
+	properties, err := Resolve(filepath.Join(tempDir, "app.yaml"))
+	if err != nil {
+		t.Fatalf("Resolve() unexpected error: %v", err)
+	}
+
+	if len(properties) != 0 {
+		t.Errorf("Resolve() = %v, expected no properties", properties)
+	}
+}
@@ -0,0 +1,178 @@
+package resolver
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AuthProvider supplies the credentials for requests to registry hosts
+type AuthProvider interface {
+	// Authorization returns the Authorization header value for the host, false if no credentials are configured
+	Authorization(host string) (string, bool)
+}
+
+// EnvAuth reads credentials from DEPUP_AUTH_<HOST> environment variables
+// The host is upper-cased and every character other than letters and digits becomes "_",
+// e.g. registry.npmjs.org reads DEPUP_AUTH_REGISTRY_NPMJS_ORG.
+// Values of the form user:password are sent as basic auth, values with a scheme ("Bearer ...") as they are
+// and any other value as bearer token.
+type EnvAuth struct{}
+
+func NewEnvAuth() *EnvAuth {
+	return &EnvAuth{}
+}
+
+func (a *EnvAuth) Authorization(host string) (string, bool) {
+	value, ok := os.LookupEnv(authVariableName(host))
+	if !ok || value == "" {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(value, "Basic ") || strings.HasPrefix(value, "Bearer "):
+		return value, true
+	case strings.Contains(value, ":"):
+		user, password, _ := strings.Cut(value, ":")
+		return basicAuth(user, password), true
+	default:
+		return "Bearer " + value, true
+	}
+}
+
+// authVariableName derives the environment variable holding the credentials of a host
+func authVariableName(host string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(host))
+
+	return "DEPUP_AUTH_" + name
+}
+
+// NetrcAuth holds basic auth credentials read from a netrc-style file
+type NetrcAuth struct {
+	machines map[string]string
+	fallback string // Credentials of the "default" entry, empty if there is none
+}
+
+// LoadNetrcAuth reads the machine, login and password entries of a netrc-style file
+// Macro definitions (macdef) aren't supported.
+func LoadNetrcAuth(path string) (*NetrcAuth, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read registry auth file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read registry auth file %s: %w", path, err)
+	}
+
+	auth := &NetrcAuth{machines: map[string]string{}}
+	var machine, login, password string
+	isDefault := false
+	flush := func() {
+		if machine == "" && !isDefault || login == "" && password == "" {
+			return
+		}
+		if isDefault {
+			auth.fallback = basicAuth(login, password)
+		} else {
+			auth.machines[machine] = basicAuth(login, password)
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		// Every keyword except default takes the following token as value
+		if tokens[i] != "default" && i+1 >= len(tokens) {
+			return nil, fmt.Errorf("invalid registry auth file %s: missing value for %q", path, tokens[i])
+		}
+
+		switch tokens[i] {
+		case "machine":
+			flush()
+			machine, login, password, isDefault = tokens[i+1], "", "", false
+			i++
+		case "default":
+			flush()
+			machine, login, password, isDefault = "", "", "", true
+		case "login":
+			login = tokens[i+1]
+			i++
+		case "password":
+			password = tokens[i+1]
+			i++
+		case "account":
+			i++
+		default:
+			return nil, fmt.Errorf("invalid registry auth file %s: unknown keyword %q", path, tokens[i])
+		}
+	}
+	flush()
+
+	return auth, nil
+}
+
+func (a *NetrcAuth) Authorization(host string) (string, bool) {
+	if credentials, ok := a.machines[host]; ok {
+		return credentials, true
+	}
+
+	return a.fallback, a.fallback != ""
+}
+
+// ChainAuth asks each provider in order and returns the first credentials found
+type ChainAuth []AuthProvider
+
+func (c ChainAuth) Authorization(host string) (string, bool) {
+	for _, provider := range c {
+		if credentials, ok := provider.Authorization(host); ok {
+			return credentials, true
+		}
+	}
+
+	return "", false
+}
+
+// basicAuth returns the Authorization header value for basic auth
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// authTransport sets the Authorization header of requests to hosts with configured credentials
+type authTransport struct {
+	base http.RoundTripper
+	auth AuthProvider
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.auth == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	credentials, ok := t.auth.Authorization(req.URL.Hostname())
+	if !ok || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the caller's request
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", credentials)
+
+	return t.base.RoundTrip(authenticated)
+}
@@ -0,0 +1,150 @@
+package resolver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvAuth_Authorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		ok       bool
+	}{
+		{name: "token", value: "secret", expected: "Bearer secret", ok: true},
+		{name: "user and password", value: "user:pass", expected: "Basic dXNlcjpwYXNz", ok: true},
+		{name: "header value", value: "Bearer abc:def", expected: "Bearer abc:def", ok: true},
+		{name: "empty", value: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEPUP_AUTH_REGISTRY_EXAMPLE_COM", tt.value)
+
+			credentials, ok := NewEnvAuth().Authorization("registry.example.com")
+			if credentials != tt.expected || ok != tt.ok {
+				t.Errorf("Authorization() = %q, %t, expected %q, %t", credentials, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLoadNetrcAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		host     string
+		expected string
+		ok       bool
+		wantErr  bool
+	}{
+		{name: "machine", content: "machine registry.example.com login user password pass\n", host: "registry.example.com", expected: "Basic dXNlcjpwYXNz", ok: true},
+		{name: "multi-line entries", content: "# private registries\nmachine a.example.com\n  login a\n  password 1\nmachine registry.example.com\n  login user\n  password pass\n", host: "registry.example.com", expected: "Basic dXNlcjpwYXNz", ok: true},
+		{name: "other host", content: "machine a.example.com login user password pass\n", host: "registry.example.com", ok: false},
+		{name: "default", content: "machine a.example.com login a password 1\ndefault login user password pass\n", host: "registry.example.com", expected: "Basic dXNlcjpwYXNz", ok: true},
+		{name: "missing value", content: "machine registry.example.com login", wantErr: true},
+		{name: "unknown keyword", content: "machine registry.example.com token abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "netrc")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			auth, err := LoadNetrcAuth(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadNetrcAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if strings.Contains(err.Error(), "pass") {
+					t.Errorf("LoadNetrcAuth() error leaks credentials: %v", err)
+				}
+				return
+			}
+
+			credentials, ok := auth.Authorization(tt.host)
+			if credentials != tt.expected || ok != tt.ok {
+				t.Errorf("Authorization() = %q, %t, expected %q, %t", credentials, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRegistry_HTTPClient(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	// The server is reachable as 127.0.0.1 and as localhost, only the former has credentials
+	t.Setenv("DEPUP_AUTH_127_0_0_1", "secret")
+	client := NewRegistry().HTTPClient()
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		expected string
+	}{
+		{name: "configured host", url: "http://127.0.0.1" + port, expected: "Bearer secret"},
+		{name: "other host", url: "http://localhost" + port, expected: ""},
+		{name: "explicit header", url: "http://127.0.0.1" + port, header: "Bearer other", expected: "Bearer other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header = ""
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if header != tt.expected {
+				t.Errorf("Authorization header = %q, expected %q", header, tt.expected)
+			}
+			if tt.header == "" && req.Header.Get("Authorization") != "" {
+				t.Error("request of the caller was modified")
+			}
+		})
+	}
+}
+
+func TestRegistry_SetAuth(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	client := registry.HTTPClient()
+	registry.SetAuth(ChainAuth{NewEnvAuth(), &NetrcAuth{machines: map[string]string{"127.0.0.1": "Basic abc"}}})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if header != "Basic abc" {
+		t.Errorf("Authorization header = %q, expected the credentials set after the client was taken", header)
+	}
+}
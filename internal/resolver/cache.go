@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultCacheTTL is how long resolved versions are reused by default
+const DefaultCacheTTL = 10 * time.Minute
+
+// cacheEntry is the file content of a cached version
+type cacheEntry struct {
+	Version    string    `json:"version"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// CachedResolver reuses versions resolved by another resolver for a limited time
+// Entries are stored as one file per request in the cache directory, so they are shared between runs
+type CachedResolver struct {
+	resolver VersionResolver
+	dir      string
+	ttl      time.Duration
+	log      io.Writer
+	now      func() time.Time
+}
+
+// NewCachedResolver wraps the resolver with a cache in dir whose entries expire after ttl
+// Cache hits, misses and write failures are logged to log
+func NewCachedResolver(resolver VersionResolver, dir string, ttl time.Duration, log io.Writer) *CachedResolver {
+	return &CachedResolver{resolver: resolver, dir: dir, ttl: ttl, log: log, now: time.Now}
+}
+
+// DefaultCacheDir returns the depup directory in the user's cache directory, e.g. $XDG_CACHE_HOME/depup
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "depup"), nil
+}
+
+func (c *CachedResolver) Resolve(ctx context.Context, request Request) (string, error) {
+	// Environment variables are local and may change between runs, so they are never cached
+	if request.Datasource == DatasourceEnv {
+		return c.resolver.Resolve(ctx, request)
+	}
+
+	path := filepath.Join(c.dir, cacheKey(request)+".json")
+	if entry, ok := c.read(path); ok {
+		fmt.Fprintf(c.log, "Resolver cache hit for %s from %s: %s\n", request.Package, request.Datasource, entry.Version)
+		return entry.Version, nil
+	}
+	fmt.Fprintf(c.log, "Resolver cache miss for %s from %s\n", request.Package, request.Datasource)
+
+	version, err := c.resolver.Resolve(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.write(path, cacheEntry{Version: version, ResolvedAt: c.now()}); err != nil {
+		fmt.Fprintf(c.log, "Cannot write resolver cache: %v\n", err)
+	}
+
+	return version, nil
+}
+
+// read returns the cache entry stored at path, if it exists and hasn't expired
+func (c *CachedResolver) read(path string) (cacheEntry, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.Version == "" {
+		return cacheEntry{}, false
+	}
+	if c.now().Sub(entry.ResolvedAt) >= c.ttl {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// write stores the cache entry at path, replacing any existing entry atomically
+func (c *CachedResolver) write(path string, entry cacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), path)
+}
+
+// cacheKey derives a file name safe key from the datasource, package and options of a request
+func cacheKey(request Request) string {
+	keys := make([]string, 0, len(request.Options))
+	for key := range request.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s", request.Datasource, request.Package)
+	for _, key := range keys {
+		fmt.Fprintf(hash, "\x00%s=%s", key, request.Options[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachedResolver_Resolve(t *testing.T) {
+	calls := 0
+	upstream := VersionResolverFunc(func(ctx context.Context, request Request) (string, error) {
+		calls++
+		if request.Package == "broken" {
+			return "", errors.New("upstream unavailable")
+		}
+		return "2.0.0", nil
+	})
+
+	dir := t.TempDir()
+	var log bytes.Buffer
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newCache := func() *CachedResolver {
+		cache := NewCachedResolver(upstream, dir, time.Minute, &log)
+		cache.now = func() time.Time { return now }
+		return cache
+	}
+	request := Request{Package: "app", Datasource: "mock"}
+
+	// The first run misses and stores the version
+	if version, err := newCache().Resolve(context.Background(), request); err != nil || version != "2.0.0" {
+		t.Fatalf("Resolve() = %q, %v, expected 2.0.0", version, err)
+	}
+	if calls != 1 || !strings.Contains(log.String(), "Resolver cache miss for app from mock") {
+		t.Errorf("expected a cache miss, got %d calls and log %q", calls, log.String())
+	}
+
+	// A later run within the TTL hits the cache
+	log.Reset()
+	now = now.Add(30 * time.Second)
+	if version, err := newCache().Resolve(context.Background(), request); err != nil || version != "2.0.0" {
+		t.Fatalf("Resolve() = %q, %v, expected 2.0.0", version, err)
+	}
+	if calls != 1 || !strings.Contains(log.String(), "Resolver cache hit for app from mock: 2.0.0") {
+		t.Errorf("expected a cache hit, got %d calls and log %q", calls, log.String())
+	}
+
+	// Different options are cached separately
+	if _, err := newCache().Resolve(context.Background(), Request{Package: "app", Datasource: "mock", Options: map[string]string{"registry": "other"}}); err != nil {
+		t.Fatalf("Resolve() unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a cache miss for different options, got %d calls", calls)
+	}
+
+	// Expired entries are resolved again
+	log.Reset()
+	now = now.Add(time.Minute)
+	if _, err := newCache().Resolve(context.Background(), request); err != nil {
+		t.Fatalf("Resolve() unexpected error: %v", err)
+	}
+	if calls != 3 || !strings.Contains(log.String(), "Resolver cache miss") {
+		t.Errorf("expected a cache miss after expiry, got %d calls and log %q", calls, log.String())
+	}
+
+	// Errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := newCache().Resolve(context.Background(), Request{Package: "broken", Datasource: "mock"}); err == nil {
+			t.Fatal("Resolve() expected error")
+		}
+	}
+	if calls != 5 {
+		t.Errorf("expected errors not to be cached, got %d calls", calls)
+	}
+}
+
+func TestCachedResolver_Resolve_Env(t *testing.T) {
+	t.Setenv("DEPUP_VERSION_APP", "1.0.0")
+	cache := NewCachedResolver(NewEnvResolver(), t.TempDir(), time.Hour, &bytes.Buffer{})
+	request := Request{Package: "app", Datasource: DatasourceEnv}
+
+	if version, _ := cache.Resolve(context.Background(), request); version != "1.0.0" {
+		t.Fatalf("Resolve() = %q, expected 1.0.0", version)
+	}
+
+	// Environment variables are read on every run
+	t.Setenv("DEPUP_VERSION_APP", "2.0.0")
+	if version, _ := cache.Resolve(context.Background(), request); version != "2.0.0" {
+		t.Errorf("Resolve() = %q, expected 2.0.0", version)
+	}
+}
@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DatasourceEnv is the name of the datasource reading versions from environment variables
+const DatasourceEnv = "env"
+
+// EnvResolver resolves versions from environment variables
+// The variable is taken from the "env" option, or derived from the package name:
+// package "my-app" reads DEPUP_VERSION_MY_APP
+type EnvResolver struct{}
+
+func NewEnvResolver() *EnvResolver {
+	return &EnvResolver{}
+}
+
+func (r *EnvResolver) Resolve(ctx context.Context, request Request) (string, error) {
+	name := request.Options["env"]
+	if name == "" {
+		name = envVariableName(request.Package)
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
+
+// envVariableName derives the default variable name of a package
+func envVariableName(packageName string) string {
+	name := strings.ToUpper(packageName)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+
+	return "DEPUP_VERSION_" + name
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnvResolver_Resolve(t *testing.T) {
+	t.Setenv("APP_VERSION", "1.2.3")
+	t.Setenv("DEPUP_VERSION_MY_APP", "4.5.6")
+
+	tests := []struct {
+		name        string
+		request     Request
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "variable from options",
+			request:  Request{Package: "app", Options: map[string]string{"env": "APP_VERSION"}},
+			expected: "1.2.3",
+		},
+		{
+			name:     "variable derived from package name",
+			request:  Request{Package: "my-app"},
+			expected: "4.5.6",
+		},
+		{
+			name:        "variable not set",
+			request:     Request{Package: "other"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := NewEnvResolver().Resolve(context.Background(), tt.request)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Resolve() error = %v, expectError %v", err, tt.expectError)
+			}
+			if version != tt.expected {
+				t.Errorf("Resolve() = %q, expected %q", version, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package resolver resolves package versions at runtime from datasources.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Request describes the package to resolve
+type Request struct {
+	Package    string            // Name of the package
+	Datasource string            // Name of the datasource to resolve from
+	Options    map[string]string // Datasource specific options, e.g. the env variable to read
+}
+
+// VersionResolver resolves the version of a package from a single datasource
+type VersionResolver interface {
+	Resolve(ctx context.Context, request Request) (string, error)
+}
+
+// VersionResolverFunc adapts a function to the VersionResolver interface
+type VersionResolverFunc func(ctx context.Context, request Request) (string, error)
+
+func (f VersionResolverFunc) Resolve(ctx context.Context, request Request) (string, error) {
+	return f(ctx, request)
+}
+
+// Registry holds the available datasources by name
+type Registry struct {
+	resolvers map[string]VersionResolver
+	transport *authTransport // Authenticates the requests of the shared HTTP client
+	client    *http.Client   // Shared by datasources querying registries over HTTP
+}
+
+// NewRegistry creates a registry with the built-in datasources
+// HTTP requests are authenticated with credentials from DEPUP_AUTH_<HOST> environment variables
+func NewRegistry() *Registry {
+	transport := &authTransport{base: http.DefaultTransport, auth: NewEnvAuth()}
+	r := &Registry{resolvers: map[string]VersionResolver{}, transport: transport, client: &http.Client{Transport: transport}}
+	r.Register(DatasourceEnv, NewEnvResolver())
+
+	return r
+}
+
+// SetAuth replaces the credentials used for HTTP requests of the datasources
+// Must be called before resolving, the client returned by HTTPClient picks up the change.
+func (r *Registry) SetAuth(auth AuthProvider) {
+	r.transport.auth = auth
+}
+
+// HTTPClient returns the client datasources must use for HTTP requests, so registry credentials are applied
+func (r *Registry) HTTPClient() *http.Client {
+	return r.client
+}
+
+// Register adds a datasource, replacing any datasource of the same name
+func (r *Registry) Register(datasource string, resolver VersionResolver) {
+	r.resolvers[datasource] = resolver
+}
+
+// Datasources returns the sorted names of the registered datasources
+func (r *Registry) Datasources() []string {
+	names := make([]string, 0, len(r.resolvers))
+	for name := range r.resolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Resolve resolves the request with the resolver of its datasource
+func (r *Registry) Resolve(ctx context.Context, request Request) (string, error) {
+	resolver, ok := r.resolvers[request.Datasource]
+	if !ok {
+		return "", fmt.Errorf("unknown datasource %q for package %s", request.Datasource, request.Package)
+	}
+
+	version, err := resolver.Resolve(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve package %s from %s: %w", request.Package, request.Datasource, err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,56 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegistry_Resolve(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("mock", VersionResolverFunc(func(ctx context.Context, request Request) (string, error) {
+		if request.Package == "broken" {
+			return "", errors.New("upstream unavailable")
+		}
+		return "2.0.0", nil
+	}))
+
+	tests := []struct {
+		name        string
+		request     Request
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "registered datasource",
+			request:  Request{Package: "app", Datasource: "mock"},
+			expected: "2.0.0",
+		},
+		{
+			name:        "datasource error",
+			request:     Request{Package: "broken", Datasource: "mock"},
+			expectError: true,
+		},
+		{
+			name:        "unknown datasource",
+			request:     Request{Package: "app", Datasource: "missing"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := registry.Resolve(context.Background(), tt.request)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Resolve() error = %v, expectError %v", err, tt.expectError)
+			}
+			if version != tt.expected {
+				t.Errorf("Resolve() = %q, expected %q", version, tt.expected)
+			}
+		})
+	}
+
+	if datasources := registry.Datasources(); len(datasources) != 2 || datasources[0] != DatasourceEnv || datasources[1] != "mock" {
+		t.Errorf("Datasources() = %v, expected [env mock]", datasources)
+	}
+}
@@ -0,0 +1,153 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// AnnotationRule selects version lines that get a depup comment for a package
+type AnnotationRule struct {
+	Package string // Package name written to the depup comment
+	Key     string // Key of the version line, e.g. "image" in YAML or "version" in HCL, empty matches any key
+	File    string // Glob restricting the rule to matching files (relative path or base name), empty matches all files
+	Line    int    // 1-based line number of the version, 0 matches all lines
+}
+
+// commentWriter is implemented by FileUpdaters that can write depup comments on their own line
+type commentWriter interface {
+	// depupComment returns a depup comment for the package in the comment style of the format
+	depupComment(packageName string) string
+}
+
+// lineKeyPattern matches the key of a YAML mapping entry, an HCL attribute or a dotenv variable
+var /* const */ lineKeyPattern = regexp.MustCompile(`^\s*(?:-\s+)?(?:export\s+)?["']?([\w.-]+)["']?\s*[:=]`)
+
+// leadingWhitespacePattern matches the indentation of a line
+var /* const */ leadingWhitespacePattern = regexp.MustCompile(`^[ \t]*`)
+
+// Annotate inserts depup comments for the versions selected by the rules into the files of the entrypoint
+// Only lines holding a semantic version that isn't annotated yet are considered, the first matching rule wins.
+// Comments are written on their own line at the configured comment position with the indentation of the version.
+// Returns the inserted annotations, with line numbers of the versions after the insertion.
+func (u *Updater) Annotate(entrypoint string, rules []AnnotationRule) ([]Annotation, error) {
+	files, err := u.Discover(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo, err := os.Stat(root); err == nil && !fileInfo.IsDir() {
+		root = filepath.Dir(root)
+	}
+
+	var annotations []Annotation
+	for _, file := range files {
+		fileAnnotations, err := u.annotateFile(root, file, rules)
+		if err != nil {
+			return annotations, err
+		}
+		annotations = append(annotations, fileAnnotations...)
+	}
+
+	return annotations, nil
+}
+
+// annotateFile inserts the depup comments of the rules into a single file
+func (u *Updater) annotateFile(root, file string, rules []AnnotationRule) ([]Annotation, error) {
+	updater, err := u.getFileUpdaterForPath(file)
+	if err != nil {
+		return nil, err
+	}
+	writer, canWrite := updater.(commentWriter)
+	analyzer, canAnalyze := updater.(LineAnalyzer)
+	if !canWrite || !canAnalyze {
+		return nil, nil
+	}
+
+	// Lines that are already annotated must not get a second comment
+	analysis, err := analyzer.AnalyzeFile(file, nil)
+	if err != nil {
+		return nil, err
+	}
+	annotated := map[int]bool{}
+	for _, line := range analysis {
+		if line.Package != "" {
+			annotated[line.Line] = true
+		}
+	}
+
+	unlock := u.fileLocks.lock(file)
+	defer unlock()
+
+	lines, format, err := readFileLines(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations []Annotation
+	output := make([]string, 0, len(lines))
+	for i, line := range lines {
+		rule, ok := matchAnnotationRule(root, file, i+1, line, rules)
+		if !ok || annotated[i+1] {
+			output = append(output, line)
+			continue
+		}
+		match, ok := findSemver(line)
+		if !ok {
+			output = append(output, line)
+			continue
+		}
+
+		comment := leadingWhitespacePattern.FindString(line) + writer.depupComment(rule.Package)
+		if u.commentPosition == CommentPositionBelow {
+			output = append(output, line, comment)
+		} else {
+			output = append(output, comment, line)
+		}
+
+		// The version moves down by the comment above it
+		lineNumber := len(output)
+		if u.commentPosition == CommentPositionBelow {
+			lineNumber--
+		}
+		annotations = append(annotations, Annotation{File: file, Line: lineNumber, Package: rule.Package, Version: match.version, Content: line, Updater: updater.Name()})
+	}
+
+	if len(annotations) == 0 || u.dryRun {
+		return annotations, nil
+	}
+
+	if err := os.WriteFile(file, []byte(format.render(output)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write annotations to %s: %w", file, err)
+	}
+
+	return annotations, nil
+}
+
+// matchAnnotationRule returns the first rule selecting the line of the file
+func matchAnnotationRule(root, file string, lineNumber int, line string, rules []AnnotationRule) (AnnotationRule, bool) {
+	key := ""
+	if keyMatches := lineKeyPattern.FindStringSubmatch(line); keyMatches != nil {
+		key = keyMatches[1]
+	}
+
+	for _, rule := range rules {
+		if rule.File != "" && !matchesPathPattern(root, file, rule.File) {
+			continue
+		}
+		if rule.Line != 0 && rule.Line != lineNumber {
+			continue
+		}
+		if rule.Key != "" && rule.Key != key {
+			continue
+		}
+		return rule, true
+	}
+
+	return AnnotationRule{}, false
+}
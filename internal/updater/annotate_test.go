@@ -0,0 +1,101 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdater_Annotate(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		fileContent     string
+		rules           []AnnotationRule
+		options         []Option
+		expectedContent string
+		expectedLines   []int
+	}{
+		{
+			name:            "YAML by key keeps the indentation",
+			file:            "deploy.yaml",
+			fileContent:     "spec:\n  containers:\n    - name: app\n      image: app:1.0.0\n    - name: db\n      image: postgres:15.4.0\n",
+			rules:           []AnnotationRule{{Package: "app", Key: "image", File: "deploy.yaml", Line: 4}, {Package: "postgres", Key: "image"}},
+			expectedContent: "spec:\n  containers:\n    - name: app\n      # depup package=app\n      image: app:1.0.0\n    - name: db\n      # depup package=postgres\n      image: postgres:15.4.0\n",
+			expectedLines:   []int{5, 8},
+		},
+		{
+			name:            "YAML skips annotated lines",
+			file:            "values.yaml",
+			fileContent:     "# depup package=app\nversion: 1.0.0\nother: 2.0.0 # depup package=other\n",
+			rules:           []AnnotationRule{{Package: "app"}},
+			expectedContent: "# depup package=app\nversion: 1.0.0\nother: 2.0.0 # depup package=other\n",
+		},
+		{
+			name:            "HCL by location",
+			file:            "main.tf",
+			fileContent:     "terraform {\n  required_providers {\n    aws = {\n      version = \"4.0.0\"\n    }\n  }\n}\n",
+			rules:           []AnnotationRule{{Package: "aws", File: "main.tf", Line: 4}},
+			options:         []Option{WithFileExtensions([]string{".tf"})},
+			expectedContent: "terraform {\n  required_providers {\n    aws = {\n      # depup package=aws\n      version = \"4.0.0\"\n    }\n  }\n}\n",
+			expectedLines:   []int{5},
+		},
+		{
+			name:            "HCL with comments below",
+			file:            "main.tf",
+			fileContent:     "module \"vpc\" {\n  version = \"3.14.0\"\n}\n",
+			rules:           []AnnotationRule{{Package: "vpc", Key: "version"}},
+			options:         []Option{WithFileExtensions([]string{".tf"}), WithCommentPosition(CommentPositionBelow)},
+			expectedContent: "module \"vpc\" {\n  version = \"3.14.0\"\n  # depup package=vpc\n}\n",
+			expectedLines:   []int{2},
+		},
+		{
+			name:            "Lines without version are not annotated",
+			file:            "app.yaml",
+			fileContent:     "image: app:latest\n",
+			rules:           []AnnotationRule{{Package: "app", Key: "image"}},
+			expectedContent: "image: app:latest\n",
+		},
+		{
+			name:            "Dry run leaves the file unchanged",
+			file:            "app.yaml",
+			fileContent:     "image: app:1.0.0\n",
+			rules:           []AnnotationRule{{Package: "app", Key: "image"}},
+			options:         []Option{WithDryRun(true)},
+			expectedContent: "image: app:1.0.0\n",
+			expectedLines:   []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			annotations, err := NewUpdater(tt.options...).Annotate(dir, tt.rules)
+			if err != nil {
+				t.Fatalf("Annotate() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+
+			if len(annotations) != len(tt.expectedLines) {
+				t.Fatalf("Annotate() = %+v, expected %d annotations", annotations, len(tt.expectedLines))
+			}
+			for i, annotation := range annotations {
+				if annotation.Line != tt.expectedLines[i] || annotation.File != filePath {
+					t.Errorf("annotation %d = %s:%d, expected %s:%d", i, annotation.File, annotation.Line, filePath, tt.expectedLines[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// bazelFileNames are the file names handled by the BazelUpdater, besides files with the .bzl extension
+var /* const */ bazelFileNames = []string{"MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel"}
+
+// bazelStringPattern matches a Starlark string literal, capturing its content without the quotes
+var /* const */ bazelStringPattern = regexp.MustCompile(`"([^"\\]*)"|'([^'\\]*)'`)
+
+// bazelVersionAttributePattern matches the version attribute of a call, e.g. `version = "1.2.3"` in a bazel_dep
+var /* const */ bazelVersionAttributePattern = regexp.MustCompile(`\bversion\s*=\s*(?:"[^"\\]*"|'[^'\\]*')`)
+
+// BazelUpdater updates version pins in Bazel WORKSPACE, MODULE.bazel and .bzl files
+// Pins are annotated with depup comments like in YAML files:
+//
+//	# depup package=rules_go
+//	bazel_dep(name = "rules_go", version = "0.41.0")
+//
+// The version attribute of a call is preferred, otherwise the first string holding a version is updated, e.g. an
+// archive URL. Every occurrence of the version within that string is updated, so a URL like
+// ".../v0.41.0/rules_go-v0.41.0.zip" stays consistent.
+type BazelUpdater struct {
+	supportedFileExtensions map[string]struct{}
+	comments                commentSyntax
+	scheme                  string // Version scheme used for comments without a scheme attribute
+	canonical               bool   // When true, versions with the same semver precedence are equal
+	leadingZeros            bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	prefixAuto              bool   // When true, the prefix of the version in the file is kept for the new version
+}
+
+func NewBazelUpdater() *BazelUpdater {
+	return &BazelUpdater{
+		supportedFileExtensions: map[string]struct{}{
+			".bzl": {},
+		},
+		comments: newCommentSyntax("#"),
+	}
+}
+
+func (u *BazelUpdater) Name() string {
+	return "bazel"
+}
+
+func (u *BazelUpdater) Supports(fileExtension string) bool {
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
+}
+
+func (u *BazelUpdater) GetSupportedExtensions() []string {
+	extensions := make([]string, 0, len(u.supportedFileExtensions)+len(bazelFileNames))
+	for ext := range u.supportedFileExtensions {
+		extensions = append(extensions, ext)
+	}
+	return append(extensions, bazelFileNames...)
+}
+
+// SupportsFileName reports whether the updater handles files with the given base name
+func (u *BazelUpdater) SupportsFileName(fileName string) bool {
+	for _, name := range bazelFileNames {
+		if fileName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *BazelUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	// Read file and prepare data
+	lines, format, err := readFileLines(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Process lines with the scheme of this run and build output
+	processor := *u
+	processor.scheme = options.Scheme
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.prefixAuto = options.VersionPrefixAuto
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
+
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
+		err = os.WriteFile(filePath, []byte(outputContent), 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return outputContent, changes, nil
+}
+
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *BazelUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
+
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *BazelUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
+
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *BazelUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
+
+// depupComments returns the syntax depup comments are recognized by
+func (u *BazelUpdater) depupComments() commentSyntax {
+	return u.comments
+}
+
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *BazelUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *BazelUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
+
+	lineContent, comment, depupDirective, ok := u.comments.splitInlineComment(line)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the call and try to update it
+	updated := u.updateLine(lineContent, depupDirective, packages)
+	if updated.change != nil {
+		updated.line += comment
+	} else {
+		updated.line = line
+	}
+
+	return updated
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *BazelUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the call and try to update it
+	return u.updateLine(currentLine, depupDirective, packages)
+}
+
+// blockScalarLines returns the number of lines following the line that belong to a call left open on the line,
+// e.g. the attributes of a multi-line http_archive, so a comment above the call annotates its first version
+func (u *BazelUpdater) blockScalarLines(lines []string, index int) int {
+	depth := 0
+	for i := index; i < len(lines); i++ {
+		code := lines[i]
+		if content, _, ok := u.splitComment(code); ok {
+			code = content
+		}
+		code = bazelStringPattern.ReplaceAllString(code, `""`)
+		depth += strings.Count(code, "(") + strings.Count(code, "[") - strings.Count(code, ")") - strings.Count(code, "]")
+		if depth <= 0 {
+			return i - index
+		}
+	}
+	return len(lines) - 1 - index
+}
+
+// splitComment splits a line into its code and a trailing comment outside of string literals
+func (u *BazelUpdater) splitComment(line string) (string, string, bool) {
+	masked := bazelStringPattern.ReplaceAllStringFunc(line, func(literal string) string {
+		return strings.Repeat("_", len(literal))
+	})
+	index := strings.Index(masked, "#")
+	if index < 0 {
+		return line, "", false
+	}
+	return line[:index], line[index:], true
+}
+
+// updateLine finds the string holding the version of the directive and updates every occurrence of the version
+// The version of the result is empty if no version was found.
+func (u *BazelUpdater) updateLine(content string, d directive, packages []Package) lineResult {
+	result := lineResult{line: content, packageName: d.packageName}
+
+	scheme, ok := lookupScheme(d, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+
+	start, end, ok := u.findVersionString(content, scheme)
+	if !ok {
+		return result
+	}
+	value := content[start:end]
+
+	match, _ := scheme.find(value)
+	result.version = match.version
+	result.matches = countDistinctVersions(scheme, value)
+
+	// Skip the update if the from= guard doesn't hold or the scheme refuses a downgrade
+	if skip := checkFromGuard(d, packages, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+
+	// Try to update the version
+	updatedValue, change := u.updateVersion(value, d, packages, scheme, match)
+	if change == nil {
+		return result
+	}
+
+	result.line = content[:start] + updatedValue + content[end:]
+	result.change = change
+
+	return result
+}
+
+// findVersionString returns the bounds of the content of the string literal holding the version
+// The version attribute of a call takes precedence over other strings like the name of a bazel_dep.
+func (u *BazelUpdater) findVersionString(content string, scheme versionScheme) (int, int, bool) {
+	offset := 0
+	if location := bazelVersionAttributePattern.FindStringIndex(content); location != nil {
+		offset = location[0]
+	}
+
+	for _, literal := range bazelStringPattern.FindAllStringSubmatchIndex(content[offset:], -1) {
+		// Either the double or the single quoted group holds the content
+		start, end := literal[2], literal[3]
+		if start < 0 {
+			start, end = literal[4], literal[5]
+		}
+		if _, ok := scheme.find(content[offset+start : offset+end]); ok {
+			return offset + start, offset + end, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// countDistinctVersions returns the number of different versions in the content
+// Repetitions of a version, e.g. in the path and file name of an archive URL, are counted once.
+func countDistinctVersions(scheme versionScheme, content string) int {
+	versions := map[string]struct{}{}
+	for {
+		match, ok := scheme.find(content)
+		if !ok {
+			return len(versions)
+		}
+		versions[match.version] = struct{}{}
+		content = content[strings.Index(content, match.text)+len(match.text):]
+	}
+}
+
+// updateVersion updates every occurrence of the version in the string if the package name of the directive matches
+// With a replace attribute, the value around the first occurrence is substituted
+func (u *BazelUpdater) updateVersion(value string, d directive, packages []Package, scheme versionScheme, match versionMatch) (string, *Change) {
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName {
+			currentVersion := match.version
+			targetVersion := scheme.format(currentVersion, packageVersion(pkg, u.prefixAuto))
+
+			if template, ok := d.attributes[replaceAttribute]; ok {
+				updatedValue, changed := replaceMatchedValue(value, match, renderReplaceTemplate(template, targetVersion))
+				if !changed {
+					return value, nil
+				}
+				return updatedValue, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+			}
+
+			targetVersion = trimVPrefix(targetVersion)
+			if versionsEqual(currentVersion, targetVersion, u.canonical) {
+				return value, nil
+			}
+
+			// Replace each occurrence of the current version, keeping other versions and the text around them
+			var updatedValue strings.Builder
+			rest := value
+			for {
+				next, ok := scheme.find(rest)
+				if !ok {
+					break
+				}
+				index := strings.Index(rest, next.text)
+				updatedValue.WriteString(rest[:index])
+				if next.version == currentVersion {
+					updatedValue.WriteString(next.startQuote + targetVersion + next.endQuote)
+				} else {
+					updatedValue.WriteString(next.text)
+				}
+				rest = rest[index+len(next.text):]
+			}
+			updatedValue.WriteString(rest)
+
+			return updatedValue.String(), &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+		}
+	}
+
+	return value, nil
+}
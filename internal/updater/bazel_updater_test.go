@@ -0,0 +1,112 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBazelUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		packages        []Package
+		expectedOutput  string
+		expectedChanges []Change
+	}{
+		{
+			name:            "Comment above a bazel_dep",
+			fileContent:     "# depup package=rules_go\nbazel_dep(name = \"rules_go\", version = \"0.41.0\")\n",
+			packages:        []Package{{Name: "rules_go", Version: "0.42.0"}},
+			expectedOutput:  "# depup package=rules_go\nbazel_dep(name = \"rules_go\", version = \"0.42.0\")\n",
+			expectedChanges: []Change{{Line: 2, Package: "rules_go", OldVersion: "0.41.0", NewVersion: "0.42.0"}},
+		},
+		{
+			name:            "Inline comment and version attribute before the name",
+			fileContent:     "bazel_dep(version = '1.4.1', name = \"protobuf-3.0.0\") # depup package=protobuf\n",
+			packages:        []Package{{Name: "protobuf", Version: "1.5.0"}},
+			expectedOutput:  "bazel_dep(version = '1.5.0', name = \"protobuf-3.0.0\") # depup package=protobuf\n",
+			expectedChanges: []Change{{Line: 1, Package: "protobuf", OldVersion: "1.4.1", NewVersion: "1.5.0"}},
+		},
+		{
+			name: "Archive URL",
+			fileContent: "http_archive(\n    name = \"io_bazel_rules_go\",\n    # depup package=rules_go\n" +
+				"    urls = [\"https://github.com/bazelbuild/rules_go/releases/download/v0.41.0/rules_go-v0.41.0.zip\"],\n)\n",
+			packages: []Package{{Name: "rules_go", Version: "v0.42.0"}},
+			expectedOutput: "http_archive(\n    name = \"io_bazel_rules_go\",\n    # depup package=rules_go\n" +
+				"    urls = [\"https://github.com/bazelbuild/rules_go/releases/download/v0.42.0/rules_go-v0.42.0.zip\"],\n)\n",
+			expectedChanges: []Change{{Line: 4, Package: "rules_go", OldVersion: "0.41.0", NewVersion: "0.42.0"}},
+		},
+		{
+			name: "Comment above a multi-line call",
+			fileContent: "# depup package=gazelle\nhttp_archive(\n    name = \"bazel_gazelle\",\n" +
+				"    url = \"https://github.com/bazelbuild/bazel-gazelle/releases/download/v0.33.0/bazel-gazelle-v0.33.0.tar.gz\",\n)\n",
+			packages: []Package{{Name: "gazelle", Version: "0.35.0"}},
+			expectedOutput: "# depup package=gazelle\nhttp_archive(\n    name = \"bazel_gazelle\",\n" +
+				"    url = \"https://github.com/bazelbuild/bazel-gazelle/releases/download/v0.35.0/bazel-gazelle-v0.35.0.tar.gz\",\n)\n",
+			expectedChanges: []Change{{Line: 4, Package: "gazelle", OldVersion: "0.33.0", NewVersion: "0.35.0"}},
+		},
+		{
+			name:           "Up to date",
+			fileContent:    "bazel_dep(name = \"rules_go\", version = \"0.42.0\") # depup package=rules_go\n",
+			packages:       []Package{{Name: "rules_go", Version: "0.42.0"}},
+			expectedOutput: "bazel_dep(name = \"rules_go\", version = \"0.42.0\") # depup package=rules_go\n",
+		},
+		{
+			name:           "Unannotated call",
+			fileContent:    "bazel_dep(name = \"rules_go\", version = \"0.41.0\")\n",
+			packages:       []Package{{Name: "rules_go", Version: "0.42.0"}},
+			expectedOutput: "bazel_dep(name = \"rules_go\", version = \"0.41.0\")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile := filepath.Join(t.TempDir(), "MODULE.bazel")
+			if err := os.WriteFile(tempFile, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+
+			output, changes, err := NewBazelUpdater().UpdateFile(tempFile, tt.packages, FileUpdaterOptions{})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+			for i, expected := range tt.expectedChanges {
+				expected.File = tempFile
+				if changes[i] != expected {
+					t.Errorf("UpdateFile() change[%d] = %+v, expected %+v", i, changes[i], expected)
+				}
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read temp file: %v", err)
+			}
+			if string(content) != tt.expectedOutput {
+				t.Errorf("File content = %q, expected %q", string(content), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestBazelUpdater_Supports(t *testing.T) {
+	updater := NewBazelUpdater()
+	for extension, expected := range map[string]bool{".bzl": true, ".bazel": false, ".yaml": false} {
+		if updater.Supports(extension) != expected {
+			t.Errorf("Supports(%q) = %v, expected %v", extension, !expected, expected)
+		}
+	}
+	for fileName, expected := range map[string]bool{"WORKSPACE": true, "WORKSPACE.bazel": true, "MODULE.bazel": true, "BUILD.bazel": false} {
+		if updater.SupportsFileName(fileName) != expected {
+			t.Errorf("SupportsFileName(%q) = %v, expected %v", fileName, !expected, expected)
+		}
+	}
+}
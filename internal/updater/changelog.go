@@ -0,0 +1,35 @@
+package updater
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ReportChangelog writes a Markdown changelog fragment with one bullet per package, e.g. for pull request descriptions
+// Packages are sorted by name like the package grouping of the change report.
+func (r *Reporter) ReportChangelog(changes []Change) {
+	for _, group := range groupChangesByPackage(changes) {
+		var oldVersions, newVersions, files []string
+		for _, change := range group {
+			oldVersions = appendUnique(oldVersions, change.OldVersion)
+			newVersions = appendUnique(newVersions, change.NewVersion)
+			files = appendUnique(files, change.File)
+		}
+
+		fileLabel := "files"
+		if len(files) == 1 {
+			fileLabel = "file"
+		}
+		fmt.Fprintf(r.out, "- Bump %s from %s to %s (%d %s)\n",
+			group[0].Package, strings.Join(oldVersions, ", "), strings.Join(newVersions, ", "), len(files), fileLabel)
+	}
+}
+
+// appendUnique appends the value unless the slice already contains it
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
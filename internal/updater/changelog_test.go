@@ -0,0 +1,26 @@
+package updater
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReporter_ReportChangelog(t *testing.T) {
+	changes := []Change{
+		{File: "a.yaml", Line: 2, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+		{File: "a.yaml", Line: 5, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "b.yaml", Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "c.tf", Line: 9, Package: "app", OldVersion: "1.5.0", NewVersion: "2.0.0"},
+		{File: "c.tf", Line: 12, Package: "app", OldVersion: "1.5.0", NewVersion: "2.0.0"},
+	}
+
+	var out bytes.Buffer
+	NewReporter(&out, ReportOptions{}).ReportChangelog(changes)
+
+	expected := "- Bump app from 1.0.0, 1.5.0 to 2.0.0 (3 files)\n" +
+		"- Bump redis from 6.0.0 to 7.0.0 (1 file)\n"
+
+	if out.String() != expected {
+		t.Errorf("ReportChangelog() output = %q, expected %q", out.String(), expected)
+	}
+}
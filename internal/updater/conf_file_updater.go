@@ -0,0 +1,224 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// confStatementPattern splits a directive of a .conf file into its leading part, the last value and the
+// terminating semicolon, e.g. "set $app_version", "1.2.3" and ";" for "set $app_version 1.2.3;"
+var /* const */ confStatementPattern = regexp.MustCompile(`^(.*?)([^\s;]+)(\s*;?\s*)$`)
+
+// ConfFileUpdater updates versions in nginx-style .conf files
+// Directives are annotated with depup comments like in YAML files:
+//
+//	# depup package=app
+//	set $app_version 1.2.3;
+//
+// The version is looked up in the last value of the directive, so the terminating semicolon is kept.
+type ConfFileUpdater struct {
+	supportedFileExtensions map[string]struct{}
+	comments                commentSyntax
+	scheme                  string // Version scheme used for comments without a scheme attribute
+	canonical               bool   // When true, versions with the same semver precedence are equal
+	leadingZeros            bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	prefixAuto              bool   // When true, the prefix of the version in the file is kept for the new version
+}
+
+func NewConfFileUpdater() *ConfFileUpdater {
+	return &ConfFileUpdater{
+		supportedFileExtensions: map[string]struct{}{
+			".conf": {},
+		},
+		comments: newCommentSyntax("#"),
+	}
+}
+
+func (u *ConfFileUpdater) Name() string {
+	return "conf"
+}
+
+func (u *ConfFileUpdater) Supports(fileExtension string) bool {
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
+}
+
+func (u *ConfFileUpdater) GetSupportedExtensions() []string {
+	extensions := make([]string, 0, len(u.supportedFileExtensions))
+	for ext := range u.supportedFileExtensions {
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+func (u *ConfFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	// Read file and prepare data
+	lines, format, err := readFileLines(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Process lines with the scheme of this run and build output
+	processor := *u
+	processor.scheme = options.Scheme
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.prefixAuto = options.VersionPrefixAuto
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
+
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
+		err = os.WriteFile(filePath, []byte(outputContent), 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return outputContent, changes, nil
+}
+
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *ConfFileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
+
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *ConfFileUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
+
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *ConfFileUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
+
+// depupComments returns the syntax depup comments are recognized by
+func (u *ConfFileUpdater) depupComments() commentSyntax {
+	return u.comments
+}
+
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *ConfFileUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *ConfFileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
+
+	lineContent, comment, depupDirective, ok := u.comments.splitInlineComment(line)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the directive and try to update it
+	updated := u.updateLine(lineContent, depupDirective, packages)
+	if updated.change != nil {
+		updated.line += comment
+	} else {
+		updated.line = line
+	}
+
+	return updated
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *ConfFileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the directive and try to update it
+	return u.updateLine(currentLine, depupDirective, packages)
+}
+
+// updateLine finds the version in the last value of the directive and updates it
+// The version of the result is empty if no version was found.
+func (u *ConfFileUpdater) updateLine(content string, d directive, packages []Package) lineResult {
+	result := lineResult{line: content, packageName: d.packageName}
+
+	scheme, ok := lookupScheme(d, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+
+	statementMatches := confStatementPattern.FindStringSubmatchIndex(content)
+	if statementMatches == nil {
+		return result
+	}
+	start, end := statementMatches[4], statementMatches[5]
+	value := content[start:end]
+
+	match, ok := scheme.find(value)
+	if !ok {
+		return result
+	}
+	result.version = match.version
+	result.matches = countVersions(scheme, value)
+
+	// Skip the update if the from= guard doesn't hold or the scheme refuses a downgrade
+	if skip := checkFromGuard(d, packages, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+
+	// Try to update the version
+	updatedValue, change := u.updateVersion(value, d, packages, scheme, match)
+	if change == nil {
+		return result
+	}
+
+	result.line = content[:start] + updatedValue + content[end:]
+	result.change = change
+
+	return result
+}
+
+// updateVersion updates the version in the value if the package name of the directive matches
+// With a replace attribute, the whole value is substituted
+func (u *ConfFileUpdater) updateVersion(value string, d directive, packages []Package, scheme versionScheme, match versionMatch) (string, *Change) {
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName {
+			currentVersion := match.version
+			targetVersion := scheme.format(currentVersion, packageVersion(pkg, u.prefixAuto))
+
+			if template, ok := d.attributes[replaceAttribute]; ok {
+				updatedValue, changed := replaceMatchedValue(value, match, renderReplaceTemplate(template, targetVersion))
+				if !changed {
+					return value, nil
+				}
+				return updatedValue, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+			}
+
+			targetVersion = trimVPrefix(targetVersion)
+			if versionsEqual(currentVersion, targetVersion, u.canonical) {
+				return value, nil
+			}
+
+			// Replace the version, keeping its quotes
+			updatedValue := strings.Replace(value, match.text, match.startQuote+targetVersion+match.endQuote, 1)
+
+			return updatedValue, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,110 @@
+package updater
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfFileUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		packages        []Package
+		expectedOutput  string
+		expectedChanges []Change
+	}{
+		{
+			name:            "Comment above a set directive",
+			fileContent:     "server {\n    # depup package=app\n    set $app_version 1.2.3;\n}\n",
+			packages:        []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput:  "server {\n    # depup package=app\n    set $app_version 1.3.0;\n}\n",
+			expectedChanges: []Change{{Line: 3, Package: "app", OldVersion: "1.2.3", NewVersion: "1.3.0"}},
+		},
+		{
+			name:            "Inline comment after the semicolon",
+			fileContent:     "set $app_version 1.2.3; # depup package=app\n",
+			packages:        []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput:  "set $app_version 1.3.0; # depup package=app\n",
+			expectedChanges: []Change{{Line: 1, Package: "app", OldVersion: "1.2.3", NewVersion: "1.3.0"}},
+		},
+		{
+			name:            "Quoted value and space before the semicolon",
+			fileContent:     "# depup package=app\nset $app_version \"v1.2.3\" ;\n",
+			packages:        []Package{{Name: "app", Version: "v1.3.0"}},
+			expectedOutput:  "# depup package=app\nset $app_version \"v1.3.0\" ;\n",
+			expectedChanges: []Change{{Line: 2, Package: "app", OldVersion: "1.2.3", NewVersion: "1.3.0"}},
+		},
+		{
+			name:            "Only the last value holds the version",
+			fileContent:     "# depup package=app\nproxy_pass http://backend-2.0.0 http://app:1.2.3;\n",
+			packages:        []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput:  "# depup package=app\nproxy_pass http://backend-2.0.0 http://app:1.3.0;\n",
+			expectedChanges: []Change{{Line: 2, Package: "app", OldVersion: "1.2.3", NewVersion: "1.3.0"}},
+		},
+		{
+			name:            "Directive without semicolon",
+			fileContent:     "# depup package=app\nversion 1.2.3\n",
+			packages:        []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput:  "# depup package=app\nversion 1.3.0\n",
+			expectedChanges: []Change{{Line: 2, Package: "app", OldVersion: "1.2.3", NewVersion: "1.3.0"}},
+		},
+		{
+			name:           "Up to date",
+			fileContent:    "set $app_version 1.3.0; # depup package=app\n",
+			packages:       []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput: "set $app_version 1.3.0; # depup package=app\n",
+		},
+		{
+			name:           "Unannotated directive",
+			fileContent:    "set $app_version 1.2.3;\n",
+			packages:       []Package{{Name: "app", Version: "1.3.0"}},
+			expectedOutput: "set $app_version 1.2.3;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".conf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewConfFileUpdater().UpdateFile(tempFile, tt.packages, FileUpdaterOptions{})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+			for i, expected := range tt.expectedChanges {
+				expected.File = tempFile
+				if changes[i] != expected {
+					t.Errorf("UpdateFile() change[%d] = %+v, expected %+v", i, changes[i], expected)
+				}
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read temp file: %v", err)
+			}
+			if string(content) != tt.expectedOutput {
+				t.Errorf("File content = %q, expected %q", string(content), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestConfFileUpdater_Supports(t *testing.T) {
+	updater := NewConfFileUpdater()
+	for extension, expected := range map[string]bool{".conf": true, ".cfg": false, ".yaml": false} {
+		if updater.Supports(extension) != expected {
+			t.Errorf("Supports(%q) = %v, expected %v", extension, !expected, expected)
+		}
+	}
+}
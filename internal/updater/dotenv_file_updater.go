@@ -1,17 +1,29 @@
 package updater
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 )
 
+// dotEnvAssignmentPattern matches a KEY=VALUE line, keeping the spacing around "=" in the key and value groups
+// The groups are an optional "export " prefix, the key, which may be quoted and then contain "=", the "=" and the value
+var /* const */ dotEnvAssignmentPattern = regexp.MustCompile(`^(\s*(?:export[ \t]+)?)("[^"]*"|'[^']*'|[^=]+?)([ \t]*=)(.*)$`)
+
+// dotEnvQuotedValuePattern splits a quoted value into leading space, opening quote, content, closing quote and the rest
+var /* const */ dotEnvQuotedValuePattern = regexp.MustCompile(`^(\s*)(['"])(.*?)(['"])(.*)$`)
+
+// dotEnvUnquotedValuePattern splits an unquoted value into leading space, the first word and the rest
+var /* const */ dotEnvUnquotedValuePattern = regexp.MustCompile(`^(\s*)([^\s]+)(.*)$`)
+
 type DotEnvFileUpdater struct {
 	supportedFileExtensions map[string]struct{}
-	commentPattern          *regexp.Regexp
+	comments                commentSyntax
+	canonical               bool   // When true, versions with the same semver precedence are equal
+	leadingZeros            bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	scheme                  string // Version scheme used for comments without a scheme attribute
+	prefixAuto              bool   // When true, the prefix of the current value is kept for the new version
 }
 
 func NewDotEnvFileUpdater() *DotEnvFileUpdater {
@@ -21,34 +33,16 @@ func NewDotEnvFileUpdater() *DotEnvFileUpdater {
 			".env.*": {},
 			".*.env": {},
 		},
-		commentPattern: regexp.MustCompile(`#\s*depup\s+package=([^\s]+)`),
+		comments: newCommentSyntax("#"),
 	}
 }
 
-func (u *DotEnvFileUpdater) Supports(fileExtension string) bool {
-	// Check for direct extension match first
-	if _, ok := u.supportedFileExtensions[fileExtension]; ok {
-		return true
-	}
-
-	// Check for glob pattern matches
-	fileName := "file" + fileExtension // Create a fake filename to match against patterns
-	for pattern := range u.supportedFileExtensions {
-		if strings.Contains(pattern, "*") || strings.Contains(pattern, "?") {
-			// Handle glob patterns
-			matched, err := filepath.Match(pattern, fileExtension)
-			if err == nil && matched {
-				return true
-			}
+func (u *DotEnvFileUpdater) Name() string {
+	return "dotenv"
+}
 
-			// Try matching against the full filename for extensions like .env.*
-			matched, err = filepath.Match(pattern, fileName)
-			if err == nil && matched {
-				return true
-			}
-		}
-	}
-	return false
+func (u *DotEnvFileUpdater) Supports(fileExtension string) bool {
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
 }
 
 func (u *DotEnvFileUpdater) GetSupportedExtensions() []string {
@@ -59,187 +53,211 @@ func (u *DotEnvFileUpdater) GetSupportedExtensions() []string {
 	return extensions
 }
 
-func (u *DotEnvFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, bool, error) {
+func (u *DotEnvFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
 	// Read file and prepare data
-	lines, endsWithNewline, err := u.readFileContent(filePath)
+	lines, format, err := readFileLines(filePath)
 	if err != nil {
-		return "", false, err
+		return "", nil, err
 	}
 
-	// Process lines and build output
-	outputContent, updated := u.processLines(lines, packages, endsWithNewline)
+	// Process lines with the options of this run and build output
+	processor := *u
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.scheme = options.Scheme
+	processor.prefixAuto = options.VersionPrefixAuto
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
 
-	// Write changes if needed
-	if updated && !options.DryRun {
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
 		err = os.WriteFile(filePath, []byte(outputContent), 0644)
 		if err != nil {
-			return "", false, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
 		}
 	}
 
-	return outputContent, updated, nil
+	return outputContent, changes, nil
 }
 
-// readFileContent reads a file and returns its lines and whether it ends with a newline
-func (u *DotEnvFileUpdater) readFileContent(filePath string) ([]string, bool, error) {
-	// Read file content first to check for trailing newline
-	fileContent, err := os.ReadFile(filePath)
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *DotEnvFileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
 	if err != nil {
-		return nil, false, fmt.Errorf("cannot read file %s: %w", filePath, err)
+		return nil, err
 	}
 
-	// Check if file ends with newline
-	endsWithNewline := len(fileContent) > 0 && (fileContent[len(fileContent)-1] == '\n')
-
-	// Open file for reading
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, false, fmt.Errorf("cannot open file %s: %w", filePath, err)
-	}
-	defer file.Close()
-
-	// Create a scanner to read line by line
-	scanner := bufio.NewScanner(file)
-
-	// Read lines
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	// Check for scanner errors
-	if err = scanner.Err(); err != nil {
-		return nil, false, fmt.Errorf("error reading file %s: %w", filePath, err)
-	}
-
-	return lines, endsWithNewline, nil
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
 }
 
-// processLines processes all lines and returns the modified content and update status
-func (u *DotEnvFileUpdater) processLines(lines []string, packages []Package, endsWithNewline bool) (string, bool) {
-	var output strings.Builder
-	updated := false
-
-	for i := 0; i < len(lines); i++ {
-		currentLine := lines[i]
-		modifiedLine := currentLine
-		lineUpdated := false
-
-		// Check for inline depup comment
-		if newLine, lineWasUpdated := u.processInlineDepupComment(currentLine, packages); lineWasUpdated {
-			modifiedLine = newLine
-			lineUpdated = true
-		} else if i > 0 {
-			// Check for depup comment in previous line
-			if newLine, lineWasUpdated := u.processPreviousLineDepupComment(lines[i-1], currentLine, packages); lineWasUpdated {
-				modifiedLine = newLine
-				lineUpdated = true
-			}
-		}
-
-		// Add the current line to output
-		output.WriteString(modifiedLine)
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *DotEnvFileUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
 
-		// Add newline if not the last line or if the original file had a trailing newline
-		if i < len(lines)-1 || endsWithNewline {
-			output.WriteString("\n")
-		}
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *DotEnvFileUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
 
-		// Update the overall update status
-		updated = updated || lineUpdated
-	}
+// depupComments returns the syntax depup comments are recognized by
+func (u *DotEnvFileUpdater) depupComments() commentSyntax {
+	return u.comments
+}
 
-	return output.String(), updated
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *DotEnvFileUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
 }
 
 // processInlineDepupComment handles the case where a depup comment is on the same line as the version
-func (u *DotEnvFileUpdater) processInlineDepupComment(line string, packages []Package) (string, bool) {
+func (u *DotEnvFileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
+
 	// Don't process lines that are only comments
-	if strings.TrimSpace(line) == "" || strings.TrimSpace(line)[0] == '#' {
-		return line, false
+	if strings.TrimSpace(line) == "" || u.comments.isCommentLine(line) {
+		return result
 	}
 
-	inlineCommentRegex := regexp.MustCompile(`(.*?)(\s*#.*)$`)
-	inlineMatches := inlineCommentRegex.FindStringSubmatch(line)
-
-	if len(inlineMatches) <= 2 {
-		return line, false
+	lineContent, comment, depupDirective, ok := u.comments.splitInlineComment(line)
+	if !ok {
+		return result
 	}
+	packageName := depupDirective.packageName
 
-	lineContent := inlineMatches[1]
-	comment := inlineMatches[2]
+	// This is a depup comment
+	result.packageName = packageName
 
-	// Check if it's a depup comment
-	depupMatches := u.commentPattern.FindStringSubmatch(comment)
-	if len(depupMatches) <= 1 {
-		return line, false
+	// Parse [export ]KEY=VALUE format preserving spaces
+	assignmentMatches := dotEnvAssignmentPattern.FindStringSubmatch(lineContent)
+	if assignmentMatches == nil {
+		return result
 	}
 
-	// This is a depup comment
-	packageName := depupMatches[1]
-
-	// Parse KEY=VALUE format preserving spaces
-	keyValueRegex := regexp.MustCompile(`^([^=]+)(=)(.*)$`)
-	keyValueMatches := keyValueRegex.FindStringSubmatch(lineContent)
-	if len(keyValueMatches) <= 3 {
-		return line, false
+	export := assignmentMatches[1]
+	key := assignmentMatches[2]
+	equals := assignmentMatches[3]
+	value := assignmentMatches[4]
+	scheme, ok := lookupScheme(depupDirective, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
 	}
+	result.version = findVersion(value, scheme)
+	result.matches = countVersions(scheme, value)
 
-	key := keyValueMatches[1]
-	equals := keyValueMatches[2]
-	value := keyValueMatches[3]
+	// Skip the update if the from= guard doesn't hold or the scheme refuses a downgrade
+	if skip := checkFromGuard(depupDirective, packages, result.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(depupDirective, packages, scheme, result.version); skip != nil {
+		result.skip = skip
+		return result
+	}
 
 	// Try to update the version
-	updatedValue, updated := u.updateEnvValue(value, packageName, packages)
-	if !updated {
-		return line, false
+	updatedValue, change := u.updateEnvValue(value, depupDirective, packages, scheme)
+	if change == nil {
+		return result
 	}
 
 	// Reconstruct the line with updated version
-	return key + equals + updatedValue + comment, true
+	result.line = export + key + equals + updatedValue + comment
+	result.change = change
+
+	return result
 }
 
-// processPreviousLineDepupComment handles the case where a depup comment is on the line before the version
-func (u *DotEnvFileUpdater) processPreviousLineDepupComment(prevLine, currentLine string, packages []Package) (string, bool) {
-	// Skip if previous line is not a depup comment or current line is a comment
-	if strings.TrimSpace(currentLine) == "" || strings.TrimSpace(currentLine)[0] == '#' {
-		return currentLine, false
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *DotEnvFileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	// Skip if the comment line is not a depup comment or current line is a comment
+	if strings.TrimSpace(currentLine) == "" || u.comments.isCommentLine(currentLine) {
+		return result
 	}
 
-	prevLineMatches := u.commentPattern.FindStringSubmatch(prevLine)
-	if len(prevLineMatches) <= 1 {
-		return currentLine, false
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
 	}
+	packageName := depupDirective.packageName
 
-	packageName := prevLineMatches[1]
+	result.packageName = packageName
 
-	// Parse KEY=VALUE format preserving spaces
-	keyValueRegex := regexp.MustCompile(`^([^=]+)(=)(.*)$`)
-	keyValueMatches := keyValueRegex.FindStringSubmatch(currentLine)
-	if len(keyValueMatches) <= 3 {
-		return currentLine, false
+	// Parse [export ]KEY=VALUE format preserving spaces
+	assignmentMatches := dotEnvAssignmentPattern.FindStringSubmatch(currentLine)
+	if assignmentMatches == nil {
+		return result
 	}
 
-	key := keyValueMatches[1]
-	equals := keyValueMatches[2]
-	value := keyValueMatches[3]
+	export := assignmentMatches[1]
+	key := assignmentMatches[2]
+	equals := assignmentMatches[3]
+	value := assignmentMatches[4]
+	scheme, ok := lookupScheme(depupDirective, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+	result.version = findVersion(value, scheme)
+	result.matches = countVersions(scheme, value)
+
+	// Skip the update if the from= guard doesn't hold or the scheme refuses a downgrade
+	if skip := checkFromGuard(depupDirective, packages, result.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(depupDirective, packages, scheme, result.version); skip != nil {
+		result.skip = skip
+		return result
+	}
 
 	// Try to update the version
-	updatedValue, updated := u.updateEnvValue(value, packageName, packages)
-	if !updated {
-		return currentLine, false
+	updatedValue, change := u.updateEnvValue(value, depupDirective, packages, scheme)
+	if change == nil {
+		return result
+	}
+
+	result.line = export + key + equals + updatedValue
+	result.change = change
+
+	return result
+}
+
+// findVersion returns the version of the scheme contained in a value, or an empty string if there is none
+func findVersion(value string, scheme versionScheme) string {
+	match, ok := scheme.find(value)
+	if !ok {
+		return ""
 	}
 
-	return key + equals + updatedValue, true
+	return match.version
 }
 
-// updateEnvValue updates the version value if the package name matches
-func (u *DotEnvFileUpdater) updateEnvValue(value, packageName string, packages []Package) (string, bool) {
+// updateEnvValue updates the version value if the package name of the directive matches
+// With a replace attribute, the value is substituted with the rendered template instead of the version
+func (u *DotEnvFileUpdater) updateEnvValue(value string, d directive, packages []Package, scheme versionScheme) (string, *Change) {
 	for _, pkg := range packages {
-		if pkg.Name == packageName {
+		if pkg.Name == d.packageName {
+			template, replace := d.attributes[replaceAttribute]
+			version := packageVersion(pkg, u.prefixAuto)
+			if !replace {
+				// A "v" of the current value is kept by valuePrefix
+				version = trimVPrefix(version)
+			}
+
+			targetVersion := scheme.format(findVersion(value, scheme), version)
+			newValue := targetVersion
+			if replace {
+				newValue = renderReplaceTemplate(template, targetVersion)
+			}
+
 			// Handle quoted values
-			quotedValueRegex := regexp.MustCompile(`^(\s*)(['"])(.*?)(['"])(.*)$`)
-			quotedMatches := quotedValueRegex.FindStringSubmatch(value)
+			quotedMatches := dotEnvQuotedValuePattern.FindStringSubmatch(value)
 
 			if len(quotedMatches) > 4 {
 				// Value is quoted
@@ -249,40 +267,66 @@ func (u *DotEnvFileUpdater) updateEnvValue(value, packageName string, packages [
 				endQuote := quotedMatches[4]
 				trailingContent := quotedMatches[5]
 
-				// Only update if it matches the version pattern
-				if !versionPattern.MatchString(currentValue) {
-					return value, false
+				// Only update if it holds a version of the scheme
+				if findVersion(currentValue, scheme) == "" {
+					return value, nil
 				}
 
-				if currentValue == pkg.Version {
-					return value, false
+				// A replaced value may hold more than the version, e.g. an image reference
+				prefix := u.valuePrefix(currentValue, replace)
+				oldVersion := strings.TrimPrefix(currentValue, prefix)
+				if replace {
+					oldVersion = findVersion(currentValue, scheme)
 				}
 
-				return leadingSpace + startQuote + pkg.Version + endQuote + trailingContent, true
+				if currentValue == prefix+newValue || !replace && versionsEqual(oldVersion, newValue, u.canonical) {
+					return value, nil
+				}
+
+				return leadingSpace + startQuote + prefix + newValue + endQuote + trailingContent, &Change{Package: pkg.Name, OldVersion: oldVersion, NewVersion: targetVersion}
 			} else {
 				// Value is not quoted - extract just the version part
-				spaceAndVersionRegex := regexp.MustCompile(`^(\s*)([^\s]+)(.*)$`)
-				spaceMatches := spaceAndVersionRegex.FindStringSubmatch(value)
+				spaceMatches := dotEnvUnquotedValuePattern.FindStringSubmatch(value)
 
 				if len(spaceMatches) > 3 {
 					leadingSpace := spaceMatches[1]
 					currentValue := spaceMatches[2]
 					trailingContent := spaceMatches[3]
 
-					// Only update if it matches the version pattern
-					if !versionPattern.MatchString(currentValue) {
-						return value, false
+					// Only update if it holds a version of the scheme
+					if findVersion(currentValue, scheme) == "" {
+						return value, nil
+					}
+
+					// A replaced value may hold more than the version, e.g. an image reference
+					prefix := u.valuePrefix(currentValue, replace)
+					oldVersion := strings.TrimPrefix(currentValue, prefix)
+					if replace {
+						oldVersion = findVersion(currentValue, scheme)
 					}
 
-					if currentValue == pkg.Version {
-						return value, false
+					if currentValue == prefix+newValue || !replace && versionsEqual(oldVersion, newValue, u.canonical) {
+						return value, nil
 					}
 
-					return leadingSpace + pkg.Version + trailingContent, true
+					return leadingSpace + prefix + newValue + trailingContent, &Change{Package: pkg.Name, OldVersion: oldVersion, NewVersion: targetVersion}
 				}
 			}
 		}
 	}
 
-	return value, false
+	return value, nil
+}
+
+// valuePrefix returns the prefix of the version in the current value that is kept for the new version
+// Without a replace template a leading "v" is always kept, other prefixes only if enabled
+func (u *DotEnvFileUpdater) valuePrefix(currentValue string, replace bool) string {
+	if replace {
+		return ""
+	}
+	if !u.prefixAuto {
+		return vPrefix(currentValue)
+	}
+	prefix, _ := splitVersionPrefix(currentValue)
+	return prefix
 }
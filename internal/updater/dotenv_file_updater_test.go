@@ -159,6 +159,78 @@ func TestDotEnvFileUpdater_UpdateFile(t *testing.T) {
 			expectUpdated:  true,
 			expectError:    false,
 		},
+		{
+			name:           "Colon directive style on previous line",
+			fileContent:    "# depup:package=test-pkg\nVERSION=1.0.0\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup:package=test-pkg\nVERSION=2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive substitutes the value",
+			fileContent:    "# depup package=app replace=newrepo/app:{version}\nIMAGE=oldrepo/app:1.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=newrepo/app:{version}\nIMAGE=newrepo/app:2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Comment below the version",
+			fileContent:    "VERSION=1.0.0\n# depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{CommentPosition: CommentPositionBelow},
+			expectedOutput: "VERSION=2.0.0\n# depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Colon directive style inline",
+			fileContent:    "VERSION=\"1.0.0\" # depup:package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "VERSION=\"2.0.0\" # depup:package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Integer scheme",
+			fileContent:    "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=20231201\n",
+			packages:       []Package{{Name: "snapshot", Version: "20240115"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=20240115\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Integer scheme with quoted value",
+			fileContent:    "BUILD=\"41\" # depup package=build scheme=integer\n",
+			packages:       []Package{{Name: "build", Version: "42"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "BUILD=\"42\" # depup package=build scheme=integer\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Integer scheme refuses a downgrade",
+			fileContent:    "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=20231201\n",
+			packages:       []Package{{Name: "snapshot", Version: "20230901"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=20231201\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Integer scheme ignores semantic versions",
+			fileContent:    "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=1.2.3\n",
+			packages:       []Package{{Name: "snapshot", Version: "20240115"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=snapshot scheme=integer\nSNAPSHOT_DATE=1.2.3\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,7 +246,7 @@ func TestDotEnvFileUpdater_UpdateFile(t *testing.T) {
 			updater := NewDotEnvFileUpdater()
 
 			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
 
 			// Check error expectation
 			if (err != nil) != tt.expectError {
@@ -183,7 +255,7 @@ func TestDotEnvFileUpdater_UpdateFile(t *testing.T) {
 			}
 
 			// Check updated flag
-			if updated != tt.expectUpdated {
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
 				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
 			}
 
@@ -208,6 +280,151 @@ func TestDotEnvFileUpdater_UpdateFile(t *testing.T) {
 	}
 }
 
+func TestDotEnvFileUpdater_VersionPrefixAuto(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		prefixAuto     bool
+		expectedOutput string
+		expectedOld    string
+	}{
+		{
+			name:           "v prefix is kept",
+			fileContent:    "APP_VERSION=v1.2.3 # depup package=app\n",
+			version:        "1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "APP_VERSION=v1.2.4 # depup package=app\n",
+			expectedOld:    "1.2.3",
+		},
+		{
+			name:           "Word prefix is kept in quotes",
+			fileContent:    "# depup package=app\nAPP_TAG=\"release-1.2.3\"\n",
+			version:        "1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nAPP_TAG=\"release-1.2.4\"\n",
+			expectedOld:    "1.2.3",
+		},
+		{
+			name:           "Prefix of the package version is replaced",
+			fileContent:    "# depup package=app\nAPP_TAG=release-1.2.3\n",
+			version:        "v1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nAPP_TAG=release-1.2.4\n",
+			expectedOld:    "1.2.3",
+		},
+		{
+			name:           "No prefix",
+			fileContent:    "# depup package=app\nAPP_VERSION=1.2.3\n",
+			version:        "v1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nAPP_VERSION=1.2.4\n",
+			expectedOld:    "1.2.3",
+		},
+		{
+			name:           "Prefix is dropped without auto detection",
+			fileContent:    "APP_VERSION=release-1.2.3 # depup package=app\n",
+			version:        "1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "APP_VERSION=1.2.4 # depup package=app\n",
+			expectedOld:    "release-1.2.3",
+		},
+		{
+			name:           "v is kept without auto detection",
+			fileContent:    "APP_VERSION=v1.2.3 # depup package=app\n",
+			version:        "1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "APP_VERSION=v1.2.4 # depup package=app\n",
+			expectedOld:    "1.2.3",
+		},
+		{
+			name:           "v of the target is dropped for a bare value",
+			fileContent:    "APP_VERSION=\"1.2.3\" # depup package=app\n",
+			version:        "v1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "APP_VERSION=\"1.2.4\" # depup package=app\n",
+			expectedOld:    "1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".env")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			options := FileUpdaterOptions{DryRun: true, VersionPrefixAuto: tt.prefixAuto}
+			output, changes, err := NewDotEnvFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 || changes[0].OldVersion != tt.expectedOld {
+				t.Errorf("UpdateFile() changes = %+v, expected one change from %s", changes, tt.expectedOld)
+			}
+		})
+	}
+}
+
+func TestDotEnvFileUpdater_TrailingWhitespace(t *testing.T) {
+	// Trailing whitespace after an updated version must be kept byte-for-byte
+	tests := []struct {
+		name           string
+		fileContent    string
+		options        FileUpdaterOptions
+		expectedOutput string
+	}{
+		{
+			name:           "Spaces after plain value",
+			fileContent:    "# depup package=app\nAPP_VERSION=1.0.0   \n",
+			expectedOutput: "# depup package=app\nAPP_VERSION=2.0.0   \n",
+		},
+		{
+			name:           "Tab after quoted value",
+			fileContent:    "# depup package=app\nAPP_VERSION=\"1.0.0\"\t\n",
+			expectedOutput: "# depup package=app\nAPP_VERSION=\"2.0.0\"\t\n",
+		},
+		{
+			name:           "Whitespace before inline comment",
+			fileContent:    "export APP_VERSION=1.0.0 \t # depup package=app\n",
+			expectedOutput: "export APP_VERSION=2.0.0 \t # depup package=app\n",
+		},
+		{
+			name:           "Whitespace with replace template",
+			fileContent:    "# depup package=app replace=v{version}\nAPP_VERSION=1.0.0  \n",
+			expectedOutput: "# depup package=app replace=v{version}\nAPP_VERSION=v2.0.0  \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".env")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			tt.options.DryRun = true
+			output, changes, err := NewDotEnvFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, tt.options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 {
+				t.Errorf("UpdateFile() changes = %+v, expected one change", changes)
+			}
+		})
+	}
+}
+
 func TestDotEnvFileUpdater_ComplexCases(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -287,7 +504,7 @@ func TestDotEnvFileUpdater_ComplexCases(t *testing.T) {
 			updater := NewDotEnvFileUpdater()
 
 			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
 
 			// Check error expectation
 			if (err != nil) != tt.expectError {
@@ -296,7 +513,7 @@ func TestDotEnvFileUpdater_ComplexCases(t *testing.T) {
 			}
 
 			// Check updated flag
-			if updated != tt.expectUpdated {
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
 				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
 			}
 
@@ -334,3 +551,86 @@ func TestDotEnvFileUpdater_Supports(t *testing.T) {
 		})
 	}
 }
+
+func TestDotEnvFileUpdater_Export(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+	}{
+		{
+			name:           "Exported variable with inline comment",
+			fileContent:    "export APP_VERSION=1.0.0 # depup package=app\n",
+			expectedOutput: "export APP_VERSION=2.0.0 # depup package=app\n",
+		},
+		{
+			name:           "Exported variable with comment above",
+			fileContent:    "# depup package=app\nexport APP_VERSION=1.0.0\n",
+			expectedOutput: "# depup package=app\nexport APP_VERSION=2.0.0\n",
+		},
+		{
+			name:           "Exported variable with quoted value",
+			fileContent:    "# depup package=app\nexport  APP_VERSION=\"1.0.0\"\n",
+			expectedOutput: "# depup package=app\nexport  APP_VERSION=\"2.0.0\"\n",
+		},
+		{
+			name:           "Quoted key",
+			fileContent:    "\"APP_VERSION\" = 1.0.0 # depup package=app\n",
+			expectedOutput: "\"APP_VERSION\" = 2.0.0 # depup package=app\n",
+		},
+		{
+			name:           "Exported quoted key containing =",
+			fileContent:    "# depup package=app\nexport 'APP=VERSION'='1.0.0'\n",
+			expectedOutput: "# depup package=app\nexport 'APP=VERSION'='2.0.0'\n",
+		},
+		{
+			name:           "Variable named export",
+			fileContent:    "export=1.0.0 # depup package=app\n",
+			expectedOutput: "export=2.0.0 # depup package=app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".env")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewDotEnvFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 || changes[0].OldVersion != "1.0.0" {
+				t.Errorf("UpdateFile() changes = %+v, expected one change from 1.0.0", changes)
+			}
+		})
+	}
+}
+
+func TestDotEnvFileUpdater_SyncCommentVersion(t *testing.T) {
+	// The VERSION= of the value must not be mistaken for the version attribute of the comment
+	fileContent := "APP_VERSION=1.0.0 # depup package=app version=1.0.0\n# depup package=db version=1.0.0\nDB_VERSION=1.0.0\n"
+	expectedOutput := "APP_VERSION=2.0.0 # depup package=app version=2.0.0\n# depup package=db version=3.0.0\nDB_VERSION=3.0.0\n"
+
+	tempFile, err := createTempFileWithContent(fileContent, ".env")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	packages := []Package{{Name: "app", Version: "2.0.0"}, {Name: "db", Version: "3.0.0"}}
+	output, _, err := NewDotEnvFileUpdater().UpdateFile(tempFile, packages, FileUpdaterOptions{DryRun: true, SyncCommentVersion: true})
+	if err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	if output != expectedOutput {
+		t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, expectedOutput)
+	}
+}
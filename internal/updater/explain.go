@@ -0,0 +1,44 @@
+package updater
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// LineAnalysis describes how a FileUpdater interprets a single line of a file
+type LineAnalysis struct {
+	Line       int    // 1-based line number
+	Content    string // Content of the line
+	Annotation string // Package name of a depup comment on this line, empty if there is none
+	Malformed  bool   // Whether the line contains a depup-like comment that cannot be parsed
+	Package    string // Package the line's version is annotated with, empty if not annotated
+	Version    string // Version found on the annotated line, empty if none was found
+	NewVersion string // Version the line would be updated to, empty if it stays unchanged
+}
+
+// WouldUpdate reports whether the line would be changed by an update
+func (a LineAnalysis) WouldUpdate() bool {
+	return a.NewVersion != ""
+}
+
+// LineAnalyzer is implemented by FileUpdaters that can describe how they interpret a file
+type LineAnalyzer interface {
+	// AnalyzeFile returns the analysis of every line in the file for the given packages
+	AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error)
+}
+
+// Explain describes line by line how the given file would be processed
+// The file is only read, never modified
+func (u *Updater) Explain(filePath string, packages []Package) ([]LineAnalysis, error) {
+	updater, err := u.getFileUpdaterForPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer, ok := updater.(LineAnalyzer)
+	if !ok {
+		return nil, fmt.Errorf("explain is not supported for file extension: %s", filepath.Ext(filePath))
+	}
+
+	return analyzer.AnalyzeFile(filePath, packages)
+}
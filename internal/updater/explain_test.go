@@ -0,0 +1,73 @@
+package updater
+
+import (
+	"os"
+	"testing"
+)
+
+func TestUpdater_Explain(t *testing.T) {
+	fileContent := `# depup package=app
+version: 1.0.0
+# depup package=other
+image: other:3.0.0
+# depup pkg=broken
+tag: 1.0.0
+# depup package=lib
+name: lib
+port: 8080 # depup package=up-to-date
+`
+	tempFile, err := createTempFileWithContent(fileContent, ".yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	packages := []Package{{Name: "app", Version: "2.0.0"}}
+
+	analysis, err := NewUpdater().Explain(tempFile, packages)
+	if err != nil {
+		t.Fatalf("Explain() unexpected error: %v", err)
+	}
+
+	expected := []LineAnalysis{
+		{Line: 1, Content: "# depup package=app", Annotation: "app"},
+		{Line: 2, Content: "version: 1.0.0", Package: "app", Version: "1.0.0", NewVersion: "2.0.0"},
+		{Line: 3, Content: "# depup package=other", Annotation: "other"},
+		{Line: 4, Content: "image: other:3.0.0", Package: "other", Version: "3.0.0"},
+		{Line: 5, Content: "# depup pkg=broken", Malformed: true},
+		{Line: 6, Content: "tag: 1.0.0"},
+		{Line: 7, Content: "# depup package=lib", Annotation: "lib"},
+		{Line: 8, Content: "name: lib", Package: "lib"},
+		{Line: 9, Content: "port: 8080 # depup package=up-to-date", Annotation: "up-to-date", Package: "up-to-date"},
+	}
+
+	if len(analysis) != len(expected) {
+		t.Fatalf("Explain() returned %d lines, expected %d", len(analysis), len(expected))
+	}
+	for i := range expected {
+		if analysis[i] != expected[i] {
+			t.Errorf("Explain() line %d = %+v, expected %+v", i+1, analysis[i], expected[i])
+		}
+	}
+
+	// Explaining must not modify the file
+	content, err := os.ReadFile(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to read temp file: %v", err)
+	}
+	if string(content) != fileContent {
+		t.Errorf("file was modified by Explain()")
+	}
+}
+
+func TestUpdater_Explain_Unsupported(t *testing.T) {
+	tempFile, err := createTempFileWithContent(`{"version": "1.0.0"}`, ".json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	if _, err := NewUpdater().Explain(tempFile, nil); err == nil {
+		t.Errorf("Explain() expected error for JSON file")
+	}
+}
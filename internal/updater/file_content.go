@@ -0,0 +1,143 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dtomasi/depup/internal/editorconfig"
+)
+
+const (
+	lineEndingLF   = "\n"
+	lineEndingCRLF = "\r\n"
+
+	charsetUTF8    = "utf-8"
+	charsetUTF8BOM = "utf-8-bom"
+)
+
+var /* const */ utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// fileFormat holds the layout details of a file that must survive an update
+type fileFormat struct {
+	lineEnding      string // Line ending used between lines ("\n" or "\r\n")
+	endsWithNewline bool   // Whether the file ends with a line ending
+	bom             bool   // Whether the file starts with a UTF-8 byte order mark
+}
+
+// readFileLines reads a file and returns its lines without line endings together with its format
+func readFileLines(filePath string) ([]string, fileFormat, error) {
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fileFormat{}, fmt.Errorf("cannot read file %s: %w", filePath, err)
+	}
+
+	lines, format, err := parseLines(fileContent)
+	if err != nil {
+		return nil, fileFormat{}, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	return lines, format, nil
+}
+
+// parseLines splits file content into lines without line endings and detects its format
+func parseLines(fileContent []byte) ([]string, fileFormat, error) {
+	format := fileFormat{lineEnding: lineEndingLF}
+
+	// Strip the byte order mark so it doesn't end up in the first line
+	if bytes.HasPrefix(fileContent, utf8BOM) {
+		format.bom = true
+		fileContent = fileContent[len(utf8BOM):]
+	}
+
+	// Detect the line ending from the first line break in the file
+	if i := bytes.IndexByte(fileContent, '\n'); i > 0 && fileContent[i-1] == '\r' {
+		format.lineEnding = lineEndingCRLF
+	}
+
+	// Check if file ends with newline
+	format.endsWithNewline = len(fileContent) > 0 && (fileContent[len(fileContent)-1] == '\n')
+
+	// Create a scanner to read line by line, dropping line endings
+	scanner := bufio.NewScanner(bytes.NewReader(fileContent))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fileFormat{}, err
+	}
+
+	return lines, format, nil
+}
+
+// withOptions returns the format with the overrides from the options applied
+func (f fileFormat) withOptions(options FileUpdaterOptions) fileFormat {
+	if options.LineEnding != "" {
+		f.lineEnding = options.LineEnding
+	}
+	if options.FinalNewline != nil {
+		f.endsWithNewline = *options.FinalNewline
+	}
+	switch options.Charset {
+	case charsetUTF8:
+		f.bom = false
+	case charsetUTF8BOM:
+		f.bom = true
+	}
+
+	return f
+}
+
+// render joins the lines into the file content according to the format
+func (f fileFormat) render(lines []string) string {
+	var output strings.Builder
+
+	if f.bom {
+		output.Write(utf8BOM)
+	}
+
+	for i, line := range lines {
+		output.WriteString(line)
+
+		// Add line ending if not the last line or if the file should end with one
+		if i < len(lines)-1 || f.endsWithNewline {
+			output.WriteString(f.lineEnding)
+		}
+	}
+
+	return output.String()
+}
+
+// applyEditorConfig returns the options with the .editorconfig rules for the file applied
+// Unsupported property values are ignored and leave the existing file layout untouched
+func applyEditorConfig(filePath string, options FileUpdaterOptions) (FileUpdaterOptions, error) {
+	properties, err := editorconfig.Resolve(filePath)
+	if err != nil {
+		return options, fmt.Errorf("cannot resolve editorconfig for %s: %w", filePath, err)
+	}
+
+	switch properties["end_of_line"] {
+	case "lf":
+		options.LineEnding = lineEndingLF
+	case "crlf":
+		options.LineEnding = lineEndingCRLF
+	}
+
+	switch properties["insert_final_newline"] {
+	case "true", "false":
+		finalNewline := properties["insert_final_newline"] == "true"
+		options.FinalNewline = &finalNewline
+	}
+
+	switch properties["charset"] {
+	case charsetUTF8, charsetUTF8BOM:
+		options.Charset = properties["charset"]
+	}
+
+	return options, nil
+}
@@ -0,0 +1,110 @@
+package updater
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileUpdaters_PreserveFileFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		updater        FileUpdater
+		ext            string
+		fileContent    string
+		expectedOutput string
+	}{
+		{
+			name:           "YAML with CRLF line endings",
+			updater:        NewYamlFileUpdater(),
+			ext:            ".yaml",
+			fileContent:    "# depup package=test-pkg\r\nversion: 1.0.0\r\nother: value\r\n",
+			expectedOutput: "# depup package=test-pkg\r\nversion: 2.0.0\r\nother: value\r\n",
+		},
+		{
+			name:           "HCL with CRLF line endings and no final newline",
+			updater:        NewHclFileUpdater(),
+			ext:            ".tf",
+			fileContent:    "// depup package=test-pkg\r\nversion = \"1.0.0\"",
+			expectedOutput: "// depup package=test-pkg\r\nversion = \"2.0.0\"",
+		},
+		{
+			name:           "dotenv with byte order mark",
+			updater:        NewDotEnvFileUpdater(),
+			ext:            ".env",
+			fileContent:    "\xEF\xBB\xBFVERSION=1.0.0 # depup package=test-pkg\n",
+			expectedOutput: "\xEF\xBB\xBFVERSION=2.0.0 # depup package=test-pkg\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, tt.ext)
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := tt.updater.UpdateFile(tempFile, []Package{{Name: "test-pkg", Version: "2.0.0"}}, FileUpdaterOptions{})
+			if err != nil {
+				t.Fatalf("UpdateFile() unexpected error: %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_RespectEditorConfig(t *testing.T) {
+	tempDir := t.TempDir()
+
+	editorConfig := "root = true\n\n[*.yaml]\nend_of_line = crlf\ninsert_final_newline = true\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".editorconfig"), []byte(editorConfig), 0644); err != nil {
+		t.Fatalf("failed to create .editorconfig: %v", err)
+	}
+
+	filePath := filepath.Join(tempDir, "app.yaml")
+	original := "# depup package=app\nversion: 1.0.0"
+
+	tests := []struct {
+		name     string
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "existing layout is preserved by default",
+			options:  nil,
+			expected: "# depup package=app\nversion: 2.0.0",
+		},
+		{
+			name:     "editorconfig rules are applied",
+			options:  []Option{WithRespectEditorConfig(true)},
+			expected: "# depup package=app\r\nversion: 2.0.0\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			updater := NewUpdater(append(tt.options, WithOutput(io.Discard))...)
+			if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package updater
+
+import "sync"
+
+// fileLocks hands out one mutex per file path
+// It guarantees a single writer per file when files are processed concurrently
+// The zero value is ready to use
+type fileLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock acquires the mutex for the given path and returns the function releasing it
+func (l *fileLocks) lock(path string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	fileLock, ok := l.locks[path]
+	if !ok {
+		fileLock = &sync.Mutex{}
+		l.locks[path] = fileLock
+	}
+	l.mu.Unlock()
+
+	fileLock.Lock()
+
+	return fileLock.Unlock
+}
@@ -0,0 +1,76 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdater_processFile_ConcurrentSameFile(t *testing.T) {
+	const packageCount = 20
+
+	// One annotated line per package, all in the same file
+	var content strings.Builder
+	var packages []Package
+	for i := 0; i < packageCount; i++ {
+		name := fmt.Sprintf("pkg-%d", i)
+		fmt.Fprintf(&content, "# depup package=%s\n%s: 1.0.0\n", name, name)
+		packages = append(packages, Package{Name: name, Version: "2.0.0"})
+	}
+
+	filePath := filepath.Join(t.TempDir(), "versions.yaml")
+	if err := os.WriteFile(filePath, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	updater := NewUpdater(WithOutput(io.Discard))
+
+	// Each goroutine performs its own read-modify-write cycle on the same file
+	var wg sync.WaitGroup
+	errs := make(chan error, packageCount)
+	for _, pkg := range packages {
+		wg.Add(1)
+		go func(pkg Package) {
+			defer wg.Done()
+			errs <- updater.processFile(filePath, []Package{pkg}, FileUpdaterOptions{})
+		}(pkg)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	updated, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	// Without per-file locking, concurrent writers would overwrite each other's updates
+	if strings.Contains(string(updated), "1.0.0") {
+		t.Errorf("expected all versions to be updated, got:\n%s", updated)
+	}
+	if len(updater.Changes()) != packageCount {
+		t.Errorf("expected %d changes, got %d", packageCount, len(updater.Changes()))
+	}
+}
+
+func TestFileLocks_lock(t *testing.T) {
+	var locks fileLocks
+
+	unlockA := locks.lock("a")
+	// A different path must not block
+	unlockB := locks.lock("b")
+	unlockB()
+	unlockA()
+
+	// The same path can be acquired again after release
+	locks.lock("a")()
+}
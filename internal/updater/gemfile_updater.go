@@ -0,0 +1,273 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// gemfileFileName is the file name handled by the GemfileUpdater
+const gemfileFileName = "Gemfile"
+
+// gemNamePattern matches the start of a gem declaration up to the quoted gem name, e.g. `gem "rails"`
+var /* const */ gemNamePattern = regexp.MustCompile(`^\s*gem\s*\(?\s*(?:"[^"]*"|'[^']*')`)
+
+// gemRequirementPattern matches the next positional string argument of a gem declaration, capturing its content
+var /* const */ gemRequirementPattern = regexp.MustCompile(`^\s*,\s*(?:"([^"]*)"|'([^']*)')`)
+
+// GemfileUpdater updates version requirements of gem declarations in Ruby Gemfiles
+// Gems are annotated with depup comments like in YAML files:
+//
+//	# depup package=rails
+//	gem "rails", "~> 7.0.0"
+//
+// Operators are kept, and with several requirements only the lower bound is updated like in HCL constraints.
+type GemfileUpdater struct {
+	comments     commentSyntax
+	scheme       string // Version scheme used for comments without a scheme attribute
+	canonical    bool   // When true, versions with the same semver precedence are equal
+	leadingZeros bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	prefixAuto   bool   // When true, the prefix of the version in the file is kept for the new version
+}
+
+func NewGemfileUpdater() *GemfileUpdater {
+	return &GemfileUpdater{
+		comments: newCommentSyntax("#"),
+	}
+}
+
+func (u *GemfileUpdater) Name() string {
+	return "gemfile"
+}
+
+// Supports returns false for all extensions, Gemfiles are matched by name
+func (u *GemfileUpdater) Supports(fileExtension string) bool {
+	return false
+}
+
+func (u *GemfileUpdater) GetSupportedExtensions() []string {
+	return []string{gemfileFileName}
+}
+
+// SupportsFileName reports whether the updater handles files with the given base name
+func (u *GemfileUpdater) SupportsFileName(fileName string) bool {
+	return fileName == gemfileFileName
+}
+
+func (u *GemfileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	// Read file and prepare data
+	lines, format, err := readFileLines(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Process lines with the scheme of this run and build output
+	processor := *u
+	processor.scheme = options.Scheme
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.prefixAuto = options.VersionPrefixAuto
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
+
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
+		err = os.WriteFile(filePath, []byte(outputContent), 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return outputContent, changes, nil
+}
+
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *GemfileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
+
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *GemfileUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
+
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *GemfileUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
+
+// depupComments returns the syntax depup comments are recognized by
+func (u *GemfileUpdater) depupComments() commentSyntax {
+	return u.comments
+}
+
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *GemfileUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *GemfileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
+
+	lineContent, comment, depupDirective, ok := u.comments.splitInlineComment(line)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the gem declaration and try to update it
+	updated := u.updateLine(lineContent, depupDirective, packages)
+	if updated.change != nil {
+		updated.line += comment
+	} else {
+		updated.line = line
+	}
+
+	return updated
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *GemfileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
+	}
+
+	// Look for the version in the gem declaration and try to update it
+	return u.updateLine(currentLine, depupDirective, packages)
+}
+
+// updateLine finds the version requirements of the gem declaration and updates the version of the directive
+// With several requirements like "~> 7.0", ">= 7.0.4" only the lower bound is updated, and updates beyond
+// an upper bound are skipped. The version of the result is empty if no version was found.
+func (u *GemfileUpdater) updateLine(content string, d directive, packages []Package) lineResult {
+	result := lineResult{line: content, packageName: d.packageName}
+
+	scheme, ok := lookupScheme(d, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+
+	requirements, ok := parseGemRequirements(content)
+	if !ok {
+		return result
+	}
+	lower, ok := lowerBound(requirements)
+	if !ok {
+		return result
+	}
+	element := content[lower.start:lower.end]
+
+	match, ok := scheme.find(element)
+	if !ok {
+		return result
+	}
+	result.version = match.version
+	result.matches = countVersions(scheme, element)
+
+	// Skip the update if the from= guard doesn't hold, the scheme refuses a downgrade or the new version
+	// exceeds an upper bound
+	if skip := checkFromGuard(d, packages, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkUpperBounds(content, requirements, d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+
+	// Try to update the version
+	updatedElement, change := u.updateVersion(element, d, packages, scheme, match)
+	if change == nil {
+		return result
+	}
+
+	result.line = content[:lower.start] + updatedElement + content[lower.end:]
+	result.change = change
+
+	return result
+}
+
+// parseGemRequirements returns the version requirements of a gem declaration, e.g. "~> 7.0" and ">= 7.0.4" in
+// `gem "rails", "~> 7.0", ">= 7.0.4"`. Requirements are the string arguments following the gem name, options
+// like `require: false` end them.
+func parseGemRequirements(line string) ([]constraintElement, bool) {
+	location := gemNamePattern.FindStringIndex(line)
+	if location == nil {
+		return nil, false
+	}
+
+	var requirements []constraintElement
+	offset := location[1]
+	for {
+		requirementMatches := gemRequirementPattern.FindStringSubmatchIndex(line[offset:])
+		if requirementMatches == nil {
+			break
+		}
+
+		// Either the double or the single quoted group holds the requirement
+		start, end := requirementMatches[2], requirementMatches[3]
+		if start < 0 {
+			start, end = requirementMatches[4], requirementMatches[5]
+		}
+		requirement := line[offset+start : offset+end]
+		trimmed := strings.TrimSpace(requirement)
+		operatorMatch := constraintElementPattern.FindStringSubmatch(trimmed)
+		if operatorMatch == nil {
+			break
+		}
+
+		elementStart := offset + start + strings.Index(requirement, trimmed)
+		requirements = append(requirements, constraintElement{operator: operatorMatch[1], start: elementStart, end: elementStart + len(trimmed)})
+		offset += requirementMatches[1]
+	}
+
+	return requirements, len(requirements) > 0
+}
+
+// updateVersion updates the version in a requirement if the package name of the directive matches
+// With a replace attribute, the whole requirement is substituted
+func (u *GemfileUpdater) updateVersion(requirement string, d directive, packages []Package, scheme versionScheme, match versionMatch) (string, *Change) {
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName {
+			currentVersion := match.version
+			targetVersion := scheme.format(currentVersion, packageVersion(pkg, u.prefixAuto))
+
+			if template, ok := d.attributes[replaceAttribute]; ok {
+				updatedRequirement, changed := replaceMatchedValue(requirement, match, renderReplaceTemplate(template, targetVersion))
+				if !changed {
+					return requirement, nil
+				}
+				return updatedRequirement, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+			}
+
+			targetVersion = trimVPrefix(targetVersion)
+			if versionsEqual(currentVersion, targetVersion, u.canonical) {
+				return requirement, nil
+			}
+
+			// Replace the version, keeping the operator in front
+			updatedRequirement := strings.Replace(requirement, match.text, match.startQuote+targetVersion+match.endQuote, 1)
+
+			return updatedRequirement, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+		}
+	}
+
+	return requirement, nil
+}
@@ -0,0 +1,135 @@
+package updater
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGemfileUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		packages        []Package
+		expectedOutput  string
+		expectedChanges []Change
+		expectedSkip    string
+	}{
+		{
+			name:            "Exact version",
+			fileContent:     "source \"https://rubygems.org\"\n\n# depup package=rails\ngem \"rails\", \"7.0.0\"\n",
+			packages:        []Package{{Name: "rails", Version: "7.1.2"}},
+			expectedOutput:  "source \"https://rubygems.org\"\n\n# depup package=rails\ngem \"rails\", \"7.1.2\"\n",
+			expectedChanges: []Change{{Line: 4, Package: "rails", OldVersion: "7.0.0", NewVersion: "7.1.2"}},
+		},
+		{
+			name:            "Pessimistic constraint keeps the operator",
+			fileContent:     "gem 'puma', '~> 6.0.0' # depup package=puma\n",
+			packages:        []Package{{Name: "puma", Version: "6.4.2"}},
+			expectedOutput:  "gem 'puma', '~> 6.4.2' # depup package=puma\n",
+			expectedChanges: []Change{{Line: 1, Package: "puma", OldVersion: "6.0.0", NewVersion: "6.4.2"}},
+		},
+		{
+			name:            "Pessimistic constraint with partial scheme",
+			fileContent:     "# depup package=rails scheme=partial\ngem \"rails\", \"~> 7.0\"\n",
+			packages:        []Package{{Name: "rails", Version: "7.1.2"}},
+			expectedOutput:  "# depup package=rails scheme=partial\ngem \"rails\", \"~> 7.1\"\n",
+			expectedChanges: []Change{{Line: 2, Package: "rails", OldVersion: "7.0", NewVersion: "7.1"}},
+		},
+		{
+			name:            "Only the lower bound of several requirements",
+			fileContent:     "# depup package=nokogiri\ngem \"nokogiri\", \">= 1.15.0\", \"< 2.0.0\", require: false\n",
+			packages:        []Package{{Name: "nokogiri", Version: "1.16.0"}},
+			expectedOutput:  "# depup package=nokogiri\ngem \"nokogiri\", \">= 1.16.0\", \"< 2.0.0\", require: false\n",
+			expectedChanges: []Change{{Line: 2, Package: "nokogiri", OldVersion: "1.15.0", NewVersion: "1.16.0"}},
+		},
+		{
+			name:           "Version beyond an upper bound is skipped",
+			fileContent:    "# depup package=nokogiri\ngem \"nokogiri\", \">= 1.15.0\", \"< 2.0.0\"\n",
+			packages:       []Package{{Name: "nokogiri", Version: "2.1.0"}},
+			expectedOutput: "# depup package=nokogiri\ngem \"nokogiri\", \">= 1.15.0\", \"< 2.0.0\"\n",
+			expectedSkip:   "version 2.1.0 is outside of the upper bound < 2.0.0",
+		},
+		{
+			name:           "Gem without version requirement",
+			fileContent:    "# depup package=rake\ngem \"rake\", require: false\n",
+			packages:       []Package{{Name: "rake", Version: "13.1.0"}},
+			expectedOutput: "# depup package=rake\ngem \"rake\", require: false\n",
+		},
+		{
+			name:           "Unannotated gem",
+			fileContent:    "gem \"rails\", \"7.0.0\"\n",
+			packages:       []Package{{Name: "rails", Version: "7.1.2"}},
+			expectedOutput: "gem \"rails\", \"7.0.0\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, "")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			var skipped []Skip
+			options := FileUpdaterOptions{OnSkip: func(skip Skip) { skipped = append(skipped, skip) }}
+			output, changes, err := NewGemfileUpdater().UpdateFile(tempFile, tt.packages, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+			for i, expected := range tt.expectedChanges {
+				expected.File = tempFile
+				if changes[i] != expected {
+					t.Errorf("UpdateFile() change[%d] = %+v, expected %+v", i, changes[i], expected)
+				}
+			}
+
+			reason := ""
+			if len(skipped) > 0 {
+				reason = skipped[0].Reason
+			}
+			if reason != tt.expectedSkip {
+				t.Errorf("UpdateFile() skip reason = %q, expected %q", reason, tt.expectedSkip)
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read temp file: %v", err)
+			}
+			if string(content) != tt.expectedOutput {
+				t.Errorf("File content = %q, expected %q", string(content), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestGemfileUpdater_RoutedByFileName(t *testing.T) {
+	tempDir := t.TempDir()
+	gemfile := filepath.Join(tempDir, "Gemfile")
+	otherFile := filepath.Join(tempDir, "Gemfile.lock")
+	for _, path := range []string{gemfile, otherFile} {
+		if err := os.WriteFile(path, []byte("# depup package=rails\ngem \"rails\", \"7.0.0\"\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	updater := NewUpdater(WithOutput(io.Discard))
+	if err := updater.Update(tempDir, []Package{{Name: "rails", Version: "7.1.2"}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	changedFiles := updater.ChangedFiles()
+	if len(changedFiles) != 1 || changedFiles[0] != gemfile {
+		t.Errorf("expected only %s to change, got %v", gemfile, changedFiles)
+	}
+}
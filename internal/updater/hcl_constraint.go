@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// quotedStringPattern matches a double quoted HCL string, capturing its content
+var /* const */ quotedStringPattern = regexp.MustCompile(`"([^"]*)"`)
+
+// constraintElementPattern matches a single element of a version constraint, e.g. ">= 4.0.0" or "4.0.0"
+var /* const */ constraintElementPattern = regexp.MustCompile(`^(>=|<=|~>|!=|=|>|<)?\s*v?\d`)
+
+// Update modes, selecting how updates treat the operators of constraints like ">= 4.0.0, < 5.0.0"
+const (
+	UpdateModeRange      = "range"      // Only the lower bound is updated, keeping the other elements
+	UpdateModeConstraint = "constraint" // The constraint is replaced by the updated lower bound with its operator
+	UpdateModePin        = "pin"        // The constraint is replaced by the new version without an operator
+)
+
+// modeAttribute is the directive attribute overriding the update mode of the run, e.g. mode=pin
+const modeAttribute = "mode"
+
+// lookupUpdateMode returns the update mode of the directive, falling back to the default mode
+// An empty mode selects UpdateModeRange, and unknown modes are reported as not ok.
+func lookupUpdateMode(d directive, defaultMode string) (string, bool) {
+	mode := d.attributes[modeAttribute]
+	if mode == "" {
+		mode = defaultMode
+	}
+	switch mode {
+	case "", UpdateModeRange:
+		return UpdateModeRange, true
+	case UpdateModeConstraint, UpdateModePin:
+		return mode, true
+	}
+	return mode, false
+}
+
+// applyUpdateMode puts the updated lower bound into the constraint according to the update mode
+// In range mode only the lower bound is replaced, otherwise the whole constraint, and in pin mode without its operator.
+func applyUpdateMode(content string, elements []constraintElement, lower constraintElement, updated, mode string) string {
+	if mode == UpdateModeRange {
+		return content[:lower.start] + updated + content[lower.end:]
+	}
+	if mode == UpdateModePin {
+		updated = strings.TrimLeft(strings.TrimPrefix(updated, lower.operator), " \t")
+	}
+	return content[:elements[0].start] + updated + content[elements[len(elements)-1].end:]
+}
+
+// constraintElement is a single comma separated element of a constraint expression
+type constraintElement struct {
+	operator string // Comparison operator, empty for a bare version
+	start    int    // Offset of the element in the line, without surrounding whitespace
+	end      int    // Offset after the element in the line
+}
+
+// parseConstraintExpression finds the first quoted constraint expression of the line, e.g. ">= 4.0.0, < 5.0.0"
+// Quoted strings count as constraint expressions if every element is a version with an optional operator
+// and at least one element has an operator, so plain versions like "4.0.0" are not constraints.
+func parseConstraintExpression(line string) ([]constraintElement, bool) {
+	for _, quoted := range quotedStringPattern.FindAllStringSubmatchIndex(line, -1) {
+		var elements []constraintElement
+		offset := quoted[2]
+		for _, part := range strings.Split(line[quoted[2]:quoted[3]], ",") {
+			trimmed := strings.TrimSpace(part)
+			operatorMatch := constraintElementPattern.FindStringSubmatch(trimmed)
+			if operatorMatch == nil {
+				elements = nil
+				break
+			}
+
+			start := offset + strings.Index(part, trimmed)
+			elements = append(elements, constraintElement{operator: operatorMatch[1], start: start, end: start + len(trimmed)})
+			offset += len(part) + 1
+		}
+
+		if len(elements) > 0 && anyOperator(elements) {
+			return elements, true
+		}
+	}
+
+	return nil, false
+}
+
+// parseConstraintList finds a single-line bracketed list of constraints, e.g. [">=1.2.3", "<2.0.0"]
+// Every entry is an element of the constraint, and the list counts as a constraint if every entry is a version with
+// an optional operator and at least one entry has an operator. Entries may be quoted.
+func parseConstraintList(line string) ([]constraintElement, bool) {
+	open := strings.Index(line, "[")
+	end := strings.LastIndex(line, "]")
+	if open < 0 || end < open || strings.ContainsAny(line[open+1:end], "[]{}") {
+		return nil, false
+	}
+
+	var elements []constraintElement
+	offset := open + 1
+	for _, entry := range strings.Split(line[open+1:end], ",") {
+		trimmed := strings.TrimSpace(strings.Trim(strings.TrimSpace(entry), `"'`))
+		operatorMatch := constraintElementPattern.FindStringSubmatch(trimmed)
+		if operatorMatch == nil {
+			return nil, false
+		}
+
+		start := offset + strings.Index(entry, trimmed)
+		elements = append(elements, constraintElement{operator: operatorMatch[1], start: start, end: start + len(trimmed)})
+		offset += len(entry) + 1
+	}
+
+	return elements, anyOperator(elements)
+}
+
+// anyOperator reports whether any element of the constraint has an operator
+func anyOperator(elements []constraintElement) bool {
+	for _, element := range elements {
+		if element.operator != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerBound returns the element holding the lowest allowed version, i.e. the first element that isn't
+// an upper bound or an exclusion
+func lowerBound(elements []constraintElement) (constraintElement, bool) {
+	for _, element := range elements {
+		switch element.operator {
+		case "", "=", ">=", ">", "~>":
+			return element, true
+		}
+	}
+	return constraintElement{}, false
+}
+
+// checkUpperBounds returns a skip if the target version of the directive's package violates an upper bound
+// of the constraint expression, as updating the lower bound would make the constraint unsatisfiable
+func checkUpperBounds(line string, elements []constraintElement, d directive, packages []Package, scheme versionScheme, currentVersion string) *Skip {
+	for _, pkg := range packages {
+		if pkg.Name != d.packageName {
+			continue
+		}
+
+		targetVersion := scheme.format(currentVersion, pkg.Version)
+		for _, element := range elements {
+			if element.operator != "<" && element.operator != "<=" {
+				continue
+			}
+			bound, ok := scheme.find(line[element.start:element.end])
+			if !ok {
+				continue
+			}
+
+			comparison := compareVersions(targetVersion, bound.version)
+			if comparison > 0 || element.operator == "<" && comparison == 0 {
+				return &Skip{
+					Package: pkg.Name,
+					Version: currentVersion,
+					Reason:  fmt.Sprintf("version %s is outside of the upper bound %s", targetVersion, line[element.start:element.end]),
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package updater
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConstraintExpression(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expected      []string // Elements as they appear in the line
+		expectedLower string
+	}{
+		{name: "compound constraint", line: `version = ">= 4.0.0, < 5.0.0"`, expected: []string{">= 4.0.0", "< 5.0.0"}, expectedLower: ">= 4.0.0"},
+		{name: "upper bound first", line: `version = "<5.0.0,>=4.0.0"`, expected: []string{"<5.0.0", ">=4.0.0"}, expectedLower: ">=4.0.0"},
+		{name: "pessimistic operator", line: `version = "~> 4.0"`, expected: []string{"~> 4.0"}, expectedLower: "~> 4.0"},
+		{name: "only upper bounds", line: `version = "!= 4.1.0, < 5.0.0"`, expected: []string{"!= 4.1.0", "< 5.0.0"}},
+		{name: "plain version", line: `version = "4.0.0"`},
+		{name: "module source", line: `source = "git::https://example.com/repo.git?ref=v1.2.3"`},
+		{name: "constraint after another string", line: `provider "aws" { version = ">= 4.0.0" }`, expected: []string{">= 4.0.0"}, expectedLower: ">= 4.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements, ok := parseConstraintExpression(tt.line)
+			if ok != (tt.expected != nil) {
+				t.Fatalf("parseConstraintExpression() ok = %v, expected %v", ok, tt.expected != nil)
+			}
+
+			var texts []string
+			for _, element := range elements {
+				texts = append(texts, tt.line[element.start:element.end])
+			}
+			if !reflect.DeepEqual(texts, tt.expected) {
+				t.Errorf("parseConstraintExpression() = %q, expected %q", texts, tt.expected)
+			}
+
+			lower, ok := lowerBound(elements)
+			if ok != (tt.expectedLower != "") || ok && tt.line[lower.start:lower.end] != tt.expectedLower {
+				t.Errorf("lowerBound() = %v, expected %q", lower, tt.expectedLower)
+			}
+		})
+	}
+}
+
+func TestParseConstraintList(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expected      []string // Elements as they appear in the line
+		expectedLower string
+	}{
+		{name: "two elements", line: `version: [">=1.2.3", "<2.0.0"]`, expected: []string{">=1.2.3", "<2.0.0"}, expectedLower: ">=1.2.3"},
+		{name: "upper bound first", line: `version: ['< 2.0.0', '>= 1.2.3']`, expected: []string{"< 2.0.0", ">= 1.2.3"}, expectedLower: ">= 1.2.3"},
+		{name: "unquoted entries", line: `version = [>=1.2.3, <2.0.0]`, expected: []string{">=1.2.3", "<2.0.0"}, expectedLower: ">=1.2.3"},
+		{name: "plain versions", line: `versions: ["1.0.0", "2.0.0"]`},
+		{name: "images", line: `images: [lib:2.0.0, app:1.0.0]`},
+		{name: "no list", line: `version: ">=1.2.3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elements, ok := parseConstraintList(tt.line)
+			if ok != (tt.expected != nil) {
+				t.Fatalf("parseConstraintList() ok = %v, expected %v", ok, tt.expected != nil)
+			}
+			if !ok {
+				return
+			}
+
+			var texts []string
+			for _, element := range elements {
+				texts = append(texts, tt.line[element.start:element.end])
+			}
+			if !reflect.DeepEqual(texts, tt.expected) {
+				t.Errorf("parseConstraintList() = %q, expected %q", texts, tt.expected)
+			}
+
+			lower, ok := lowerBound(elements)
+			if !ok || tt.line[lower.start:lower.end] != tt.expectedLower {
+				t.Errorf("lowerBound() = %v, expected %q", lower, tt.expectedLower)
+			}
+		})
+	}
+}
@@ -1,16 +1,23 @@
 package updater
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 )
 
+// hclHeredocPattern matches a line starting a heredoc like `user_data = <<-EOT`, capturing the end marker
+var /* const */ hclHeredocPattern = regexp.MustCompile(`<<-?([A-Za-z_][A-Za-z0-9_-]*)\s*$`)
+
 type HclFileUpdater struct {
 	supportedFileExtensions map[string]struct{}
-	commentPatterns         []*regexp.Regexp
+	comments                commentSyntax
+	scheme                  string // Version scheme used for comments without a scheme attribute
+	canonical               bool   // When true, versions with the same semver precedence are equal
+	leadingZeros            bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	prefixAuto              bool   // When true, the prefix of the version in the file is kept for the new version
+	updateMode              string // Treatment of constraint operators for comments without a mode attribute
 }
 
 func NewHclFileUpdater() *HclFileUpdater {
@@ -20,16 +27,16 @@ func NewHclFileUpdater() *HclFileUpdater {
 			".tf":     {},
 			".tfvars": {},
 		},
-		commentPatterns: []*regexp.Regexp{
-			regexp.MustCompile(`#\s*depup\s+package=([^\s]+)`),  // # style comment
-			regexp.MustCompile(`//\s*depup\s+package=([^\s]+)`), // // style comment
-		},
+		comments: newCommentSyntax("#", "//"),
 	}
 }
 
+func (u *HclFileUpdater) Name() string {
+	return "hcl"
+}
+
 func (u *HclFileUpdater) Supports(fileExtension string) bool {
-	_, ok := u.supportedFileExtensions[fileExtension]
-	return ok
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
 }
 
 func (u *HclFileUpdater) GetSupportedExtensions() []string {
@@ -40,221 +47,301 @@ func (u *HclFileUpdater) GetSupportedExtensions() []string {
 	return extensions
 }
 
-func (u *HclFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, bool, error) {
+func (u *HclFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
 	// Read file and prepare data
-	lines, endsWithNewline, err := u.readFileContent(filePath)
+	lines, format, err := readFileLines(filePath)
 	if err != nil {
-		return "", false, err
+		return "", nil, err
 	}
 
-	// Process lines and build output
-	outputContent, updated := u.processLines(lines, packages, endsWithNewline)
+	// Process lines with the scheme of this run and build output
+	processor := *u
+	processor.scheme = options.Scheme
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.prefixAuto = options.VersionPrefixAuto
+	processor.updateMode = options.UpdateMode
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
 
-	// Write changes if needed
-	if updated && !options.DryRun {
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
 		err = os.WriteFile(filePath, []byte(outputContent), 0644)
 		if err != nil {
-			return "", false, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
 		}
 	}
 
-	return outputContent, updated, nil
+	return outputContent, changes, nil
 }
 
-// readFileContent reads a file and returns its lines and whether it ends with a newline
-func (u *HclFileUpdater) readFileContent(filePath string) ([]string, bool, error) {
-	// Read file content first to check for trailing newline
-	fileContent, err := os.ReadFile(filePath)
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *HclFileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
 	if err != nil {
-		return nil, false, fmt.Errorf("cannot read file %s: %w", filePath, err)
+		return nil, err
 	}
 
-	// Check if file ends with newline
-	endsWithNewline := len(fileContent) > 0 && (fileContent[len(fileContent)-1] == '\n')
-
-	// Open file for reading
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, false, fmt.Errorf("cannot open file %s: %w", filePath, err)
-	}
-	defer file.Close()
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
 
-	// Create a scanner to read line by line
-	scanner := bufio.NewScanner(file)
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *HclFileUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
 
-	// Read lines
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *HclFileUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
 
-	// Check for scanner errors
-	if err = scanner.Err(); err != nil {
-		return nil, false, fmt.Errorf("error reading file %s: %w", filePath, err)
-	}
+// depupComments returns the syntax depup comments are recognized by
+func (u *HclFileUpdater) depupComments() commentSyntax {
+	return u.comments
+}
 
-	return lines, endsWithNewline, nil
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *HclFileUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
 }
 
-// processLines processes all lines and returns the modified content and update status
-func (u *HclFileUpdater) processLines(lines []string, packages []Package, endsWithNewline bool) (string, bool) {
-	var output strings.Builder
-	updated := false
-
-	for i := 0; i < len(lines); i++ {
-		currentLine := lines[i]
-		modifiedLine := currentLine
-		lineUpdated := false
-
-		// Check for inline depup comment
-		if newLine, lineWasUpdated := u.processInlineDepupComment(currentLine, packages); lineWasUpdated {
-			modifiedLine = newLine
-			lineUpdated = true
-		} else if i > 0 {
-			// Check for depup comment in previous line
-			if newLine, lineWasUpdated := u.processPreviousLineDepupComment(lines[i-1], currentLine, packages); lineWasUpdated {
-				modifiedLine = newLine
-				lineUpdated = true
+// heredocLines reports for each line whether it belongs to the body of a heredoc, including its end marker
+// Heredocs hold text like scripts, so versions in them are left alone even if a depup comment precedes them.
+func (u *HclFileUpdater) heredocLines(lines []string) []bool {
+	heredoc := make([]bool, len(lines))
+	marker := ""
+	for i, line := range lines {
+		if marker != "" {
+			heredoc[i] = true
+			if strings.TrimSpace(line) == marker {
+				marker = ""
 			}
+			continue
 		}
 
-		// Add the current line to output
-		output.WriteString(modifiedLine)
-
-		// Add newline if not the last line or if the original file had a trailing newline
-		if i < len(lines)-1 || endsWithNewline {
-			output.WriteString("\n")
+		// Markers in comments, e.g. "# see <<EOT", start no heredoc
+		if matches := hclHeredocPattern.FindStringSubmatch(line); matches != nil && !u.inComment(line, matches[0]) {
+			marker = matches[1]
 		}
-
-		// Update the overall update status
-		updated = updated || lineUpdated
 	}
-
-	return output.String(), updated
+	return heredoc
 }
 
-// processInlineDepupComment handles the case where a depup comment is on the same line as the version
-func (u *HclFileUpdater) processInlineDepupComment(line string, packages []Package) (string, bool) {
-	// Patterns for both comment styles in HCL
-	inlineCommentRegexes := []*regexp.Regexp{
-		regexp.MustCompile(`(.*?)(\s*#.*)$`), // # style comment
-		regexp.MustCompile(`(.*?)(\s*//.*)`), // // style comment
+// inComment reports whether the text at the end of the line is part of a comment
+func (u *HclFileUpdater) inComment(line, text string) bool {
+	if u.comments.isCommentLine(line) {
+		return true
 	}
-
-	for _, regex := range inlineCommentRegexes {
-		inlineMatches := regex.FindStringSubmatch(line)
-		if len(inlineMatches) <= 2 {
-			continue
+	for _, split := range u.comments.inlineSplits(line) {
+		if strings.HasSuffix(split[1], text) {
+			return true
 		}
+	}
+	return false
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *HclFileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
 
-		lineContent := inlineMatches[1]
-		comment := inlineMatches[2]
+	// Try each comment style of HCL
+	for _, split := range u.comments.inlineSplits(line) {
+		lineContent, comment := split[0], split[1]
 
 		// Check if it's a depup comment using all patterns
-		var packageName string
-		for _, pattern := range u.commentPatterns {
-			depupMatches := pattern.FindStringSubmatch(comment)
-			if len(depupMatches) > 1 {
-				packageName = depupMatches[1]
-				break
-			}
+		depupDirective, ok := u.parseDepupComment(comment)
+		if !ok || strings.TrimSpace(lineContent) == "" {
+			continue
 		}
 
-		if packageName == "" {
+		// Look for the versions in the line content and try to update them
+		updated := updatePackages(lineContent, depupDirective, u.scheme, u.leadingZeros, func(content string, d directive) lineResult {
+			return u.updateLine(content, d, packages)
+		})
+		result.packageName = updated.packageName
+		if updated.version == "" {
 			continue
 		}
 
-		// Look for version in the line content
-		versionMatches := versionPattern.FindStringSubmatch(lineContent)
-		if len(versionMatches) <= 3 {
-			continue
+		// Reconstruct the line with updated version
+		if updated.changed() {
+			updated.line += comment
+			return updated
 		}
 
-		// Try to update the version
-		updatedContent, updated := u.updateVersion(lineContent, packageName, packages, versionMatches)
-		if !updated {
-			continue
+		// Report versions a guard kept unchanged
+		result.version, result.skip, result.more = updated.version, updated.skip, updated.more
+		if updated.skip != nil {
+			return result
 		}
+	}
 
-		// Reconstruct the line with updated version
-		return updatedContent + comment, true
+	return result
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *HclFileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
 	}
 
-	return line, false
+	// Look for the versions in the current line and try to update them
+	return updatePackages(currentLine, depupDirective, u.scheme, u.leadingZeros, func(content string, d directive) lineResult {
+		return u.updateLine(content, d, packages)
+	})
 }
 
-// processPreviousLineDepupComment handles the case where a depup comment is on the line before the version
-func (u *HclFileUpdater) processPreviousLineDepupComment(prevLine, currentLine string, packages []Package) (string, bool) {
-	var packageName string
+// updateLine finds the version of the directive in the line content and updates it
+// Interpolations like "${var.registry}/app:1.2.3" are masked, so only versions outside of them are updated.
+func (u *HclFileUpdater) updateLine(content string, d directive, packages []Package) lineResult {
+	masked, interpolations := maskInterpolations(content)
+	return restoreTemplateTags(u.updateMaskedLine(masked, d, packages), content, interpolations)
+}
 
-	// Check all comment patterns
-	for _, pattern := range u.commentPatterns {
-		prevLineMatches := pattern.FindStringSubmatch(prevLine)
-		if len(prevLineMatches) > 1 {
-			packageName = prevLineMatches[1]
-			break
-		}
+// updateMaskedLine finds the version of the directive in the line content without interpolations and updates it
+// In constraint expressions like ">= 4.0.0, < 5.0.0" only the lower bound is updated, and updates beyond
+// an upper bound are skipped. Other update modes replace the whole constraint by the updated lower bound.
+// The version of the result is empty if no version was found.
+func (u *HclFileUpdater) updateMaskedLine(content string, d directive, packages []Package) lineResult {
+	result := lineResult{line: content, packageName: d.packageName}
+
+	scheme, ok := lookupScheme(d, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+	mode, ok := lookupUpdateMode(d, u.updateMode)
+	if !ok {
+		return result
 	}
 
-	if packageName == "" {
-		return currentLine, false
+	// Narrow constraint expressions and lists down to their lower bound, constraints without one are left alone
+	start, end := 0, len(content)
+	elements, isConstraint := parseConstraintList(content)
+	if !isConstraint {
+		elements, isConstraint = parseConstraintExpression(content)
+	}
+	var lower constraintElement
+	if isConstraint {
+		lower, ok = lowerBound(elements)
+		if !ok {
+			return result
+		}
+		start, end = lower.start, lower.end
 	}
+	element := content[start:end]
 
-	// Look for version in current line
-	versionMatches := versionPattern.FindStringSubmatch(currentLine)
-	if len(versionMatches) <= 3 {
-		return currentLine, false
+	match, ok := scheme.find(element)
+	if !ok {
+		return result
+	}
+	result.version = match.version
+	result.matches = countVersions(scheme, element)
+
+	// Skip the update if the from= guard doesn't hold, the scheme refuses a downgrade or the new version
+	// exceeds an upper bound
+	if skip := checkFromGuard(d, packages, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if isConstraint && mode == UpdateModeRange {
+		if skip := checkUpperBounds(content, elements, d, packages, scheme, match.version); skip != nil {
+			result.skip = skip
+			return result
+		}
 	}
 
 	// Try to update the version
-	updatedContent, updated := u.updateVersion(currentLine, packageName, packages, versionMatches)
-	if !updated {
-		return currentLine, false
+	updatedElement, change := u.updateVersion(element, d, packages, scheme, match)
+	if change == nil {
+		return result
+	}
+
+	result.line = content[:start] + updatedElement + content[end:]
+	if isConstraint {
+		result.line = applyUpdateMode(content, elements, lower, updatedElement, mode)
 	}
+	result.change = change
 
-	return updatedContent, true
+	return result
 }
 
-// updateVersion updates the version in a line if the package name matches
-func (u *HclFileUpdater) updateVersion(line, packageName string, packages []Package, versionMatches []string) (string, bool) {
+// updateVersion updates the version in a line if the package name of the directive matches
+// With a replace attribute, the whole value containing the version is substituted
+func (u *HclFileUpdater) updateVersion(line string, d directive, packages []Package, scheme versionScheme, match versionMatch) (string, *Change) {
 	for _, pkg := range packages {
-		if pkg.Name == packageName {
-			// Extract version components by named regex groups
-			startQuote := versionMatches[1]
-			major := versionMatches[2]
-			minor := versionMatches[3]
-			patch := versionMatches[4]
-			prerelease := versionMatches[5]
-			buildmetadata := versionMatches[6]
-			endQuote := versionMatches[7]
-
-			// Compose current version
-			currentVersion := major + "." + minor + "." + patch
-			if prerelease != "" {
-				currentVersion += "-" + prerelease
+		if pkg.Name == d.packageName {
+			currentVersion := match.version
+			targetVersion := scheme.format(currentVersion, packageVersion(pkg, u.prefixAuto))
+
+			if template, ok := d.attributes[replaceAttribute]; ok {
+				updatedLine, changed := replaceMatchedValue(line, match, renderReplaceTemplate(template, targetVersion))
+				if !changed {
+					return line, nil
+				}
+				return updatedLine, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
 			}
-			if buildmetadata != "" {
-				currentVersion += "+" + buildmetadata
+
+			// Only the digits are replaced, a "v" in front of them stays as written in the file
+			targetVersion = trimVPrefix(targetVersion)
+			if versionsEqual(currentVersion, targetVersion, u.canonical) {
+				return line, nil
 			}
 
-			cleanCurrent := currentVersion
-			cleanTarget := pkg.Version
+			// Replace the entire match, keeping the quotes and any constraint operator in front
+			updatedLine := strings.Replace(line, match.text, match.startQuote+targetVersion+match.endQuote, 1)
 
-			if cleanCurrent == cleanTarget {
-				return line, false
-			}
+			return updatedLine, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+		}
+	}
 
-			// Replace using fully normalized target version
-			updatedLine := strings.Replace(
-				line,
-				versionMatches[0], // entire match
-				startQuote+pkg.Version+endQuote,
-				1,
-			)
+	return line, nil
+}
 
-			return updatedLine, true
+// maskInterpolations replaces every ${...} interpolation and %{...} directive of the line with a placeholder
+// Braces nested inside them, e.g. of object expressions, are matched, and an unterminated sequence is left alone.
+// Returns the masked line and the replaced sequences in order, to be put back by restoreTemplateTags.
+func maskInterpolations(line string) (string, []string) {
+	var masked strings.Builder
+	var sequences []string
+	for i := 0; i < len(line); i++ {
+		if (line[i] == '$' || line[i] == '%') && i+1 < len(line) && line[i+1] == '{' {
+			if end := closingBrace(line, i+1); end >= 0 {
+				sequences = append(sequences, line[i:end+1])
+				masked.WriteString(templateTagPlaceholder)
+				i = end
+				continue
+			}
 		}
+		masked.WriteByte(line[i])
 	}
 
-	return line, false
+	return masked.String(), sequences
+}
+
+// closingBrace returns the index of the brace closing the one at start, or -1 if it isn't closed on the line
+func closingBrace(line string, start int) int {
+	depth := 0
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
 }
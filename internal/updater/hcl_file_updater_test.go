@@ -42,6 +42,24 @@ func TestHclFileUpdater_UpdateFile(t *testing.T) {
 			expectUpdated:  true,
 			expectError:    false,
 		},
+		{
+			name:           "Replace directive changes module source",
+			fileContent:    "# depup package=app replace=git::https://example.com/new.git?ref=v{version}\nsource = \"git::https://example.com/old.git?ref=v1.0.0\"\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=git::https://example.com/new.git?ref=v{version}\nsource = \"git::https://example.com/new.git?ref=v2.0.0\"\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Double slash comment below the version",
+			fileContent:    "version = \"1.0.0\"\n// depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{CommentPosition: CommentPositionBelow},
+			expectedOutput: "version = \"2.0.0\"\n// depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
 		{
 			name:           "Inline slash comment with matching package",
 			fileContent:    "version = \"1.0.0\" // depup package=test-pkg\n",
@@ -230,6 +248,42 @@ func TestHclFileUpdater_UpdateFile(t *testing.T) {
 			expectUpdated:  false,
 			expectError:    false,
 		},
+		{
+			name:           "Whitespace inside quotes is preserved",
+			fileContent:    "# depup package=test-pkg\nversion = \" 1.0.0 \"\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=test-pkg\nversion = \" 2.0.0 \"\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Leading whitespace inside quotes with inline comment",
+			fileContent:    "version = \"  1.0.0\" // depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version = \"  2.0.0\" // depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Colon directive style with hash comment",
+			fileContent:    "# depup:package=test-pkg\nversion = \"1.0.0\"\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup:package=test-pkg\nversion = \"2.0.0\"\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Colon directive style with inline slash comment",
+			fileContent:    "version = \"1.0.0\" //depup:package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version = \"2.0.0\" //depup:package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -245,7 +299,7 @@ func TestHclFileUpdater_UpdateFile(t *testing.T) {
 			updater := NewHclFileUpdater()
 
 			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
 
 			// Check error expectation
 			if (err != nil) != tt.expectError {
@@ -254,7 +308,7 @@ func TestHclFileUpdater_UpdateFile(t *testing.T) {
 			}
 
 			// Check updated flag
-			if updated != tt.expectUpdated {
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
 				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
 			}
 
@@ -279,6 +333,95 @@ func TestHclFileUpdater_UpdateFile(t *testing.T) {
 	}
 }
 
+func TestHclFileUpdater_Constraints(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		expectedOutput string
+		expectedSkip   string
+	}{
+		{
+			name:           "Lower bound of compound constraint",
+			fileContent:    "# depup package=aws\nversion = \">= 4.0.0, < 5.0.0\"\n",
+			version:        "4.5.0",
+			expectedOutput: "# depup package=aws\nversion = \">= 4.5.0, < 5.0.0\"\n",
+		},
+		{
+			name:           "Lower bound after the upper bound",
+			fileContent:    "version = \"< 5.0.0, >= 4.0.0\" # depup package=aws\n",
+			version:        "4.5.0",
+			expectedOutput: "version = \"< 5.0.0, >= 4.5.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Exclusion before the lower bound",
+			fileContent:    "# depup package=aws\nversion = \"!= 4.1.0, >= 4.0.0, <= 5.0.0\"\n",
+			version:        "5.0.0",
+			expectedOutput: "# depup package=aws\nversion = \"!= 4.1.0, >= 5.0.0, <= 5.0.0\"\n",
+		},
+		{
+			name:           "Pessimistic lower bound with partial scheme",
+			fileContent:    "# depup package=aws scheme=partial\nversion = \"~> 4.0, < 5.0\"\n",
+			version:        "4.5.2",
+			expectedOutput: "# depup package=aws scheme=partial\nversion = \"~> 4.5, < 5.0\"\n",
+		},
+		{
+			name:           "Single lower bound",
+			fileContent:    "# depup package=aws\nversion = \">= 4.0.0\"\n",
+			version:        "4.5.0",
+			expectedOutput: "# depup package=aws\nversion = \">= 4.5.0\"\n",
+		},
+		{
+			name:           "Single upper bound is left alone",
+			fileContent:    "# depup package=aws\nversion = \"< 5.0.0\"\n",
+			version:        "4.5.0",
+			expectedOutput: "# depup package=aws\nversion = \"< 5.0.0\"\n",
+		},
+		{
+			name:           "Version beyond the upper bound is skipped",
+			fileContent:    "# depup package=aws\nversion = \">= 4.0.0, < 5.0.0\"\n",
+			version:        "5.0.0",
+			expectedOutput: "# depup package=aws\nversion = \">= 4.0.0, < 5.0.0\"\n",
+			expectedSkip:   "version 5.0.0 is outside of the upper bound < 5.0.0",
+		},
+		{
+			name:           "Lower bound of a constraint list",
+			fileContent:    "versions = [\"< 5.0.0\", \">= 4.0.0\"] # depup package=aws\n",
+			version:        "4.5.0",
+			expectedOutput: "versions = [\"< 5.0.0\", \">= 4.5.0\"] # depup package=aws\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".hcl")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			var skipped []Skip
+			options := FileUpdaterOptions{DryRun: true, OnSkip: func(skip Skip) { skipped = append(skipped, skip) }}
+			output, _, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "aws", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			var reasons []string
+			for _, skip := range skipped {
+				reasons = append(reasons, skip.Reason)
+			}
+			if tt.expectedSkip == "" && len(reasons) > 0 || tt.expectedSkip != "" && (len(reasons) != 1 || reasons[0] != tt.expectedSkip) {
+				t.Errorf("UpdateFile() skipped = %q, expectedSkip %q", reasons, tt.expectedSkip)
+			}
+		})
+	}
+}
+
 func TestHclFileUpdater_TerraformFiles(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -452,7 +595,7 @@ func TestHclFileUpdater_TerraformFiles(t *testing.T) {
 			updater := NewHclFileUpdater()
 
 			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
 
 			// Check error expectation
 			if (err != nil) != tt.expectError {
@@ -461,7 +604,7 @@ func TestHclFileUpdater_TerraformFiles(t *testing.T) {
 			}
 
 			// Check updated flag
-			if updated != tt.expectUpdated {
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
 				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
 			}
 
@@ -486,6 +629,394 @@ func TestHclFileUpdater_TerraformFiles(t *testing.T) {
 	}
 }
 
+func TestHclFileUpdater_PartialScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		packages       []Package
+		options        FileUpdaterOptions
+		expectedOutput string
+		expectUpdated  bool
+	}{
+		{
+			name:           "Pessimistic constraint with scheme attribute",
+			fileContent:    "# depup package=aws scheme=partial\nversion = \"~> 4.0\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.0"}},
+			expectedOutput: "# depup package=aws scheme=partial\nversion = \"~> 4.5\"\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Inline comment with scheme attribute",
+			fileContent:    "version = \">= 4.0, < 5.0\" // depup package=aws scheme=partial\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.2"}},
+			expectedOutput: "version = \">= 4.5, < 5.0\" // depup package=aws scheme=partial\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Three components are kept",
+			fileContent:    "# depup package=aws scheme=partial\nversion = \"~> 4.0.1\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.2"}},
+			expectedOutput: "# depup package=aws scheme=partial\nversion = \"~> 4.5.2\"\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Same precision is up to date",
+			fileContent:    "# depup package=aws scheme=partial\nversion = \"~> 4.5\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.3"}},
+			expectedOutput: "# depup package=aws scheme=partial\nversion = \"~> 4.5\"\n",
+			expectUpdated:  false,
+		},
+		{
+			name:           "Partial scheme from options",
+			fileContent:    "# depup package=aws\nversion = \"~> 4.0\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.0"}},
+			options:        FileUpdaterOptions{Scheme: SchemePartial},
+			expectedOutput: "# depup package=aws\nversion = \"~> 4.5\"\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Semver scheme skips two components",
+			fileContent:    "# depup package=aws\nversion = \"~> 4.0\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.0"}},
+			expectedOutput: "# depup package=aws\nversion = \"~> 4.0\"\n",
+			expectUpdated:  false,
+		},
+		{
+			name:           "Unknown scheme is skipped",
+			fileContent:    "# depup package=aws scheme=calver\nversion = \"4.0.0\"\n",
+			packages:       []Package{{Name: "aws", Version: "4.5.0"}},
+			expectedOutput: "# depup package=aws scheme=calver\nversion = \"4.0.0\"\n",
+			expectUpdated:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			updater := NewHclFileUpdater()
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestHclFileUpdater_TrailingWhitespace(t *testing.T) {
+	// Trailing whitespace after an updated version must be kept byte-for-byte
+	tests := []struct {
+		name           string
+		fileContent    string
+		options        FileUpdaterOptions
+		expectedOutput string
+	}{
+		{
+			name:           "Spaces after quoted version",
+			fileContent:    "# depup package=app\nversion = \"1.0.0\"   \n",
+			expectedOutput: "# depup package=app\nversion = \"2.0.0\"   \n",
+		},
+		{
+			name:           "Whitespace before inline comment",
+			fileContent:    "version = \"1.0.0\" \t // depup package=app\n",
+			expectedOutput: "version = \"2.0.0\" \t // depup package=app\n",
+		},
+		{
+			name:           "Whitespace after constraint",
+			fileContent:    "# depup package=app\nversion = \">= 1.0.0, < 3.0.0\"\t\n",
+			expectedOutput: "# depup package=app\nversion = \">= 2.0.0, < 3.0.0\"\t\n",
+		},
+		{
+			name:           "Whitespace before CRLF",
+			fileContent:    "# depup package=app\r\nversion = \"1.0.0\"  \r\n",
+			expectedOutput: "# depup package=app\r\nversion = \"2.0.0\"  \r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			tt.options.DryRun = true
+			output, changes, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, tt.options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 {
+				t.Errorf("UpdateFile() changes = %+v, expected one change", changes)
+			}
+		})
+	}
+}
+
+func TestHclFileUpdater_VPrefix(t *testing.T) {
+	// A "v" in front of the version is kept as written in the file, whether the target has one or not
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		expectedOutput string
+	}{
+		{
+			name:           "v in the file with a bare target",
+			fileContent:    "# depup package=app\nversion = \"v1.0.0\"\n",
+			version:        "2.0.0",
+			expectedOutput: "# depup package=app\nversion = \"v2.0.0\"\n",
+		},
+		{
+			name:           "v in the file and the target",
+			fileContent:    "# depup package=app\nversion = \"v1.0.0\"\n",
+			version:        "v2.0.0",
+			expectedOutput: "# depup package=app\nversion = \"v2.0.0\"\n",
+		},
+		{
+			name:           "Bare file with a v target",
+			fileContent:    "# depup package=app\nversion = \"~> 1.0.0\"\n",
+			version:        "v2.0.0",
+			expectedOutput: "# depup package=app\nversion = \"~> 2.0.0\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: tt.version}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 || changes[0].NewVersion != "2.0.0" {
+				t.Errorf("UpdateFile() changes = %+v, expected one change to 2.0.0", changes)
+			}
+		})
+	}
+}
+
+func TestHclFileUpdater_MultiplePackages(t *testing.T) {
+	fileContent := "# depup package=app,sidecar\nversions = [\"1.0.0\", \"1.0.0\"]\n" +
+		"images = \"app:1.0.0 sidecar:1.0.0\" // depup package=app,sidecar\n"
+	expectedOutput := "# depup package=app,sidecar\nversions = [\"2.0.0\", \"3.0.0\"]\n" +
+		"images = \"app:2.0.0 sidecar:3.0.0\" // depup package=app,sidecar\n"
+
+	tempFile, err := createTempFileWithContent(fileContent, ".tf")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	packages := []Package{{Name: "app", Version: "2.0.0"}, {Name: "sidecar", Version: "3.0.0"}}
+	output, changes, err := NewHclFileUpdater().UpdateFile(tempFile, packages, FileUpdaterOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	if output != expectedOutput {
+		t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, expectedOutput)
+	}
+	if len(changes) != 4 {
+		t.Errorf("UpdateFile() changes = %+v, expected one change per package and line", changes)
+	}
+}
+
+func TestHclFileUpdater_Interpolations(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+	}{
+		{
+			name:           "Registry interpolation before the image",
+			fileContent:    "image = \"${var.registry}/app:1.2.3\" # depup package=app\n",
+			expectedOutput: "image = \"${var.registry}/app:2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Interpolation directly before the tag",
+			fileContent:    "# depup package=app\nimage = \"${local.app_v1_0_0}:1.2.3\"\n",
+			expectedOutput: "# depup package=app\nimage = \"${local.app_v1_0_0}:2.0.0\"\n",
+		},
+		{
+			name:           "Version inside the interpolation is left alone",
+			fileContent:    "image = \"${lookup(var.images, \"9.9.9\")}/app:1.2.3\" # depup package=app\n",
+			expectedOutput: "image = \"${lookup(var.images, \"9.9.9\")}/app:2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Nested braces and template directives",
+			fileContent:    "image = \"${merge({tag = \"9.9.9\"}, var.m).registry}%{ if true }/x%{ endif }/app:1.2.3\" # depup package=app\n",
+			expectedOutput: "image = \"${merge({tag = \"9.9.9\"}, var.m).registry}%{ if true }/x%{ endif }/app:2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Only an interpolated version",
+			fileContent:    "image = \"app:${var.app_version}\" # depup package=app\n",
+			expectedOutput: "image = \"app:${var.app_version}\" # depup package=app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestHclFileUpdater_UpdateModes(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		updateMode     string
+		expectedOutput string
+	}{
+		{
+			name:           "Range updates the lower bound",
+			fileContent:    "version = \">= 4.0.0, < 6.0.0\" # depup package=aws\n",
+			updateMode:     UpdateModeRange,
+			expectedOutput: "version = \">= 5.1.0, < 6.0.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Constraint keeps the operator of the lower bound",
+			fileContent:    "version = \">= 4.0.0, < 6.0.0\" # depup package=aws\n",
+			updateMode:     UpdateModeConstraint,
+			expectedOutput: "version = \">= 5.1.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Pin writes the exact version",
+			fileContent:    "version = \"~> 4.0.0\" # depup package=aws\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "version = \"5.1.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Range skips updates beyond the upper bound",
+			fileContent:    "version = \">= 4.0.0, < 5.0.0\" # depup package=aws\n",
+			updateMode:     UpdateModeRange,
+			expectedOutput: "version = \">= 4.0.0, < 5.0.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Constraint drops the upper bound",
+			fileContent:    "version = \">= 4.0.0, < 5.0.0\" # depup package=aws\n",
+			updateMode:     UpdateModeConstraint,
+			expectedOutput: "version = \">= 5.1.0\" # depup package=aws\n",
+		},
+		{
+			name:           "Mode attribute overrides the default",
+			fileContent:    "# depup package=aws mode=constraint\nversion = \"~> 4.0.0, != 4.2.0\"\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "# depup package=aws mode=constraint\nversion = \"~> 5.1.0\"\n",
+		},
+		{
+			name:           "Unknown mode attribute",
+			fileContent:    "version = \"~> 4.0.0\" # depup package=aws mode=exact\n",
+			expectedOutput: "version = \"~> 4.0.0\" # depup package=aws mode=exact\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "aws", Version: "5.1.0"}}, FileUpdaterOptions{DryRun: true, UpdateMode: tt.updateMode})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestHclFileUpdater_Heredocs(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+	}{
+		{
+			name:           "Comment above a heredoc",
+			fileContent:    "# depup package=app\nuser_data = <<EOT\ninstall app 1.2.3\nEOT\n",
+			expectedOutput: "# depup package=app\nuser_data = <<EOT\ninstall app 1.2.3\nEOT\n",
+		},
+		{
+			name:           "Annotations inside an indented heredoc",
+			fileContent:    "script = <<-SCRIPT\n  # depup package=app\n  APP_VERSION=1.2.3\n  app:1.2.3 # depup package=app\n  SCRIPT\n",
+			expectedOutput: "script = <<-SCRIPT\n  # depup package=app\n  APP_VERSION=1.2.3\n  app:1.2.3 # depup package=app\n  SCRIPT\n",
+		},
+		{
+			name:           "Versions after the heredoc are updated",
+			fileContent:    "user_data = <<EOT\nEOT is not the end here\n# depup package=app\nEOT\n# depup package=app\nversion = \"1.2.3\"\n",
+			expectedOutput: "user_data = <<EOT\nEOT is not the end here\n# depup package=app\nEOT\n# depup package=app\nversion = \"2.0.0\"\n",
+		},
+		{
+			name:           "Marker in a comment",
+			fileContent:    "# scripts start with <<EOT\n# depup package=app\nversion = \"1.2.3\"\n",
+			expectedOutput: "# scripts start with <<EOT\n# depup package=app\nversion = \"2.0.0\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".tf")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewHclFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
 func TestHclFileUpdater_Supports(t *testing.T) {
 	updater := NewHclFileUpdater()
 
@@ -0,0 +1,325 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSidecarSuffix is appended to a JSON file path to locate its annotation sidecar
+// JSON doesn't support comments, so annotations live in a parallel file instead
+const jsonSidecarSuffix = ".depup.yaml"
+
+// errJSONNotFound is returned when a JSON pointer references a missing member or element
+var errJSONNotFound = errors.New("not found")
+
+// errJSONTypeMismatch is returned when a JSON pointer descends into a value of another type, e.g. a key into an array
+var errJSONTypeMismatch = errors.New("type mismatch")
+
+// JsonFileUpdater updates JSON files using a sidecar file that maps JSON pointers to package names
+// This includes Terraform's JSON variants like override.tf.json and terraform.tfvars.json.
+// Example sidecar content for config.json (config.json.depup.yaml):
+//
+//	/services/api/version: api
+type JsonFileUpdater struct {
+	supportedFileExtensions map[string]struct{}
+}
+
+func NewJsonFileUpdater() *JsonFileUpdater {
+	return &JsonFileUpdater{
+		// .tf.json and .tfvars.json files use Terraform's JSON syntax, so they are handled here rather than as HCL
+		supportedFileExtensions: map[string]struct{}{
+			".json":        {},
+			".tf.json":     {},
+			".tfvars.json": {},
+		},
+	}
+}
+
+func (u *JsonFileUpdater) Name() string {
+	return "json"
+}
+
+func (u *JsonFileUpdater) Supports(fileExtension string) bool {
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
+}
+
+func (u *JsonFileUpdater) GetSupportedExtensions() []string {
+	extensions := make([]string, 0, len(u.supportedFileExtensions))
+	for ext := range u.supportedFileExtensions {
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+func (u *JsonFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot read file %s: %w", filePath, err)
+	}
+
+	// Files without a sidecar are not annotated
+	annotations, err := u.readSidecar(filePath + jsonSidecarSuffix)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(annotations) == 0 {
+		return string(content), nil, nil
+	}
+
+	// Apply pointers in a stable order so the change list is deterministic
+	pointers := make([]string, 0, len(annotations))
+	for pointer := range annotations {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+
+	var changes []Change
+	var matched bool
+	for _, pointer := range pointers {
+		start, end, err := findJSONPointer(content, pointer)
+		if err != nil {
+			return "", nil, fmt.Errorf("cannot resolve %s in %s: %w", pointer, filePath, err)
+		}
+
+		if hasPackage(packages, annotations[pointer]) {
+			matched = true
+		}
+
+		updatedValue, change := u.updateValue(content[start:end], annotations[pointer], packages, options.CanonicalVersions)
+		if change == nil {
+			continue
+		}
+
+		change.File = filePath
+		change.Line = bytes.Count(content[:start], []byte("\n")) + 1
+		changes = append(changes, *change)
+
+		// Splice the new value in, leaving all surrounding bytes untouched
+		content = append(content[:start:start], append(updatedValue, content[end:]...)...)
+	}
+
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && matched) {
+		err = os.WriteFile(filePath, content, 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return string(content), changes, nil
+}
+
+// readSidecar reads the pointer to package name mapping
+// Returns an empty mapping if the sidecar doesn't exist
+func (u *JsonFileUpdater) readSidecar(sidecarPath string) (map[string]string, error) {
+	content, err := os.ReadFile(sidecarPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sidecar %s: %w", sidecarPath, err)
+	}
+
+	annotations := map[string]string{}
+	if err := yaml.Unmarshal(content, &annotations); err != nil {
+		return nil, fmt.Errorf("invalid sidecar %s: %w", sidecarPath, err)
+	}
+
+	return annotations, nil
+}
+
+// updateValue replaces the version inside a raw JSON string value if the package matches
+func (u *JsonFileUpdater) updateValue(rawValue []byte, packageName string, packages []Package, canonical bool) ([]byte, *Change) {
+	for _, pkg := range packages {
+		if pkg.Name == packageName {
+			return replaceJSONVersion(rawValue, pkg, canonical)
+		}
+	}
+
+	return rawValue, nil
+}
+
+// replaceJSONVersion replaces the version inside a raw JSON string value with the package version
+// Only the version itself is replaced, keeping quotes and any surrounding text such as range operators or a "v"
+func replaceJSONVersion(rawValue []byte, pkg Package, canonical bool) ([]byte, *Change) {
+	// Only string values can hold a version
+	if len(rawValue) < 2 || rawValue[0] != '"' {
+		return rawValue, nil
+	}
+
+	value := string(rawValue)
+	versionMatches := versionPattern.FindStringSubmatchIndex(value)
+	if versionMatches == nil {
+		return rawValue, nil
+	}
+
+	versionStart, versionEnd := versionMatches[3], versionMatches[14]
+	currentVersion := value[versionStart:versionEnd]
+	targetVersion := trimVPrefix(pkg.Version)
+	if versionsEqual(currentVersion, targetVersion, canonical) {
+		return rawValue, nil
+	}
+
+	updatedValue := value[:versionStart] + targetVersion + value[versionEnd:]
+	return []byte(updatedValue), &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+}
+
+// findJSONPointer returns the byte range of the value referenced by an RFC 6901 JSON pointer
+func findJSONPointer(data []byte, pointer string) (int, int, error) {
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return 0, 0, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	start := skipJSONWhitespace(data, 0)
+	end, err := scanJSONValue(data, start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if pointer == "" {
+		return start, end, nil
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch data[start] {
+		case '{':
+			start, end, err = findJSONObjectMember(data, start, token)
+		case '[':
+			start, end, err = findJSONArrayElement(data, start, token)
+		default:
+			err = fmt.Errorf("cannot descend into scalar value with %q: %w", token, errJSONTypeMismatch)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return start, end, nil
+}
+
+// findJSONObjectMember returns the value range of the member with the given key
+func findJSONObjectMember(data []byte, pos int, key string) (int, int, error) {
+	pos = skipJSONWhitespace(data, pos+1)
+	for pos < len(data) && data[pos] != '}' {
+		keyEnd, err := scanJSONString(data, pos)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var memberKey string
+		if err := json.Unmarshal(data[pos:keyEnd], &memberKey); err != nil {
+			return 0, 0, err
+		}
+
+		pos = skipJSONWhitespace(data, keyEnd)
+		if pos >= len(data) || data[pos] != ':' {
+			return 0, 0, fmt.Errorf("expected ':' at offset %d", pos)
+		}
+
+		valueStart := skipJSONWhitespace(data, pos+1)
+		valueEnd, err := scanJSONValue(data, valueStart)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if memberKey == key {
+			return valueStart, valueEnd, nil
+		}
+
+		pos = skipJSONWhitespace(data, valueEnd)
+		if pos < len(data) && data[pos] == ',' {
+			pos = skipJSONWhitespace(data, pos+1)
+		}
+	}
+
+	return 0, 0, fmt.Errorf("key %q %w", key, errJSONNotFound)
+}
+
+// findJSONArrayElement returns the value range of the element at the given index
+func findJSONArrayElement(data []byte, pos int, token string) (int, int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, 0, fmt.Errorf("invalid array index %q: %w", token, errJSONTypeMismatch)
+	}
+
+	pos = skipJSONWhitespace(data, pos+1)
+	for i := 0; pos < len(data) && data[pos] != ']'; i++ {
+		valueEnd, err := scanJSONValue(data, pos)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if i == index {
+			return pos, valueEnd, nil
+		}
+
+		pos = skipJSONWhitespace(data, valueEnd)
+		if pos < len(data) && data[pos] == ',' {
+			pos = skipJSONWhitespace(data, pos+1)
+		}
+	}
+
+	return 0, 0, fmt.Errorf("array index %d %w", index, errJSONNotFound)
+}
+
+// scanJSONValue returns the offset right after the JSON value starting at pos
+func scanJSONValue(data []byte, pos int) (int, error) {
+	if pos >= len(data) {
+		return 0, fmt.Errorf("unexpected end of JSON input")
+	}
+
+	switch data[pos] {
+	case '"':
+		return scanJSONString(data, pos)
+	case '{', '[':
+		// Let the standard decoder find the end of nested structures
+		decoder := json.NewDecoder(bytes.NewReader(data[pos:]))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return 0, err
+		}
+		return pos + int(decoder.InputOffset()), nil
+	default:
+		end := pos
+		for end < len(data) && !strings.ContainsRune(",}] \t\r\n", rune(data[end])) {
+			end++
+		}
+		return end, nil
+	}
+}
+
+// scanJSONString returns the offset right after the JSON string starting at pos
+func scanJSONString(data []byte, pos int) (int, error) {
+	if pos >= len(data) || data[pos] != '"' {
+		return 0, fmt.Errorf("expected string at offset %d", pos)
+	}
+
+	for i := pos + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated string at offset %d", pos)
+}
+
+// skipJSONWhitespace returns the offset of the next non-whitespace byte
+func skipJSONWhitespace(data []byte, pos int) int {
+	for pos < len(data) && strings.ContainsRune(" \t\r\n", rune(data[pos])) {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,176 @@
+package updater
+
+import (
+	"os"
+	"testing"
+)
+
+func TestJsonFileUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		sidecarContent  string
+		packages        []Package
+		options         FileUpdaterOptions
+		expectedOutput  string
+		expectedChanges []Change
+		expectError     bool
+	}{
+		{
+			name:            "Nested pointer",
+			fileContent:     "{\n  \"services\": {\n    \"api\": {\n      \"version\": \"1.0.0\"\n    }\n  }\n}\n",
+			sidecarContent:  "/services/api/version: api\n",
+			packages:        []Package{{Name: "api", Version: "2.0.0"}},
+			options:         FileUpdaterOptions{DryRun: false},
+			expectedOutput:  "{\n  \"services\": {\n    \"api\": {\n      \"version\": \"2.0.0\"\n    }\n  }\n}\n",
+			expectedChanges: []Change{{Line: 4, Package: "api", OldVersion: "1.0.0", NewVersion: "2.0.0"}},
+			expectError:     false,
+		},
+		{
+			name:            "Array element by index",
+			fileContent:     `{"images": ["nginx:1.25.0", "company/app:1.0.0-rc.1"]}`,
+			sidecarContent:  "/images/1: app\n",
+			packages:        []Package{{Name: "app", Version: "1.0.0"}},
+			options:         FileUpdaterOptions{DryRun: false},
+			expectedOutput:  `{"images": ["nginx:1.25.0", "company/app:1.0.0"]}`,
+			expectedChanges: []Change{{Line: 1, Package: "app", OldVersion: "1.0.0-rc.1", NewVersion: "1.0.0"}},
+			expectError:     false,
+		},
+		{
+			name:            "Pointer into object inside array",
+			fileContent:     "[\n  {\"name\": \"db\", \"tag\": \"14.6.0\"},\n  {\"name\": \"cache\", \"tag\": \"7.0.0\"}\n]\n",
+			sidecarContent:  "/0/tag: postgres\n/1/tag: redis\n",
+			packages:        []Package{{Name: "redis", Version: "7.2.0"}, {Name: "postgres", Version: "15.4.0"}},
+			options:         FileUpdaterOptions{DryRun: false},
+			expectedOutput:  "[\n  {\"name\": \"db\", \"tag\": \"15.4.0\"},\n  {\"name\": \"cache\", \"tag\": \"7.2.0\"}\n]\n",
+			expectedChanges: []Change{{Line: 2, Package: "postgres", OldVersion: "14.6.0", NewVersion: "15.4.0"}, {Line: 3, Package: "redis", OldVersion: "7.0.0", NewVersion: "7.2.0"}},
+			expectError:     false,
+		},
+		{
+			name:            "Escaped pointer tokens",
+			fileContent:     `{"a/b": {"m~n": "1.0.0"}}`,
+			sidecarContent:  "/a~1b/m~0n: pkg\n",
+			packages:        []Package{{Name: "pkg", Version: "1.1.0"}},
+			options:         FileUpdaterOptions{DryRun: false},
+			expectedOutput:  `{"a/b": {"m~n": "1.1.0"}}`,
+			expectedChanges: []Change{{Line: 1, Package: "pkg", OldVersion: "1.0.0", NewVersion: "1.1.0"}},
+			expectError:     false,
+		},
+		{
+			name:            "No sidecar",
+			fileContent:     `{"version": "1.0.0"}`,
+			packages:        []Package{{Name: "pkg", Version: "2.0.0"}},
+			options:         FileUpdaterOptions{DryRun: false},
+			expectedOutput:  `{"version": "1.0.0"}`,
+			expectedChanges: nil,
+			expectError:     false,
+		},
+		{
+			name:            "Dry run mode",
+			fileContent:     `{"version": "1.0.0"}`,
+			sidecarContent:  "/version: pkg\n",
+			packages:        []Package{{Name: "pkg", Version: "2.0.0"}},
+			options:         FileUpdaterOptions{DryRun: true},
+			expectedOutput:  `{"version": "2.0.0"}`,
+			expectedChanges: []Change{{Line: 1, Package: "pkg", OldVersion: "1.0.0", NewVersion: "2.0.0"}},
+			expectError:     false,
+		},
+		{
+			name:           "Array index out of range",
+			fileContent:    `{"images": ["app:1.0.0"]}`,
+			sidecarContent: "/images/3: app\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectError:    true,
+		},
+		{
+			name:           "Missing key",
+			fileContent:    `{"version": "1.0.0"}`,
+			sidecarContent: "/services/version: app\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temp file with test content
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".json")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			if tt.sidecarContent != "" {
+				sidecar := tempFile + jsonSidecarSuffix
+				if err := os.WriteFile(sidecar, []byte(tt.sidecarContent), 0644); err != nil {
+					t.Fatalf("Failed to create sidecar: %v", err)
+				}
+				defer os.Remove(sidecar)
+			}
+
+			updater := NewJsonFileUpdater()
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if tt.expectError {
+				return
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+			for i, expected := range tt.expectedChanges {
+				expected.File = tempFile
+				if changes[i] != expected {
+					t.Errorf("UpdateFile() change[%d] = %+v, expected %+v", i, changes[i], expected)
+				}
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read temp file: %v", err)
+			}
+			expectedContent := tt.expectedOutput
+			if tt.options.DryRun {
+				expectedContent = tt.fileContent
+			}
+			if string(content) != expectedContent {
+				t.Errorf("File content = %q, expected %q", string(content), expectedContent)
+			}
+		})
+	}
+}
+
+func TestJsonFileUpdater_Supports(t *testing.T) {
+	updater := NewJsonFileUpdater()
+
+	tests := []struct {
+		extension string
+		expected  bool
+	}{
+		{".json", true},
+		{".tf.json", true},
+		{".tfvars.json", true},
+		{".yaml", false},
+		{".txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.extension, func(t *testing.T) {
+			result := updater.Supports(tt.extension)
+			if result != tt.expected {
+				t.Errorf("Supports(%q) = %v, expected %v", tt.extension, result, tt.expected)
+			}
+		})
+	}
+}
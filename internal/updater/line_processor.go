@@ -0,0 +1,592 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// newCommentPattern builds the pattern matching a depup comment started by the given comment prefix
+// Both the "depup package=NAME" and the "depup:package=NAME" directive styles are accepted
+// The first capture group holds the package name
+func newCommentPattern(commentPrefix string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(commentPrefix) + `\s*depup(?:\s*:\s*|\s+)package=([^\s]+)`)
+}
+
+// commentSyntax recognizes depup comments started by any of the comment prefixes of a file format
+type commentSyntax struct {
+	prefixes []string         // Comment prefixes in order of precedence, the first one starts written comments
+	patterns []*regexp.Regexp // Depup comment pattern per prefix
+	inline   []*regexp.Regexp // Pattern per prefix splitting a line into its content and a trailing comment
+	custom   *regexp.Regexp   // User supplied pattern replacing the depup comment patterns, nil for the built-in syntax
+}
+
+// newCommentSyntax builds the comment syntax of a file format with the given comment prefixes
+func newCommentSyntax(prefixes ...string) commentSyntax {
+	c := commentSyntax{prefixes: prefixes}
+	for _, prefix := range prefixes {
+		c.patterns = append(c.patterns, newCommentPattern(prefix))
+		c.inline = append(c.inline, regexp.MustCompile(`(.*?)(\s*`+regexp.QuoteMeta(prefix)+`.*)$`))
+	}
+	return c
+}
+
+// withPrefixes returns the syntax with other comment prefixes, keeping a custom pattern
+func (c commentSyntax) withPrefixes(prefixes []string) commentSyntax {
+	updated := newCommentSyntax(prefixes...)
+	updated.custom = c.custom
+	return updated
+}
+
+// withPattern returns the syntax recognizing depup comments by a custom pattern instead of the built-in syntax
+// The first capture group of the pattern holds the package name, attributes may follow the match.
+func (c commentSyntax) withPattern(pattern *regexp.Regexp) commentSyntax {
+	c.custom = pattern
+	return c
+}
+
+// parse returns the directive of a depup comment started by any of the prefixes found in the line
+func (c commentSyntax) parse(line string) (directive, bool) {
+	if c.custom != nil {
+		return parseDirective(c.custom, line)
+	}
+	for _, pattern := range c.patterns {
+		if d, ok := parseDirective(pattern, line); ok {
+			return d, true
+		}
+	}
+	return directive{}, false
+}
+
+// inlineSplits returns the ways to split a line into its content and a trailing comment, in order of precedence
+// The whitespace in front of the comment belongs to the comment, so content + comment restores the line byte for byte.
+// Prefixes are tried in order, so "#" wins over a "//" found earlier in the line, e.g. in a URL.
+// With a custom pattern, the comment starts at the whitespace in front of its match and ends the line.
+func (c commentSyntax) inlineSplits(line string) [][2]string {
+	if c.custom != nil {
+		location := c.custom.FindStringIndex(line)
+		if location == nil {
+			return nil
+		}
+		start := location[0]
+		for start > 0 && (line[start-1] == ' ' || line[start-1] == '\t') {
+			start--
+		}
+		// A match in a comment on its own line, e.g. "# @dep{app}", is no inline comment
+		if c.isCommentLine(line[:start]) {
+			return nil
+		}
+		return [][2]string{{line[:start], line[start:]}}
+	}
+
+	var splits [][2]string
+	for _, inline := range c.inline {
+		if matches := inline.FindStringSubmatch(line); len(matches) > 2 {
+			splits = append(splits, [2]string{matches[1], matches[2]})
+		}
+	}
+	return splits
+}
+
+// splitInlineComment splits a line into its content and a trailing depup comment
+// It reports false if no depup comment follows non-empty content.
+func (c commentSyntax) splitInlineComment(line string) (string, string, directive, bool) {
+	for _, split := range c.inlineSplits(line) {
+		if strings.TrimSpace(split[0]) == "" {
+			continue
+		}
+		if d, ok := c.parse(split[1]); ok {
+			return split[0], split[1], d, true
+		}
+	}
+	return "", "", directive{}, false
+}
+
+// isCommentLine reports whether the line holds nothing but a comment
+func (c commentSyntax) isCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// depupComment returns a depup comment for the package started by the first prefix, written by Annotate
+func (c commentSyntax) depupComment(packageName string) string {
+	return c.prefixes[0] + " depup package=" + packageName
+}
+
+// directiveAttributePattern matches a single KEY=VALUE attribute following the package of a depup comment
+var /* const */ directiveAttributePattern = regexp.MustCompile(`^\s+([a-zA-Z][\w-]*)=([^\s]*)`)
+
+// packageAttribute is the attribute naming the annotated package, repeated or comma separated for several packages
+const packageAttribute = "package"
+
+// directive is a parsed depup comment
+type directive struct {
+	packageName  string            // Name of the annotated package, the first one if the comment names several
+	packageNames []string          // All packages of a comment naming several, nil if it names a single package
+	attributes   map[string]string // Additional KEY=VALUE attributes, e.g. scheme=partial
+}
+
+// parseDirective parses a depup comment matched by the comment pattern
+// Attributes must directly follow the package, separated by whitespace:
+//
+//	# depup package=aws scheme=partial
+//
+// Several packages are named with a comma separated list or repeated package attributes, e.g. package=app,sidecar
+func parseDirective(commentPattern *regexp.Regexp, line string) (directive, bool) {
+	location := commentPattern.FindStringSubmatchIndex(line)
+	// A custom pattern may match without its package group participating, e.g. an optional group
+	if location == nil || location[2] < 0 {
+		return directive{}, false
+	}
+
+	d := directive{attributes: map[string]string{}}
+	names := splitPackageNames(nil, line[location[2]:location[3]])
+	rest := line[location[1]:]
+	for {
+		attributeMatches := directiveAttributePattern.FindStringSubmatch(rest)
+		if attributeMatches == nil {
+			break
+		}
+		if attributeMatches[1] == packageAttribute {
+			names = splitPackageNames(names, attributeMatches[2])
+		} else {
+			d.attributes[attributeMatches[1]] = attributeMatches[2]
+		}
+		rest = rest[len(attributeMatches[0]):]
+	}
+	// A replace template without the placeholder would write the same value whatever the version, so the
+	// comment is malformed rather than an update that can never be verified
+	if template, ok := d.attributes[replaceAttribute]; ok && !strings.Contains(template, versionPlaceholder) {
+		return directive{}, false
+	}
+
+	if len(names) == 0 {
+		return directive{}, false
+	}
+	d.packageName = names[0]
+	if len(names) > 1 {
+		d.packageNames = names
+	}
+
+	return d, true
+}
+
+// splitPackageNames appends the non-empty names of a comma separated package list to names
+func splitPackageNames(names []string, list string) []string {
+	for _, name := range strings.Split(list, ",") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// updatePackages runs update, which handles a single package, for every package of the directive
+// The first package updates the first version of the content, each further package the next version
+// after it, so app:1.0.0 sidecar:2.0.0 annotated with package=app,sidecar updates both versions.
+// The results of further packages are added to the result of the first package.
+func updatePackages(content string, d directive, defaultScheme string, leadingZeros bool, update func(content string, d directive) lineResult) lineResult {
+	if len(d.packageNames) == 0 {
+		return update(content, d)
+	}
+
+	scheme, ok := lookupScheme(d, defaultScheme, leadingZeros)
+	if !ok {
+		return lineResult{line: content, packageName: d.packageName}
+	}
+
+	var result lineResult
+	line, offset := content, 0
+	for i, name := range d.packageNames {
+		single := d
+		single.packageName, single.packageNames = name, nil
+
+		// Naming the packages in order disambiguates the versions of the line
+		packageResult := update(line[offset:], single)
+		packageResult.matches = 1
+		line = line[:offset] + packageResult.line
+		if i == 0 {
+			result = packageResult
+		} else {
+			result.more = append(result.more, packageResult)
+		}
+
+		// Continue after the version of this package, which may have been rewritten
+		match, ok := scheme.find(line[offset:])
+		if !ok {
+			break
+		}
+		offset += strings.Index(line[offset:], match.text) + len(match.text)
+	}
+	result.line = line
+
+	return result
+}
+
+// replaceAttribute is the directive attribute holding a template for the whole value, e.g. replace=newrepo/app:{version}
+const replaceAttribute = "replace"
+
+// versionPlaceholder is substituted with the target version in replace templates
+const versionPlaceholder = "{version}"
+
+// renderReplaceTemplate substitutes the target version in a replace template
+func renderReplaceTemplate(template, version string) string {
+	return strings.ReplaceAll(template, versionPlaceholder, version)
+}
+
+// fromAttribute is the directive attribute guarding an update, e.g. from=1.2.3 only updates the line if it holds 1.2.3
+const fromAttribute = "from"
+
+// checkFromGuard returns a skip if the directive has a from= guard that doesn't match the current version
+// Lines of packages that weren't supplied or already hold the target version are never reported
+func checkFromGuard(d directive, packages []Package, currentVersion string) *Skip {
+	from, ok := d.attributes[fromAttribute]
+	if !ok || currentVersion == from {
+		return nil
+	}
+
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName && pkg.Version != currentVersion {
+			return &Skip{
+				Package: pkg.Name,
+				Version: currentVersion,
+				Reason:  fmt.Sprintf("current version %s doesn't match from=%s", currentVersion, from),
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDowngrade returns a skip if the scheme refuses downgrades and the directive's package is older
+// than the current version
+func checkDowngrade(d directive, packages []Package, scheme versionScheme, currentVersion string) *Skip {
+	if scheme.compare == nil {
+		return nil
+	}
+
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName && scheme.compare(pkg.Version, currentVersion) < 0 {
+			return &Skip{
+				Package: pkg.Name,
+				Version: currentVersion,
+				Reason:  fmt.Sprintf("version %s is lower than the current version %s", pkg.Version, currentVersion),
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceMatchedValue replaces the value containing the matched version, keeping its quotes
+// The value extends from the version to the nearest whitespace or quote on either side,
+// e.g. oldrepo/app:1.0.0 in "image: oldrepo/app:1.0.0"
+func replaceMatchedValue(line string, match versionMatch, value string) (string, bool) {
+	index := strings.Index(line, match.text)
+	start := index + len(match.startQuote)
+	end := index + len(match.text) - len(match.endQuote)
+	for start > 0 && !strings.ContainsRune(" \t\"'", rune(line[start-1])) {
+		start--
+	}
+	for end < len(line) && !strings.ContainsRune(" \t\"'", rune(line[end])) {
+		end++
+	}
+
+	if line[start:end] == value {
+		return line, false
+	}
+
+	return line[:start] + value + line[end:], true
+}
+
+// depupLikePattern matches comments that look like a depup annotation, parseable or not
+var /* const */ depupLikePattern = regexp.MustCompile(`(#|//)\s*depup\b`)
+
+// lineResult is the outcome of matching depup annotations against a single line
+type lineResult struct {
+	line        string  // Resulting line content
+	original    string  // Line content before processing, set by processLines
+	packageName string  // Package the line is annotated with, empty if no annotation applies
+	version     string  // Version found on the annotated line
+	change      *Change // Applied change, nil if the line is unchanged
+	skip        *Skip   // Set if a guard of the depup comment prevented the update
+	comment     int     // Index of the line holding the depup comment, set by processLines
+	matches     int     // Number of versions the annotated content holds, only the first of them is updated
+
+	more []lineResult // Results of the further packages of a depup comment naming several packages
+}
+
+// changed reports whether the line was changed for any package
+func (r lineResult) changed() bool {
+	if r.change != nil {
+		return true
+	}
+	for _, more := range r.more {
+		if more.change != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// lineProcessor is implemented by the updaters of line-based file formats
+type lineProcessor interface {
+	// parseDepupComment returns the directive of a depup comment found in the line
+	parseDepupComment(line string) (directive, bool)
+
+	// processInlineDepupComment handles a depup comment on the same line as the version
+	processInlineDepupComment(line string, packages []Package) lineResult
+
+	// processSeparateLineDepupComment handles a depup comment on its own line above or below the version
+	processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult
+}
+
+// blockScalarProcessor is implemented by line processors of formats where a value can span the following lines
+type blockScalarProcessor interface {
+	// blockScalarLines returns the number of lines following the line that belong to its value,
+	// e.g. the indented lines of a YAML block scalar, or 0 if the value ends on the line
+	blockScalarLines(lines []string, index int) int
+}
+
+// nestedVersionProcessor is implemented by line processors of formats where the version of an annotated key
+// can be held by a nested child, e.g. the tag of a YAML mapping
+type nestedVersionProcessor interface {
+	// nestedVersionLine returns the index of the line below the line holding the version of its value,
+	// or -1 if there is none
+	nestedVersionLine(lines []string, index int) int
+}
+
+// heredocProcessor is implemented by line processors of formats with heredocs, whose text is left alone
+type heredocProcessor interface {
+	// heredocLines reports for each line whether it belongs to the body of a heredoc, including its end marker
+	heredocLines(lines []string) []bool
+}
+
+// Positions of depup comments on their own line, relative to the version they annotate
+const (
+	CommentPositionAbove = "above" // The comment is on the line before the version
+	CommentPositionBelow = "below" // The comment is on the line after the version
+)
+
+// processLines runs the processor over all lines and returns the result for each line
+// Comments on their own line annotate the version at the given position, empty selects CommentPositionAbove.
+// Only one position is considered, so a comment between two versions never applies twice.
+// If the annotated line starts a block scalar without a version, the first version within the block is annotated.
+// Otherwise, processors implementing nestedVersionProcessor may name a line below holding the version.
+// Lines in the body of a heredoc of a heredocProcessor are never updated, even if a depup comment annotates them.
+func processLines(p lineProcessor, lines []string, packages []Package, position string) []lineResult {
+	results := make([]lineResult, len(lines))
+
+	var heredoc []bool
+	if h, ok := p.(heredocProcessor); ok {
+		heredoc = h.heredocLines(lines)
+	}
+
+	// Annotation of a block scalar or nested version whose version hasn't been found yet
+	// Lines from blockStart to blockEnd are searched for the version.
+	blockHeader, blockStart, blockEnd, blockComment, blockCommentLine := -1, -1, -1, "", -1
+
+	for i, currentLine := range lines {
+		// Keep the text of heredocs as it is
+		if heredoc != nil && heredoc[i] {
+			results[i] = lineResult{line: currentLine, original: currentLine, comment: i}
+			continue
+		}
+
+		// Check for inline depup comment
+		result := p.processInlineDepupComment(currentLine, packages)
+		result.comment = i
+
+		// Find the comment line annotating this line, if any
+		commentIndex := i - 1
+		if position == CommentPositionBelow {
+			commentIndex = i + 1
+		}
+
+		// Check for depup comment on its own line, unless the line has an inline comment
+		// A skip of the inline comment, e.g. by a from= guard, is final and not overridden by the comment line
+		if result.packageName == "" && result.skip == nil && commentIndex >= 0 && commentIndex < len(lines) {
+			result = p.processSeparateLineDepupComment(lines[commentIndex], currentLine, packages)
+			result.comment = commentIndex
+		}
+
+		// Look for the version of an annotated block scalar within the block
+		if i > blockEnd {
+			blockHeader = -1
+		}
+		if blockHeader >= 0 && i >= blockStart && result.packageName == "" {
+			if blockResult := p.processSeparateLineDepupComment(blockComment, currentLine, packages); blockResult.version != "" {
+				result = blockResult
+				result.comment = blockCommentLine
+				results[blockHeader].packageName = ""
+				blockHeader = -1
+			}
+		}
+		if result.packageName != "" && result.version == "" {
+			start, end := -1, -1
+			if block, ok := p.(blockScalarProcessor); ok {
+				if blockLines := block.blockScalarLines(lines, i); blockLines > 0 {
+					start, end = i+1, i+blockLines
+				}
+			}
+			if nested, ok := p.(nestedVersionProcessor); ok && start < 0 {
+				if line := nested.nestedVersionLine(lines, i); line > i {
+					start, end = line, line
+				}
+			}
+
+			if start >= 0 {
+				// The depup comment is either inline or on its own line next to the annotated line
+				blockHeader, blockStart, blockEnd, blockComment, blockCommentLine = i, start, end, currentLine, i
+				if _, inline := p.parseDepupComment(currentLine); !inline {
+					blockComment, blockCommentLine = lines[commentIndex], commentIndex
+				}
+			}
+		}
+
+		// Record the 1-based line number of the changes
+		for _, packageResult := range append([]lineResult{result}, result.more...) {
+			if packageResult.change != nil {
+				packageResult.change.Line = i + 1
+			}
+			if packageResult.skip != nil {
+				packageResult.skip.Line = i + 1
+			}
+		}
+
+		result.original = currentLine
+		results[i] = result
+	}
+
+	return results
+}
+
+// collectResults returns the output lines and the changes from the line results
+// Skipped versions are passed to the OnSkip callback of the options. With ErrorOnMultipleMatches,
+// an error is returned if a change was made to a line holding several versions.
+func collectResults(results []lineResult, filePath string, options FileUpdaterOptions) ([]string, []Change, error) {
+	output := make([]string, 0, len(results))
+	var changes []Change
+	quarantined := map[int]bool{}
+
+	for i, result := range results {
+		output = append(output, result.line)
+		for _, packageResult := range append([]lineResult{result}, result.more...) {
+			if packageResult.change != nil && packageResult.matches > 1 {
+				// Quarantined lines are left unchanged and reported like lines a guard kept unchanged
+				if options.QuarantineOnConflict {
+					output[i] = result.original
+					quarantined[i] = true
+					options.reportSkip(Skip{
+						File:    filePath,
+						Line:    i + 1,
+						Package: packageResult.packageName,
+						Version: packageResult.change.OldVersion,
+						Reason:  fmt.Sprintf("line holds %d versions for the package", packageResult.matches),
+					})
+					continue
+				}
+				if options.ErrorOnMultipleMatches {
+					return nil, nil, fmt.Errorf("line %d of %s holds %d versions for package %s, annotate them separately or name a package for each",
+						i+1, filePath, packageResult.matches, packageResult.packageName)
+				}
+			}
+			if packageResult.change != nil {
+				packageResult.change.File = filePath
+				changes = append(changes, *packageResult.change)
+			}
+			if packageResult.skip != nil {
+				packageResult.skip.File = filePath
+				options.reportSkip(*packageResult.skip)
+			}
+		}
+	}
+
+	// Keep the version recorded in depup comments in sync, which is ambiguous for comments naming several packages
+	if options.SyncCommentVersion {
+		for i, result := range results {
+			if result.change != nil && len(result.more) == 0 && !quarantined[i] {
+				output[result.comment] = syncCommentVersion(output[result.comment], result.change.NewVersion)
+			}
+		}
+	}
+
+	return output, changes, nil
+}
+
+// versionAttribute is the directive attribute recording the expected version, e.g. # depup package=app version=1.2.3
+const versionAttribute = "version"
+
+// directiveStartPattern matches the start of a depup directive, regardless of the comment prefix
+var /* const */ directiveStartPattern = regexp.MustCompile(`depup(?:\s*:\s*|\s+)package=`)
+
+// directiveVersionPattern matches the version attribute of a depup directive, the second group holds the version
+var /* const */ directiveVersionPattern = regexp.MustCompile(`(\s` + versionAttribute + `=)([^\s]*)`)
+
+// syncCommentVersion sets the version attribute of the depup comment in the line to the version
+// The comment keeps its "v" style, and lines whose comment has no version attribute are returned unchanged.
+func syncCommentVersion(line, version string) string {
+	locations := directiveStartPattern.FindAllStringIndex(line, -1)
+	if locations == nil {
+		return line
+	}
+
+	start := locations[len(locations)-1][1]
+	versionMatches := directiveVersionPattern.FindStringSubmatchIndex(line[start:])
+	if versionMatches == nil {
+		return line
+	}
+
+	current := line[start+versionMatches[4] : start+versionMatches[5]]
+	return line[:start+versionMatches[4]] + vPrefix(current) + trimVPrefix(version) + line[start+versionMatches[5]:]
+}
+
+// hasAnnotatedVersion reports whether any line holds a version annotated with one of the packages
+func hasAnnotatedVersion(results []lineResult, packages []Package) bool {
+	for _, result := range results {
+		if result.version != "" && hasPackage(packages, result.packageName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// analyzeLines describes how the processor interprets every line
+// Lines annotated with several packages are described once per package
+func analyzeLines(p lineProcessor, lines []string, packages []Package, position string) []LineAnalysis {
+	results := processLines(p, lines, packages, position)
+	analysis := make([]LineAnalysis, 0, len(lines))
+
+	for i, result := range results {
+		annotation, isAnnotation := p.parseDepupComment(lines[i])
+		annotationName := annotation.packageName
+		if len(annotation.packageNames) > 0 {
+			annotationName = strings.Join(annotation.packageNames, ",")
+		}
+
+		for j, packageResult := range append([]lineResult{result}, result.more...) {
+			lineAnalysis := LineAnalysis{
+				Line:    i + 1,
+				Content: lines[i],
+				Package: packageResult.packageName,
+				Version: packageResult.version,
+			}
+			if j == 0 {
+				lineAnalysis.Annotation = annotationName
+				lineAnalysis.Malformed = !isAnnotation && depupLikePattern.MatchString(lines[i])
+			}
+			if packageResult.change != nil {
+				lineAnalysis.NewVersion = packageResult.change.NewVersion
+			}
+			analysis = append(analysis, lineAnalysis)
+		}
+	}
+
+	return analysis
+}
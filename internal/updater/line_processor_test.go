@@ -0,0 +1,61 @@
+package updater
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestProcessLines_InlineCommentSpacing checks that the whitespace in front of an inline comment is kept byte for byte
+func TestProcessLines_InlineCommentSpacing(t *testing.T) {
+	updaters := []struct {
+		name     string
+		updater  func() lineProcessor
+		line     string // Line with %s in place of the whitespace in front of the comment
+		expected string
+	}{
+		{name: "yaml", updater: func() lineProcessor { return NewYamlFileUpdater() }, line: "image: app:1.0.0%s# depup package=app", expected: "image: app:2.0.0%s# depup package=app"},
+		{name: "hcl hash", updater: func() lineProcessor { return NewHclFileUpdater() }, line: `version = "1.0.0"%s# depup package=app`, expected: `version = "2.0.0"%s# depup package=app`},
+		{name: "hcl slashes", updater: func() lineProcessor { return NewHclFileUpdater() }, line: `version = "1.0.0"%s// depup package=app`, expected: `version = "2.0.0"%s// depup package=app`},
+		{name: "dotenv", updater: func() lineProcessor { return NewDotEnvFileUpdater() }, line: "APP_VERSION=1.0.0%s# depup package=app", expected: "APP_VERSION=2.0.0%s# depup package=app"},
+		{name: "template", updater: func() lineProcessor { return NewTemplateFileUpdater() }, line: "image: {{ registry }}/app:1.0.0%s# depup package=app", expected: "image: {{ registry }}/app:2.0.0%s# depup package=app"},
+		{name: "gemfile", updater: func() lineProcessor { return NewGemfileUpdater() }, line: `gem "app", "1.0.0"%s# depup package=app`, expected: `gem "app", "2.0.0"%s# depup package=app`},
+		{name: "conf", updater: func() lineProcessor { return NewConfFileUpdater() }, line: "set $app_version 1.0.0;%s# depup package=app", expected: "set $app_version 2.0.0;%s# depup package=app"},
+		{name: "bazel", updater: func() lineProcessor { return NewBazelUpdater() }, line: `bazel_dep(name = "app", version = "1.0.0")%s# depup package=app`, expected: `bazel_dep(name = "app", version = "2.0.0")%s# depup package=app`},
+		{
+			name: "custom pattern",
+			updater: func() lineProcessor {
+				u := NewYamlFileUpdater()
+				u.setDepupComments(u.depupComments().withPattern(regexp.MustCompile(`@dep\{([^}]+)\}`)))
+				return u
+			},
+			line:     "image: app:1.0.0%s# @dep{app}",
+			expected: "image: app:2.0.0%s# @dep{app}",
+		},
+	}
+	spacings := map[string]string{
+		"single space":    " ",
+		"several spaces":  "     ",
+		"tab":             "\t",
+		"tab and spaces":  " \t  ",
+		"trailing spaces": "  ",
+	}
+
+	for _, tt := range updaters {
+		for spacingName, spacing := range spacings {
+			t.Run(tt.name+"/"+spacingName, func(t *testing.T) {
+				line := strings.Replace(tt.line, "%s", spacing, 1)
+				expected := strings.Replace(tt.expected, "%s", spacing, 1)
+				if spacingName == "trailing spaces" {
+					line += "   "
+					expected += "   "
+				}
+
+				results := processLines(tt.updater(), []string{line}, []Package{{Name: "app", Version: "2.0.0"}}, CommentPositionAbove)
+				if results[0].line != expected {
+					t.Errorf("processLines() = %q, expected %q", results[0].line, expected)
+				}
+			})
+		}
+	}
+}
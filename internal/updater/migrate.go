@@ -0,0 +1,134 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// RenamedComment is a depup comment whose package was renamed by Migrate
+type RenamedComment struct {
+	File    string // Absolute path of the file
+	Line    int    // 1-based line number of the comment
+	Content string // Content of the line after renaming
+}
+
+// directivePackagesPattern matches the package list following the start of a depup directive
+var /* const */ directivePackagesPattern = regexp.MustCompile(`^[^\s]*`)
+
+// Migrate renames the package from to the package to in the depup comments of the files of the entrypoint
+// Only package names are rewritten, versions and other attributes stay as they are. Files without depup
+// comments, like JSON files annotated by a sidecar, are left alone.
+func (u *Updater) Migrate(entrypoint, from, to string) ([]RenamedComment, error) {
+	files, err := u.Discover(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var renamed []RenamedComment
+	for _, file := range files {
+		fileRenamed, err := u.migrateFile(file, from, to)
+		if err != nil {
+			return renamed, err
+		}
+		renamed = append(renamed, fileRenamed...)
+	}
+
+	return renamed, nil
+}
+
+// migrateFile renames the package in the depup comments of a single file
+func (u *Updater) migrateFile(file, from, to string) ([]RenamedComment, error) {
+	updater, err := u.getFileUpdaterForPath(file)
+	if err != nil {
+		return nil, err
+	}
+	processor, ok := updater.(lineProcessor)
+	if !ok {
+		return nil, nil
+	}
+
+	unlock := u.fileLocks.lock(file)
+	defer unlock()
+
+	lines, format, err := readFileLines(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var renamed []RenamedComment
+	output := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if d, ok := processor.parseDepupComment(line); ok && namesPackage(d, from) {
+			line = renameDirectivePackage(line, from, to)
+			renamed = append(renamed, RenamedComment{File: file, Line: i + 1, Content: line})
+		}
+		output = append(output, line)
+	}
+
+	if len(renamed) == 0 || u.dryRun {
+		return renamed, nil
+	}
+
+	if err := os.WriteFile(file, []byte(format.render(output)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write renamed packages to %s: %w", file, err)
+	}
+
+	return renamed, nil
+}
+
+// namesPackage reports whether the directive names the package, alone or in a list of packages
+func namesPackage(d directive, name string) bool {
+	if len(d.packageNames) == 0 {
+		return d.packageName == name
+	}
+	for _, packageName := range d.packageNames {
+		if packageName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renameDirectivePackage renames the package from to the package to in the last depup directive of the line
+// Comma separated package lists and repeated package attributes are renamed as well, e.g.
+// "# depup package=aws-provider,vpc" results in "# depup package=aws,vpc".
+func renameDirectivePackage(line, from, to string) string {
+	locations := directiveStartPattern.FindAllStringIndex(line, -1)
+	if locations == nil {
+		return line
+	}
+
+	start := locations[len(locations)-1][1]
+	names := directivePackagesPattern.FindString(line[start:])
+	output := line[:start] + renamePackageList(names, from, to)
+
+	// Attributes directly follow the package list, as in parseDirective
+	rest := line[start+len(names):]
+	for {
+		attributeMatches := directiveAttributePattern.FindStringSubmatchIndex(rest)
+		if attributeMatches == nil {
+			break
+		}
+		value := rest[attributeMatches[4]:attributeMatches[5]]
+		if rest[attributeMatches[2]:attributeMatches[3]] == packageAttribute {
+			value = renamePackageList(value, from, to)
+		}
+		output += rest[:attributeMatches[4]] + value
+		rest = rest[attributeMatches[1]:]
+	}
+
+	return output + rest
+}
+
+// renamePackageList renames the package from to the package to in a comma separated list of packages
+func renamePackageList(list, from, to string) string {
+	names := strings.Split(list, ",")
+	for i, name := range names {
+		if name == from {
+			names[i] = to
+		}
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,87 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdater_Migrate(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		fileContent     string
+		options         []Option
+		expectedContent string
+		expectedLines   []int
+	}{
+		{
+			name:            "YAML comments above and inline",
+			file:            "values.yaml",
+			fileContent:     "# depup package=aws-provider\nversion: 4.0.0\nother: 4.0.0 # depup package=aws-provider version=4.0.0\n",
+			expectedContent: "# depup package=aws\nversion: 4.0.0\nother: 4.0.0 # depup package=aws version=4.0.0\n",
+			expectedLines:   []int{1, 3},
+		},
+		{
+			name:            "YAML package lists",
+			file:            "values.yaml",
+			fileContent:     "image: a:1.0.0 b:2.0.0 c:3.0.0 # depup package=vpc,aws-provider package=aws-provider-extra\n",
+			expectedContent: "image: a:1.0.0 b:2.0.0 c:3.0.0 # depup package=vpc,aws package=aws-provider-extra\n",
+			expectedLines:   []int{1},
+		},
+		{
+			name:            "HCL comment styles",
+			file:            "main.tf",
+			fileContent:     "aws = {\n  # depup package=aws-provider\n  version = \"4.0.0\"\n  // depup:package=aws-provider scheme=partial\n  constraint = \"~> 4.0\"\n}\n",
+			options:         []Option{WithFileExtensions([]string{".tf"})},
+			expectedContent: "aws = {\n  # depup package=aws\n  version = \"4.0.0\"\n  // depup:package=aws scheme=partial\n  constraint = \"~> 4.0\"\n}\n",
+			expectedLines:   []int{2, 4},
+		},
+		{
+			name:            "Other packages and prefixes are left alone",
+			file:            "values.yaml",
+			fileContent:     "# depup package=aws-provider-extra\nversion: 4.0.0\nnote: aws-provider # mentions aws-provider\n",
+			expectedContent: "# depup package=aws-provider-extra\nversion: 4.0.0\nnote: aws-provider # mentions aws-provider\n",
+		},
+		{
+			name:            "Dry run leaves the file unchanged",
+			file:            "values.yaml",
+			fileContent:     "# depup package=aws-provider\nversion: 4.0.0\n",
+			options:         []Option{WithDryRun(true)},
+			expectedContent: "# depup package=aws-provider\nversion: 4.0.0\n",
+			expectedLines:   []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			renamed, err := NewUpdater(tt.options...).Migrate(dir, "aws-provider", "aws")
+			if err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+
+			if len(renamed) != len(tt.expectedLines) {
+				t.Fatalf("Migrate() = %+v, expected %d renamed comments", renamed, len(tt.expectedLines))
+			}
+			for i, comment := range renamed {
+				if comment.Line != tt.expectedLines[i] || comment.File != filePath {
+					t.Errorf("renamed comment %d = %s:%d, expected %s:%d", i, comment.File, comment.Line, filePath, tt.expectedLines[i])
+				}
+			}
+		})
+	}
+}
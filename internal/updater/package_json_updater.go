@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// packageJsonFileName is the file name handled by the PackageJsonUpdater
+const packageJsonFileName = "package.json"
+
+// packageJsonSections lists the dependency maps of package.json that are updated
+var /* const */ packageJsonSections = []string{"dependencies", "devDependencies"}
+
+// PackageJsonUpdater updates dependency version ranges in npm package.json files
+// Dependencies are matched by their key, so no annotations are needed:
+//
+//	"dependencies": { "left-pad": "^1.0.0" }
+//
+// updated with left-pad=2.0.0 becomes "^2.0.0", keeping the range operator
+type PackageJsonUpdater struct{}
+
+func NewPackageJsonUpdater() *PackageJsonUpdater {
+	return &PackageJsonUpdater{}
+}
+
+func (u *PackageJsonUpdater) Name() string {
+	return "package.json"
+}
+
+// Supports returns false for all extensions, package.json files are matched by name
+func (u *PackageJsonUpdater) Supports(fileExtension string) bool {
+	return false
+}
+
+func (u *PackageJsonUpdater) GetSupportedExtensions() []string {
+	return []string{".json"}
+}
+
+// SupportsFileName reports whether the updater handles files with the given base name
+func (u *PackageJsonUpdater) SupportsFileName(fileName string) bool {
+	return fileName == packageJsonFileName
+}
+
+func (u *PackageJsonUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot read file %s: %w", filePath, err)
+	}
+
+	var changes []Change
+	var matched bool
+	for _, section := range packageJsonSections {
+		for _, pkg := range packages {
+			// Escape the name as a JSON pointer token, scoped packages contain a slash
+			token := strings.ReplaceAll(strings.ReplaceAll(pkg.Name, "~", "~0"), "/", "~1")
+
+			// Sections of another type, e.g. in fixtures or a root array, hold no dependencies to update
+			start, end, err := findJSONPointer(content, "/"+section+"/"+token)
+			if errors.Is(err, errJSONNotFound) || errors.Is(err, errJSONTypeMismatch) {
+				continue
+			}
+			if err != nil {
+				return "", nil, fmt.Errorf("cannot read %s of %s: %w", section, filePath, err)
+			}
+			matched = true
+
+			updatedValue, change := replaceJSONVersion(content[start:end], pkg, options.CanonicalVersions)
+			if change == nil {
+				continue
+			}
+
+			change.File = filePath
+			change.Line = bytes.Count(content[:start], []byte("\n")) + 1
+			changes = append(changes, *change)
+
+			// Splice the new value in, leaving all surrounding bytes untouched
+			content = append(content[:start:start], append(updatedValue, content[end:]...)...)
+		}
+	}
+
+	// Write changes if needed, or rewrite matched files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && matched) {
+		err = os.WriteFile(filePath, content, 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return string(content), changes, nil
+}
@@ -0,0 +1,155 @@
+package updater
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageJsonUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		packages        []Package
+		options         FileUpdaterOptions
+		expectedOutput  string
+		expectedChanges []Change
+		expectError     bool
+	}{
+		{
+			name:            "Caret range in dependencies",
+			fileContent:     "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"left-pad\": \"^1.0.0\"\n  }\n}\n",
+			packages:        []Package{{Name: "left-pad", Version: "2.0.0"}},
+			expectedOutput:  "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"left-pad\": \"^2.0.0\"\n  }\n}\n",
+			expectedChanges: []Change{{Line: 4, Package: "left-pad", OldVersion: "1.0.0", NewVersion: "2.0.0"}},
+		},
+		{
+			name:            "Tilde range in devDependencies",
+			fileContent:     `{"devDependencies": {"jest": "~29.1.0", "eslint": "8.0.0"}}`,
+			packages:        []Package{{Name: "jest", Version: "29.7.0"}},
+			expectedOutput:  `{"devDependencies": {"jest": "~29.7.0", "eslint": "8.0.0"}}`,
+			expectedChanges: []Change{{Line: 1, Package: "jest", OldVersion: "29.1.0", NewVersion: "29.7.0"}},
+		},
+		{
+			name:           "Both sections and scoped packages",
+			fileContent:    `{"dependencies": {"@types/node": ">=18.0.0"}, "devDependencies": {"@types/node": "^18.0.0"}}`,
+			packages:       []Package{{Name: "@types/node", Version: "20.1.0"}},
+			expectedOutput: `{"dependencies": {"@types/node": ">=20.1.0"}, "devDependencies": {"@types/node": "^20.1.0"}}`,
+			expectedChanges: []Change{
+				{Line: 1, Package: "@types/node", OldVersion: "18.0.0", NewVersion: "20.1.0"},
+				{Line: 1, Package: "@types/node", OldVersion: "18.0.0", NewVersion: "20.1.0"},
+			},
+		},
+		{
+			name:            "Unknown package and missing section",
+			fileContent:     `{"dependencies": {"react": "^18.0.0"}}`,
+			packages:        []Package{{Name: "vue", Version: "3.0.0"}},
+			expectedOutput:  `{"dependencies": {"react": "^18.0.0"}}`,
+			expectedChanges: nil,
+		},
+		{
+			name:            "Dry run mode",
+			fileContent:     `{"dependencies": {"react": "^18.0.0"}}`,
+			packages:        []Package{{Name: "react", Version: "18.2.0"}},
+			options:         FileUpdaterOptions{DryRun: true},
+			expectedOutput:  `{"dependencies": {"react": "^18.2.0"}}`,
+			expectedChanges: []Change{{Line: 1, Package: "react", OldVersion: "18.0.0", NewVersion: "18.2.0"}},
+		},
+		{
+			name:            "Root array",
+			fileContent:     `[1, 2]`,
+			packages:        []Package{{Name: "react", Version: "18.2.0"}},
+			expectedOutput:  `[1, 2]`,
+			expectedChanges: nil,
+		},
+		{
+			name:            "Scalar section",
+			fileContent:     `{"dependencies": "x"}`,
+			packages:        []Package{{Name: "react", Version: "18.2.0"}},
+			expectedOutput:  `{"dependencies": "x"}`,
+			expectedChanges: nil,
+		},
+		{
+			name:            "Array section next to a valid section",
+			fileContent:     `{"dependencies": ["react"], "devDependencies": {"react": "^18.0.0"}}`,
+			packages:        []Package{{Name: "react", Version: "18.2.0"}},
+			expectedOutput:  `{"dependencies": ["react"], "devDependencies": {"react": "^18.2.0"}}`,
+			expectedChanges: []Change{{Line: 1, Package: "react", OldVersion: "18.0.0", NewVersion: "18.2.0"}},
+		},
+		{
+			name:        "Invalid JSON",
+			fileContent: `{"dependencies": {"react": }`,
+			packages:    []Package{{Name: "react", Version: "18.2.0"}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".json")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			updater := NewPackageJsonUpdater()
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if tt.expectError {
+				return
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+			for i, expected := range tt.expectedChanges {
+				expected.File = tempFile
+				if changes[i] != expected {
+					t.Errorf("UpdateFile() change[%d] = %+v, expected %+v", i, changes[i], expected)
+				}
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read temp file: %v", err)
+			}
+			expectedContent := tt.expectedOutput
+			if tt.options.DryRun {
+				expectedContent = tt.fileContent
+			}
+			if string(content) != expectedContent {
+				t.Errorf("File content = %q, expected %q", string(content), expectedContent)
+			}
+		})
+	}
+}
+
+func TestPackageJsonUpdater_RoutedByFileName(t *testing.T) {
+	tempDir := t.TempDir()
+	packageJson := filepath.Join(tempDir, "package.json")
+	otherJson := filepath.Join(tempDir, "other.json")
+	for _, path := range []string{packageJson, otherJson} {
+		if err := os.WriteFile(path, []byte(`{"dependencies": {"left-pad": "^1.0.0"}}`), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	updater := NewUpdater(WithFileExtensions([]string{".json"}), WithOutput(io.Discard))
+	if err := updater.Update(tempDir, []Package{{Name: "left-pad", Version: "2.0.0"}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	changedFiles := updater.ChangedFiles()
+	if len(changedFiles) != 1 || changedFiles[0] != packageJson {
+		t.Errorf("expected only %s to change, got %v", packageJson, changedFiles)
+	}
+}
@@ -0,0 +1,79 @@
+package updater
+
+import "fmt"
+
+// Plan describes the changes of an update, grouped by file
+type Plan struct {
+	Files   []PlannedFile // Files with changes, in processing order
+	Skipped []Skip        // Annotated versions left unchanged by a guard of their depup comment
+}
+
+// PlannedFile holds the proposed content and changes of a single file
+type PlannedFile struct {
+	Path    string   // Absolute path of the file
+	Content string   // Content of the file after the update
+	Changes []Change // Version changes within the file
+}
+
+// Changes returns the changes of all files in the plan
+func (p *Plan) Changes() []Change {
+	var changes []Change
+	for _, file := range p.Files {
+		changes = append(changes, file.Changes...)
+	}
+	return changes
+}
+
+// Select returns a plan with only the changes for which keep returns true
+// Lines of rejected changes are restored from the files on disk, so the files must not have changed since planning
+func (p *Plan) Select(keep func(Change) bool) (*Plan, error) {
+	selected := &Plan{Skipped: p.Skipped}
+	for _, file := range p.Files {
+		var kept, rejected []Change
+		for _, change := range file.Changes {
+			if keep(change) {
+				kept = append(kept, change)
+			} else {
+				rejected = append(rejected, change)
+			}
+		}
+
+		if len(kept) == 0 {
+			continue
+		}
+		if len(rejected) == 0 {
+			selected.Files = append(selected.Files, file)
+			continue
+		}
+
+		content, err := restoreLines(file, rejected)
+		if err != nil {
+			return nil, err
+		}
+		selected.Files = append(selected.Files, PlannedFile{Path: file.Path, Content: content, Changes: kept})
+	}
+
+	return selected, nil
+}
+
+// restoreLines returns the planned content of the file with the lines of the given changes read from disk
+func restoreLines(file PlannedFile, changes []Change) (string, error) {
+	originalLines, _, err := readFileLines(file.Path)
+	if err != nil {
+		return "", err
+	}
+
+	lines, format, err := parseLines([]byte(file.Content))
+	if err != nil {
+		return "", err
+	}
+	if len(lines) != len(originalLines) {
+		return "", fmt.Errorf("cannot select changes of %s: the file has changed since planning", file.Path)
+	}
+
+	for _, change := range changes {
+		lines[change.Line-1] = originalLines[change.Line-1]
+	}
+
+	return format.render(lines), nil
+}
@@ -0,0 +1,161 @@
+package updater
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdater_Plan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml":   "# depup package=app\nimage: app:1.0.0\nredis: 6.0.0 # depup package=redis\n",
+		"main.tf":    "# depup package=aws\nversion = \"4.0.0\"\n",
+		"other.yaml": "# depup package=unknown\nimage: other:1.0.0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	updater := NewUpdater(WithOutput(&out))
+	packages := []Package{
+		{Name: "app", Version: "2.0.0"},
+		{Name: "redis", Version: "7.0.0"},
+		{Name: "aws", Version: "4.5.0"},
+	}
+
+	plan, err := updater.Plan(dir, packages)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	appPath := filepath.Join(dir, "app.yaml")
+	tfPath := filepath.Join(dir, "main.tf")
+	expected := &Plan{Files: []PlannedFile{
+		{
+			Path:    appPath,
+			Content: "# depup package=app\nimage: app:2.0.0\nredis: 7.0.0 # depup package=redis\n",
+			Changes: []Change{
+				{File: appPath, Line: 2, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+				{File: appPath, Line: 3, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+			},
+		},
+		{
+			Path:    tfPath,
+			Content: "# depup package=aws\nversion = \"4.5.0\"\n",
+			Changes: []Change{
+				{File: tfPath, Line: 2, Package: "aws", OldVersion: "4.0.0", NewVersion: "4.5.0"},
+			},
+		},
+	}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Plan() = %+v, want %+v", plan, expected)
+	}
+
+	if len(plan.Changes()) != 3 {
+		t.Errorf("Plan.Changes() returned %d changes, want 3", len(plan.Changes()))
+	}
+
+	// Planning must neither write files nor print anything
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("Plan() modified %s", name)
+		}
+	}
+	if out.Len() != 0 {
+		t.Errorf("Plan() printed %q, want no output", out.String())
+	}
+}
+
+func TestUpdater_Plan_InvalidPackage(t *testing.T) {
+	updater := NewUpdater()
+
+	plan, err := updater.Plan(t.TempDir(), []Package{{Name: "app", Version: "not a version"}})
+	if err == nil {
+		t.Fatal("Plan() expected error for invalid package")
+	}
+	if plan != nil {
+		t.Errorf("Plan() = %+v, want nil", plan)
+	}
+}
+
+func TestPlan_Select(t *testing.T) {
+	dir := t.TempDir()
+	appPath := filepath.Join(dir, "app.yaml")
+	dbPath := filepath.Join(dir, "db.yaml")
+	files := map[string]string{
+		appPath: "# depup package=app\r\nimage: app:1.0.0\r\nredis: 6.0.0 # depup package=redis\r\n",
+		dbPath:  "# depup package=db\nimage: db:1.0.0\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	updater := NewUpdater(WithOutput(io.Discard))
+	plan, err := updater.Plan(dir, []Package{{Name: "app", Version: "2.0.0"}, {Name: "redis", Version: "7.0.0"}, {Name: "db", Version: "2.0.0"}})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	// Keep the redis change only, dropping the other change in the same file and the whole db file
+	selected, err := plan.Select(func(change Change) bool { return change.Package == "redis" })
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	expected := &Plan{Files: []PlannedFile{
+		{
+			Path:    appPath,
+			Content: "# depup package=app\r\nimage: app:1.0.0\r\nredis: 7.0.0 # depup package=redis\r\n",
+			Changes: []Change{{File: appPath, Line: 3, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"}},
+		},
+	}}
+	if !reflect.DeepEqual(selected, expected) {
+		t.Errorf("Select() = %+v, want %+v", selected, expected)
+	}
+}
+
+func TestUpdater_Apply(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(filePath, []byte("# depup package=app\nimage: app:1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	updater := NewUpdater(WithOutput(&out))
+	plan, err := updater.Plan(filePath, []Package{{Name: "app", Version: "2.0.0"}})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if err := updater.Apply(plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(content) != "# depup package=app\nimage: app:2.0.0\n" {
+		t.Errorf("Apply() wrote %q", string(content))
+	}
+	if expected := "Updated " + filePath + ":2 app 1.0.0 -> 2.0.0\n"; out.String() != expected {
+		t.Errorf("Apply() output = %q, want %q", out.String(), expected)
+	}
+	if len(updater.Changes()) != 1 {
+		t.Errorf("Changes() returned %d changes, want 1", len(updater.Changes()))
+	}
+}
@@ -0,0 +1,60 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rejectSuffix is appended to the path of a file to name the file holding its rejected updates, like patch does
+const rejectSuffix = ".rej"
+
+// writeRejects writes the skipped updates of each file to FILE.rej, leaving the file itself intact
+// Every entry states the line, the intended update and why it was rejected, followed by the line as found in the
+// file and as it would have been written, which has the version of the package in place of the current version.
+func (u *Updater) writeRejects(skips []Skip, packages []Package) error {
+	var files []string
+	skipsByFile := map[string][]Skip{}
+	for _, skip := range skips {
+		if _, ok := skipsByFile[skip.File]; !ok {
+			files = append(files, skip.File)
+		}
+		skipsByFile[skip.File] = append(skipsByFile[skip.File], skip)
+	}
+
+	for _, file := range files {
+		lines, _, err := readFileLines(file)
+		if err != nil {
+			return err
+		}
+
+		var content strings.Builder
+		fmt.Fprintf(&content, "# depup rejected %d update(s) of %s\n", len(skipsByFile[file]), filepath.Base(file))
+		for _, skip := range skipsByFile[file] {
+			line := ""
+			if skip.Line >= 1 && skip.Line <= len(lines) {
+				line = lines[skip.Line-1]
+			}
+			target := skip.Version
+			for _, pkg := range u.packagesForFile(file, packages) {
+				if pkg.Name == skip.Package {
+					target = trimVPrefix(pkg.Version)
+					break
+				}
+			}
+			fmt.Fprintf(&content, "# line %d: %s %s -> %s: %s\n", skip.Line, skip.Package, skip.Version, target, skip.Reason)
+			fmt.Fprintf(&content, "-%s\n+%s\n", line, strings.Replace(line, skip.Version, target, 1))
+		}
+
+		rejectPath := file + rejectSuffix
+		if err := os.WriteFile(rejectPath, []byte(content.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write rejected updates to %s: %w", rejectPath, err)
+		}
+		if u.warnings != nil {
+			fmt.Fprintf(u.warnings, "Warning: wrote %d rejected update(s) to %s\n", len(skipsByFile[file]), rejectPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdater_Update_QuarantineOnConflict(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileContent     string
+		options         []Option
+		expectedContent string
+		expectedReject  string
+	}{
+		{
+			name:            "Ambiguous line",
+			fileContent:     "image: a:1.0.0 b:1.0.0 # depup package=app\n\nother: 1.0.0 # depup package=app\n",
+			expectedContent: "image: a:1.0.0 b:1.0.0 # depup package=app\n\nother: 2.0.0 # depup package=app\n",
+			expectedReject: "# depup rejected 1 update(s) of values.yaml\n" +
+				"# line 1: app 1.0.0 -> 2.0.0: line holds 2 versions for the package\n" +
+				"-image: a:1.0.0 b:1.0.0 # depup package=app\n" +
+				"+image: a:2.0.0 b:1.0.0 # depup package=app\n",
+		},
+		{
+			name:            "Guard mismatch",
+			fileContent:     "# depup package=app from=0.9.0 version=1.0.0\nversion: 1.0.0\n",
+			options:         []Option{WithSyncCommentVersion(true)},
+			expectedContent: "# depup package=app from=0.9.0 version=1.0.0\nversion: 1.0.0\n",
+			expectedReject: "# depup rejected 1 update(s) of values.yaml\n" +
+				"# line 2: app 1.0.0 -> 2.0.0: current version 1.0.0 doesn't match from=0.9.0\n" +
+				"-version: 1.0.0\n" +
+				"+version: 2.0.0\n",
+		},
+		{
+			name:            "No conflicts",
+			fileContent:     "version: 1.0.0 # depup package=app\n",
+			expectedContent: "version: 2.0.0 # depup package=app\n",
+		},
+		{
+			name:            "Dry run",
+			fileContent:     "image: a:1.0.0 b:1.0.0 # depup package=app\n",
+			options:         []Option{WithDryRun(true)},
+			expectedContent: "image: a:1.0.0 b:1.0.0 # depup package=app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "values.yaml")
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			options := append([]Option{WithOutput(io.Discard), WithWarnings(io.Discard), WithQuarantineOnConflict(true)}, tt.options...)
+			if err := NewUpdater(options...).Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+
+			reject, err := os.ReadFile(filePath + rejectSuffix)
+			if tt.expectedReject == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("expected no reject file, got %q (%v)", string(reject), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read reject file: %v", err)
+			}
+			if string(reject) != tt.expectedReject {
+				t.Errorf("reject file = %q, expected %q", string(reject), tt.expectedReject)
+			}
+		})
+	}
+}
+
+func TestCollectResults_QuarantineOnConflict(t *testing.T) {
+	var skips []Skip
+	options := FileUpdaterOptions{QuarantineOnConflict: true, ErrorOnMultipleMatches: true, OnSkip: func(skip Skip) { skips = append(skips, skip) }}
+	results := processLines(NewYamlFileUpdater(), []string{"image: a:1.0.0 b:1.0.0 # depup package=app"}, []Package{{Name: "app", Version: "2.0.0"}}, CommentPositionAbove)
+
+	output, changes, err := collectResults(results, "values.yaml", options)
+	if err != nil {
+		t.Fatalf("collectResults() error = %v, expected the line to be quarantined", err)
+	}
+	if output[0] != "image: a:1.0.0 b:1.0.0 # depup package=app" || len(changes) != 0 {
+		t.Errorf("collectResults() = %q, %+v, expected the line unchanged", output, changes)
+	}
+	if len(skips) != 1 || skips[0].Line != 1 || skips[0].Version != "1.0.0" {
+		t.Errorf("skips = %+v, expected the quarantined line", skips)
+	}
+}
@@ -0,0 +1,279 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Supported groupings of the change report
+const (
+	GroupByFile    = "file"    // Changes are listed in the order the files were processed
+	GroupByPackage = "package" // Changes are listed below the package they belong to
+)
+
+// Supported report formats
+const (
+	FormatText      = "text"  // Human readable lines
+	FormatJSON      = "json"  // A single JSON document for tooling
+	FormatSARIF     = "sarif" // A SARIF document for code scanning, see SARIFLevel
+	FormatLines     = "lines" // One "file:line package old -> new" line per change and nothing else, e.g. for CI logs
+	FormatJSONLines = "jsonl" // One JSON object per change, written on a single line unless PrettyJSON is set
+)
+
+// Change describes a single version replacement made by a FileUpdater
+type Change struct {
+	File       string `json:"file"`             // Absolute path of the changed file
+	Line       int    `json:"line"`             // 1-based line number of the replaced version
+	Package    string `json:"package"`          // Name of the package that was updated
+	OldVersion string `json:"old_version"`      // Version found in the file before the update
+	NewVersion string `json:"new_version"`      // Version written to the file
+	Source     string `json:"source,omitempty"` // Origin of the new version, see Package.Source
+	Mode       string `json:"mode,omitempty"`   // Permissions of the file, kept when it is written, only set in dry runs, e.g. 0644
+}
+
+// Skip describes an annotated version that was left unchanged because a guard of its depup comment didn't hold
+type Skip struct {
+	File    string `json:"file"`    // Absolute path of the file
+	Line    int    `json:"line"`    // 1-based line number of the version
+	Package string `json:"package"` // Name of the package that was not updated
+	Version string `json:"version"` // Version found in the file
+	Reason  string `json:"reason"`  // Why the version was not updated
+}
+
+// ReportOptions contains configuration for rendering reports
+type ReportOptions struct {
+	BaseDir     string      // When set, reported paths are made relative to this directory
+	GroupBy     string      // Grouping of the change report, GroupByFile if empty
+	Format      string      // Report format, FormatText if empty
+	ShowSource  bool        // When true, text reports state where each new version came from
+	CompactJSON bool        // When true, JSON reports are written on a single line, JSON and SARIF documents are indented otherwise
+	PrettyJSON  bool        // When true, JSON reports are indented, including JSON lines, which are compact otherwise
+	SARIFLevel  string      // Level of SARIF results (SARIFLevelError, SARIFLevelWarning, SARIFLevelNote), SARIFLevelWarning if empty
+	FileMode    os.FileMode // Permissions written files are set to, 0 if they keep their permissions
+}
+
+// jsonReport is the document written by JSON reports
+type jsonReport struct {
+	DryRun  bool     `json:"dry_run"`
+	Changes []Change `json:"changes"`
+	Skipped []Skip   `json:"skipped,omitempty"`
+}
+
+// Reporter renders the outcome of an update run
+// Paths are kept absolute internally and only rebased when they are printed
+type Reporter struct {
+	out     io.Writer
+	options ReportOptions
+}
+
+// NewReporter creates a Reporter writing to out
+func NewReporter(out io.Writer, options ReportOptions) *Reporter {
+	return &Reporter{
+		out:     out,
+		options: options,
+	}
+}
+
+// ReportDryRun prints the content a file would have after the update and the permissions that would be kept
+// JSON, SARIF and lines reports only contain the changes, so the content is not printed. A zero mode is omitted.
+func (r *Reporter) ReportDryRun(filePath string, content string, mode os.FileMode) {
+	if r.options.Format == FormatJSON || r.options.Format == FormatSARIF || r.options.Format == FormatLines || r.options.Format == FormatJSONLines {
+		return
+	}
+
+	if r.options.FileMode != 0 {
+		fmt.Fprintf(r.out, "Dry run mode - updated content for %s (mode %s set):\n%s\n", r.displayPath(filePath), formatFileMode(r.options.FileMode), content)
+		return
+	}
+	if mode == 0 {
+		fmt.Fprintf(r.out, "Dry run mode - updated content for %s:\n%s\n", r.displayPath(filePath), content)
+		return
+	}
+	fmt.Fprintf(r.out, "Dry run mode - updated content for %s (mode %s kept):\n%s\n", r.displayPath(filePath), formatFileMode(mode), content)
+}
+
+// formatFileMode formats the permission bits of a file mode in octal, e.g. 0644
+func formatFileMode(mode os.FileMode) string {
+	return fmt.Sprintf("%04o", mode.Perm())
+}
+
+// ReportRun prints the outcome of a run
+// Text reports list changes that were written, dry runs are covered by ReportDryRun.
+// Skipped versions are reported in both modes.
+func (r *Reporter) ReportRun(changes []Change, skipped []Skip, dryRun bool) error {
+	switch r.options.Format {
+	case FormatJSON:
+		return r.reportJSON(changes, skipped, dryRun)
+	case FormatSARIF:
+		return r.reportSARIF(changes)
+	case FormatLines:
+		r.reportLines(changes)
+		return nil
+	case FormatJSONLines:
+		return r.reportJSONLines(changes)
+	}
+
+	if !dryRun {
+		r.ReportChanges(changes)
+	}
+	for _, skip := range skipped {
+		fmt.Fprintf(r.out, "Skipped %s:%d %s: %s\n", r.displayPath(skip.File), skip.Line, skip.Package, skip.Reason)
+	}
+	return nil
+}
+
+// ReportChanges prints the applied changes using the configured grouping
+func (r *Reporter) ReportChanges(changes []Change) {
+	if r.options.GroupBy == GroupByPackage {
+		r.reportChangesByPackage(changes)
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Fprintf(r.out, "Updated %s:%d %s %s -> %s%s\n",
+			r.displayPath(change.File), change.Line, change.Package, change.OldVersion, change.NewVersion, r.sourceSuffix(change))
+	}
+}
+
+// reportJSON writes all changes as a single JSON document
+func (r *Reporter) reportJSON(changes []Change, skipped []Skip, dryRun bool) error {
+	report := jsonReport{DryRun: dryRun, Changes: make([]Change, 0, len(changes))}
+	for _, change := range changes {
+		change.File = r.displayPath(change.File)
+		report.Changes = append(report.Changes, change)
+	}
+	for _, skip := range skipped {
+		skip.File = r.displayPath(skip.File)
+		report.Skipped = append(report.Skipped, skip)
+	}
+
+	encoder := json.NewEncoder(r.out)
+	if r.indentJSON(true) {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(report)
+}
+
+// reportJSONLines writes one JSON object per change, so tools can stream the changes of large runs
+// Changes of dry runs are included, skipped versions are not.
+func (r *Reporter) reportJSONLines(changes []Change) error {
+	encoder := json.NewEncoder(r.out)
+	if r.indentJSON(false) {
+		encoder.SetIndent("", "  ")
+	}
+	for _, change := range changes {
+		change.File = r.displayPath(change.File)
+		if err := encoder.Encode(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indentJSON reports whether JSON is indented, CompactJSON and PrettyJSON override the default of the format
+func (r *Reporter) indentJSON(defaultIndent bool) bool {
+	switch {
+	case r.options.CompactJSON:
+		return false
+	case r.options.PrettyJSON:
+		return true
+	default:
+		return defaultIndent
+	}
+}
+
+// reportLines prints one line per change, sorted by file and line, for grepping CI logs
+// Changes of dry runs are included, skipped versions are not.
+func (r *Reporter) reportLines(changes []Change) {
+	sorted := make([]Change, 0, len(changes))
+	for _, change := range changes {
+		change.File = r.displayPath(change.File)
+		sorted = append(sorted, change)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	for _, change := range sorted {
+		fmt.Fprintf(r.out, "%s:%d %s %s -> %s\n", change.File, change.Line, change.Package, change.OldVersion, change.NewVersion)
+	}
+}
+
+// sourceSuffix returns the text describing where the new version came from, if enabled
+func (r *Reporter) sourceSuffix(change Change) string {
+	if !r.options.ShowSource || change.Source == "" {
+		return ""
+	}
+	return " (from " + change.Source + ")"
+}
+
+// reportChangesByPackage prints a section per package listing every location it was changed in
+func (r *Reporter) reportChangesByPackage(changes []Change) {
+	for _, group := range groupChangesByPackage(changes) {
+		fmt.Fprintf(r.out, "Updated %s:\n", group[0].Package)
+		for _, change := range group {
+			fmt.Fprintf(r.out, "  %s:%d %s -> %s%s\n",
+				r.displayPath(change.File), change.Line, change.OldVersion, change.NewVersion, r.sourceSuffix(change))
+		}
+	}
+}
+
+// ReportPackageTotals prints the number of files each package changes, one line per package sorted by name
+// Individual files aren't listed, which keeps dry runs of huge change sets readable.
+func (r *Reporter) ReportPackageTotals(changes []Change) {
+	for _, group := range groupChangesByPackage(changes) {
+		files := map[string]struct{}{}
+		for _, change := range group {
+			files[change.File] = struct{}{}
+		}
+
+		unit := "files"
+		if len(files) == 1 {
+			unit = "file"
+		}
+		fmt.Fprintf(r.out, "%s: %d %s\n", group[0].Package, len(files), unit)
+	}
+}
+
+// groupChangesByPackage returns the changes grouped by package, sorted by package name
+// Changes within a group keep their original order
+func groupChangesByPackage(changes []Change) [][]Change {
+	groups := map[string][]Change{}
+	var names []string
+	for _, change := range changes {
+		if _, ok := groups[change.Package]; !ok {
+			names = append(names, change.Package)
+		}
+		groups[change.Package] = append(groups[change.Package], change)
+	}
+	sort.Strings(names)
+
+	result := make([][]Change, 0, len(names))
+	for _, name := range names {
+		result = append(result, groups[name])
+	}
+
+	return result
+}
+
+// displayPath returns the path as it should be shown to the user
+// Falls back to the original path if it cannot be made relative
+func (r *Reporter) displayPath(path string) string {
+	if r.options.BaseDir == "" {
+		return path
+	}
+
+	relPath, err := filepath.Rel(r.options.BaseDir, path)
+	if err != nil {
+		return path
+	}
+
+	return relPath
+}
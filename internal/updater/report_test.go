@@ -0,0 +1,300 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReporter_ReportChanges(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	changes := []Change{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+		{File: filepath.Join(baseDir, "infra", "main.tf"), Line: 7, Package: "aws", OldVersion: "4.0.0", NewVersion: "4.5.0"},
+	}
+
+	tests := []struct {
+		name     string
+		baseDir  string
+		expected string
+	}{
+		{
+			name:    "absolute paths",
+			baseDir: "",
+			expected: "Updated " + filepath.Join(baseDir, "app.yaml") + ":3 app 1.0.0 -> 1.1.0\n" +
+				"Updated " + filepath.Join(baseDir, "infra", "main.tf") + ":7 aws 4.0.0 -> 4.5.0\n",
+		},
+		{
+			name:    "relative paths",
+			baseDir: baseDir,
+			expected: "Updated app.yaml:3 app 1.0.0 -> 1.1.0\n" +
+				"Updated " + filepath.Join("infra", "main.tf") + ":7 aws 4.0.0 -> 4.5.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			NewReporter(&out, ReportOptions{BaseDir: tt.baseDir}).ReportChanges(changes)
+
+			if out.String() != tt.expected {
+				t.Errorf("ReportChanges() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestReporter_ReportChanges_GroupByPackage(t *testing.T) {
+	changes := []Change{
+		{File: "a.yaml", Line: 2, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "a.yaml", Line: 5, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+		{File: "b.yaml", Line: 3, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+		{File: "c.tf", Line: 9, Package: "app", OldVersion: "1.5.0", NewVersion: "2.0.0"},
+	}
+
+	var out bytes.Buffer
+	NewReporter(&out, ReportOptions{GroupBy: GroupByPackage}).ReportChanges(changes)
+
+	expected := "Updated app:\n" +
+		"  a.yaml:2 1.0.0 -> 2.0.0\n" +
+		"  c.tf:9 1.5.0 -> 2.0.0\n" +
+		"Updated redis:\n" +
+		"  a.yaml:5 6.0.0 -> 7.0.0\n" +
+		"  b.yaml:3 6.0.0 -> 7.0.0\n"
+
+	if out.String() != expected {
+		t.Errorf("ReportChanges() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestReporter_ReportPackageTotals(t *testing.T) {
+	changes := []Change{
+		{File: "a.yaml", Line: 2, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "a.yaml", Line: 7, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "a.yaml", Line: 5, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+		{File: "b.yaml", Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "c.tf", Line: 9, Package: "app", OldVersion: "1.5.0", NewVersion: "2.0.0"},
+	}
+
+	var out bytes.Buffer
+	NewReporter(&out, ReportOptions{}).ReportPackageTotals(changes)
+
+	// Several changes in one file count once
+	expected := "app: 3 files\n" +
+		"redis: 1 file\n"
+
+	if out.String() != expected {
+		t.Errorf("ReportPackageTotals() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestReporter_ShowSource(t *testing.T) {
+	changes := []Change{
+		{File: "a.yaml", Line: 2, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0", Source: SourceFlag},
+		{File: "b.yaml", Line: 4, Package: "db", OldVersion: "1.0.0", NewVersion: "1.1.0", Source: SourceResolver + "env"},
+		{File: "c.yaml", Line: 1, Package: "lib", OldVersion: "0.1.0", NewVersion: "0.2.0"},
+	}
+
+	var out bytes.Buffer
+	NewReporter(&out, ReportOptions{ShowSource: true}).ReportChanges(changes)
+
+	expected := "Updated a.yaml:2 app 1.0.0 -> 2.0.0 (from flag)\n" +
+		"Updated b.yaml:4 db 1.0.0 -> 1.1.0 (from resolver:env)\n" +
+		"Updated c.yaml:1 lib 0.1.0 -> 0.2.0\n"
+
+	if out.String() != expected {
+		t.Errorf("ReportChanges() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestReporter_ReportRun_JSON(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	changes := []Change{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "1.1.0", Source: SourceConfig},
+	}
+
+	tests := []struct {
+		name        string
+		compactJSON bool
+		expected    string
+	}{
+		{
+			name: "pretty",
+			expected: `{
+  "dry_run": true,
+  "changes": [
+    {
+      "file": "app.yaml",
+      "line": 3,
+      "package": "app",
+      "old_version": "1.0.0",
+      "new_version": "1.1.0",
+      "source": "config"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:        "compact",
+			compactJSON: true,
+			expected: `{"dry_run":true,"changes":[{"file":"app.yaml","line":3,"package":"app","old_version":"1.0.0","new_version":"1.1.0","source":"config"}]}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			reporter := NewReporter(&out, ReportOptions{BaseDir: baseDir, Format: FormatJSON, CompactJSON: tt.compactJSON})
+			reporter.ReportDryRun(filepath.Join(baseDir, "app.yaml"), "content", 0)
+			if err := reporter.ReportRun(changes, nil, true); err != nil {
+				t.Fatalf("ReportRun() unexpected error: %v", err)
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("ReportRun() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestReporter_ReportRun_JSONLines(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	changes := []Change{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "1.1.0", Source: SourceFlag},
+		{File: filepath.Join(baseDir, "db.yaml"), Line: 5, Package: "db", OldVersion: "5.0.0", NewVersion: "6.0.0", Source: SourceFlag},
+	}
+	skipped := []Skip{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 9, Package: "app", Version: "1.2.4", Reason: "pinned"},
+	}
+
+	var out bytes.Buffer
+	reporter := NewReporter(&out, ReportOptions{BaseDir: baseDir, Format: FormatJSONLines})
+	reporter.ReportDryRun(filepath.Join(baseDir, "app.yaml"), "content", 0)
+	if err := reporter.ReportRun(changes, skipped, true); err != nil {
+		t.Fatalf("ReportRun() unexpected error: %v", err)
+	}
+
+	expected := `{"file":"app.yaml","line":3,"package":"app","old_version":"1.0.0","new_version":"1.1.0","source":"flag"}
+{"file":"db.yaml","line":5,"package":"db","old_version":"5.0.0","new_version":"6.0.0","source":"flag"}
+`
+	if out.String() != expected {
+		t.Errorf("ReportRun() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestReporter_JSONIndentation(t *testing.T) {
+	changes := []Change{
+		{File: "app.yaml", Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+	}
+
+	tests := []struct {
+		format         string
+		compactJSON    bool
+		prettyJSON     bool
+		expectIndented bool
+	}{
+		{format: FormatJSON, expectIndented: true},
+		{format: FormatJSON, compactJSON: true, expectIndented: false},
+		{format: FormatJSON, prettyJSON: true, expectIndented: true},
+		{format: FormatSARIF, expectIndented: true},
+		{format: FormatSARIF, compactJSON: true, expectIndented: false},
+		{format: FormatSARIF, prettyJSON: true, expectIndented: true},
+		{format: FormatJSONLines, expectIndented: false},
+		{format: FormatJSONLines, compactJSON: true, expectIndented: false},
+		{format: FormatJSONLines, prettyJSON: true, expectIndented: true},
+		{format: FormatJSONLines, compactJSON: true, prettyJSON: true, expectIndented: false},
+	}
+
+	for _, tt := range tests {
+		name := tt.format
+		if tt.compactJSON {
+			name += " compact"
+		}
+		if tt.prettyJSON {
+			name += " pretty"
+		}
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			reporter := NewReporter(&out, ReportOptions{Format: tt.format, CompactJSON: tt.compactJSON, PrettyJSON: tt.prettyJSON})
+			if err := reporter.ReportRun(changes, nil, true); err != nil {
+				t.Fatalf("ReportRun() unexpected error: %v", err)
+			}
+
+			if indented := strings.Contains(out.String(), "\n  "); indented != tt.expectIndented {
+				t.Errorf("ReportRun() indented = %v, expected %v:\n%s", indented, tt.expectIndented, out.String())
+			}
+			if !tt.expectIndented && strings.Count(out.String(), "\n") != 1 {
+				t.Errorf("ReportRun() output = %q, expected a single line", out.String())
+			}
+			if !json.Valid(out.Bytes()) {
+				t.Errorf("ReportRun() output is not valid JSON: %q", out.String())
+			}
+		})
+	}
+}
+
+func TestReporter_ReportRun_Lines(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	changes := []Change{
+		{File: filepath.Join(baseDir, "values.yaml"), Line: 7, Package: "redis", OldVersion: "7.2.0", NewVersion: "7.2.4", Source: SourceFlag},
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 3, Package: "nginx", OldVersion: "1.25.0", NewVersion: "1.25.3", Source: SourceFlag},
+	}
+	skipped := []Skip{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 9, Package: "app", Version: "1.2.4", Reason: "pinned"},
+	}
+
+	var out bytes.Buffer
+	reporter := NewReporter(&out, ReportOptions{BaseDir: baseDir, Format: FormatLines})
+	reporter.ReportDryRun(filepath.Join(baseDir, "app.yaml"), "content", 0)
+	if err := reporter.ReportRun(changes, skipped, true); err != nil {
+		t.Fatalf("ReportRun() unexpected error: %v", err)
+	}
+
+	expected := "app.yaml:3 nginx 1.25.0 -> 1.25.3\nvalues.yaml:7 redis 7.2.0 -> 7.2.4\n"
+	if out.String() != expected {
+		t.Errorf("ReportRun() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestReporter_ReportRun_Skipped(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	skipped := []Skip{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 2, Package: "app", Version: "1.2.4", Reason: "current version 1.2.4 doesn't match from=1.2.3"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "text",
+			format:   FormatText,
+			expected: "Skipped app.yaml:2 app: current version 1.2.4 doesn't match from=1.2.3\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			expected: `{"dry_run":true,"changes":[],"skipped":[{"file":"app.yaml","line":2,"package":"app","version":"1.2.4","reason":"current version 1.2.4 doesn't match from=1.2.3"}]}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			reporter := NewReporter(&out, ReportOptions{BaseDir: baseDir, Format: tt.format, CompactJSON: true})
+			if err := reporter.ReportRun(nil, skipped, true); err != nil {
+				t.Fatalf("ReportRun() unexpected error: %v", err)
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("ReportRun() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
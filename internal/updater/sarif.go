@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Supported levels of SARIF results
+const (
+	SARIFLevelError   = "error"
+	SARIFLevelWarning = "warning"
+	SARIFLevelNote    = "note"
+)
+
+// sarifRuleOutdated identifies results for annotated versions that differ from the supplied version
+const sarifRuleOutdated = "outdated-version"
+
+// sarifLog is the minimal subset of a SARIF 2.1.0 document written by SARIF reports
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// reportSARIF writes a SARIF document with one result per change, pointing at the line of the outdated version
+func (r *Reporter) reportSARIF(changes []Change) error {
+	level := r.options.SARIFLevel
+	if level == "" {
+		level = SARIFLevelWarning
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "depup",
+			InformationURI: "https://github.com/dtomasi/depup",
+			Rules: []sarifRule{{
+				ID:               sarifRuleOutdated,
+				ShortDescription: sarifMessage{Text: "Annotated version differs from the supplied version"},
+			}},
+		}},
+		Results: make([]sarifResult, 0, len(changes)),
+	}
+
+	for _, change := range changes {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  sarifRuleOutdated,
+			Level:   level,
+			Message: sarifMessage{Text: fmt.Sprintf("%s %s should be updated to %s", change.Package, change.OldVersion, change.NewVersion)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(r.displayPath(change.File))},
+				Region:           sarifRegion{StartLine: change.Line},
+			}}},
+		})
+	}
+
+	document := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(r.out)
+	if r.indentJSON(true) {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(document)
+}
+
+// sarifURI returns the artifact URI of a path, a file URI for absolute paths and a relative reference otherwise
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		return fileURI(filepath.ToSlash(path))
+	}
+	return (&url.URL{Path: filepath.ToSlash(path)}).String()
+}
+
+// fileURI returns the file URI of an absolute slash separated path
+// Windows drive letters become part of the path ("file:///C:/proj") and UNC servers the host ("file://server/share")
+func fileURI(path string) string {
+	if server, share, ok := strings.Cut(strings.TrimPrefix(path, "//"), "/"); ok && strings.HasPrefix(path, "//") {
+		return (&url.URL{Scheme: "file", Host: server, Path: "/" + share}).String()
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,132 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestReporter_ReportRun_SARIF(t *testing.T) {
+	baseDir := filepath.FromSlash("/work/project")
+	changes := []Change{
+		{File: filepath.Join(baseDir, "app.yaml"), Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+		{File: filepath.Join(baseDir, "infra", "main.tf"), Line: 7, Package: "aws", OldVersion: "4.0.0", NewVersion: "5.0.0"},
+	}
+
+	tests := []struct {
+		name          string
+		options       ReportOptions
+		expectedLevel string
+		expectedURIs  []string
+	}{
+		{
+			name:          "relative paths with default level",
+			options:       ReportOptions{BaseDir: baseDir},
+			expectedLevel: SARIFLevelWarning,
+			expectedURIs:  []string{"app.yaml", "infra/main.tf"},
+		},
+		{
+			name:          "absolute paths with error level",
+			options:       ReportOptions{SARIFLevel: SARIFLevelError},
+			expectedLevel: SARIFLevelError,
+			expectedURIs:  []string{"file:///work/project/app.yaml", "file:///work/project/infra/main.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.options.Format = FormatSARIF
+			reporter := NewReporter(&out, tt.options)
+			reporter.ReportDryRun(changes[0].File, "content", 0)
+			if err := reporter.ReportRun(changes, nil, true); err != nil {
+				t.Fatalf("ReportRun() unexpected error: %v", err)
+			}
+
+			var document struct {
+				Schema  string `json:"$schema"`
+				Version string `json:"version"`
+				Runs    []struct {
+					Tool struct {
+						Driver struct {
+							Name  string `json:"name"`
+							Rules []struct {
+								ID string `json:"id"`
+							} `json:"rules"`
+						} `json:"driver"`
+					} `json:"tool"`
+					Results []struct {
+						RuleID  string `json:"ruleId"`
+						Level   string `json:"level"`
+						Message struct {
+							Text string `json:"text"`
+						} `json:"message"`
+						Locations []struct {
+							PhysicalLocation struct {
+								ArtifactLocation struct {
+									URI string `json:"uri"`
+								} `json:"artifactLocation"`
+								Region struct {
+									StartLine int `json:"startLine"`
+								} `json:"region"`
+							} `json:"physicalLocation"`
+						} `json:"locations"`
+					} `json:"results"`
+				} `json:"runs"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+				t.Fatalf("ReportRun() output is not valid JSON: %v\n%s", err, out.String())
+			}
+
+			if document.Version != "2.1.0" || document.Schema == "" || len(document.Runs) != 1 {
+				t.Fatalf("ReportRun() document = %+v, expected a single SARIF 2.1.0 run", document)
+			}
+			run := document.Runs[0]
+			if run.Tool.Driver.Name != "depup" || len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != sarifRuleOutdated {
+				t.Errorf("ReportRun() driver = %+v, expected depup with rule %s", run.Tool.Driver, sarifRuleOutdated)
+			}
+			if len(run.Results) != len(changes) {
+				t.Fatalf("ReportRun() results = %d, expected %d", len(run.Results), len(changes))
+			}
+
+			for i, result := range run.Results {
+				if result.RuleID != sarifRuleOutdated || result.Level != tt.expectedLevel {
+					t.Errorf("result %d rule = %s, level = %s, expected %s, %s", i, result.RuleID, result.Level, sarifRuleOutdated, tt.expectedLevel)
+				}
+				if len(result.Locations) != 1 {
+					t.Fatalf("result %d locations = %d, expected 1", i, len(result.Locations))
+				}
+				location := result.Locations[0].PhysicalLocation
+				if location.ArtifactLocation.URI != tt.expectedURIs[i] || location.Region.StartLine != changes[i].Line {
+					t.Errorf("result %d location = %s:%d, expected %s:%d", i, location.ArtifactLocation.URI, location.Region.StartLine, tt.expectedURIs[i], changes[i].Line)
+				}
+			}
+			if expected := "app 1.0.0 should be updated to 1.1.0"; run.Results[0].Message.Text != expected {
+				t.Errorf("result message = %q, expected %q", run.Results[0].Message.Text, expected)
+			}
+		})
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "unix path", path: "/work/project/app.yaml", expected: "file:///work/project/app.yaml"},
+		{name: "drive letter", path: "C:/proj/app.yaml", expected: "file:///C:/proj/app.yaml"},
+		{name: "UNC path", path: "//server/share/proj/app.yaml", expected: "file://server/share/proj/app.yaml"},
+		{name: "escaped characters", path: "/work/my project/app.yaml", expected: "file:///work/my%20project/app.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Windows paths are converted with filepath.ToSlash before, like in sarifURI
+			if uri := fileURI(tt.path); uri != tt.expected {
+				t.Errorf("fileURI(%q) = %q, expected %q", tt.path, uri, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package updater
+
+import (
+	"slices"
+	"strings"
+)
+
+// Annotation is a version annotated with a depup comment
+type Annotation struct {
+	File    string // Absolute path of the file
+	Line    int    // 1-based line number of the annotated version, or of the depup comment if it annotates no line
+	Package string // Package name of the depup comment
+	Version string // Version found on the line, empty if none was found
+	Content string // Content of the line
+	Updater string // Name of the FileUpdater handling the file
+}
+
+// Ignored reports whether an update skips the annotation because no version was found on the line
+// Such annotations are unversioned, e.g. "version: TODO" or a depup comment at the end of a file.
+func (a Annotation) Ignored() bool {
+	return a.Version == ""
+}
+
+// MalformedComment is a depup-like comment that cannot be parsed
+type MalformedComment struct {
+	File    string // Absolute path of the file
+	Line    int    // 1-based line number of the comment
+	Content string // Content of the line
+}
+
+// AnnotationConflict is a package annotated more than once in a file with differing current versions
+// A single package version would overwrite all of them, which may be unintended.
+type AnnotationConflict struct {
+	File        string       // Absolute path of the file
+	Package     string       // Package name shared by the annotations
+	Annotations []Annotation // Annotations of the package in the file, in line order
+}
+
+// ScanResult holds the annotations found in the files of an entrypoint
+type ScanResult struct {
+	Files       []string             // Files matching the configured extensions and excludes
+	Annotations []Annotation         // Annotated versions, in file and line order
+	Malformed   []MalformedComment   // Comments that look like depup comments but don't parse
+	Dangling    []Annotation         // Depup comments on their own line annotating no line, also listed in Annotations
+	NonStrict   []Annotation         // Annotated versions not written as strict semantic versions, only collected in strict mode
+	Conflicts   []AnnotationConflict // Packages annotated with differing versions in one file, only collected when deduplicating
+}
+
+// Scan reads the files an update of the entrypoint would consider and collects their annotations
+// Files of updaters that cannot describe their lines, such as JSON, are listed but not scanned
+func (u *Updater) Scan(entrypoint string) (*ScanResult, error) {
+	files, err := u.Discover(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{Files: files}
+	for _, file := range files {
+		fileAnnotations := len(result.Annotations)
+		updater, err := u.getFileUpdaterForPath(file)
+		if err != nil {
+			return nil, err
+		}
+		analyzer, ok := updater.(LineAnalyzer)
+		if !ok {
+			continue
+		}
+
+		analysis, err := analyzer.AnalyzeFile(file, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		dangling := danglingComments(analysis)
+		for i, line := range analysis {
+			if dangling[i] {
+				// Report the comment itself, so placeholders that were never filled in don't go unnoticed
+				annotation := Annotation{File: file, Line: line.Line, Package: firstPackage(line.Annotation), Content: line.Content, Updater: updater.Name()}
+				result.Annotations = append(result.Annotations, annotation)
+				result.Dangling = append(result.Dangling, annotation)
+			}
+			if line.Malformed {
+				result.Malformed = append(result.Malformed, MalformedComment{File: file, Line: line.Line, Content: line.Content})
+			}
+			// A depup comment of another package isn't the version of the comment next to it
+			if line.Package == "" || line.Version == "" && line.Annotation != "" && line.Annotation != line.Package {
+				continue
+			}
+
+			annotation := Annotation{File: file, Line: line.Line, Package: line.Package, Version: line.Version, Content: line.Content, Updater: updater.Name()}
+			// Zero-padded versions like 01.2.3 and short ones like 1.2 aren't found as semantic versions,
+			// strict mode reports them as written
+			if u.strictSemver && annotation.Version == "" {
+				match, ok := findSemverLeadingZeros(line.Content)
+				if !ok {
+					match, ok = findPartialVersion(line.Content)
+				}
+				if ok {
+					annotation.Version = strings.TrimSuffix(strings.TrimPrefix(match.text, match.startQuote), match.endQuote)
+				}
+			}
+			result.Annotations = append(result.Annotations, annotation)
+			if u.strictSemver && annotation.Version != "" && !isStrictSemverIn(line.Content, annotation.Version) {
+				result.NonStrict = append(result.NonStrict, annotation)
+			}
+		}
+
+		if u.dedupeAnnotations {
+			result.Conflicts = append(result.Conflicts, findAnnotationConflicts(file, result.Annotations[fileAnnotations:])...)
+		}
+	}
+
+	return result, nil
+}
+
+// UpdaterCount is the number of files and annotations handled by a FileUpdater
+type UpdaterCount struct {
+	Updater     string // Name of the FileUpdater
+	Files       int    // Files handled by the updater
+	Annotations int    // Annotations found in those files, including ignored ones
+}
+
+// CountByUpdater tallies the files and annotations of a scan result per FileUpdater
+// Counts are ordered by the number of annotations, then files, then updater name.
+func (u *Updater) CountByUpdater(result *ScanResult) ([]UpdaterCount, error) {
+	counts := map[string]*UpdaterCount{}
+	count := func(name string) *UpdaterCount {
+		if counts[name] == nil {
+			counts[name] = &UpdaterCount{Updater: name}
+		}
+		return counts[name]
+	}
+
+	for _, file := range result.Files {
+		updater, err := u.getFileUpdaterForPath(file)
+		if err != nil {
+			return nil, err
+		}
+		count(updater.Name()).Files++
+	}
+	for _, annotation := range result.Annotations {
+		count(annotation.Updater).Annotations++
+	}
+
+	tally := make([]UpdaterCount, 0, len(counts))
+	for _, c := range counts {
+		tally = append(tally, *c)
+	}
+	slices.SortFunc(tally, func(a, b UpdaterCount) int {
+		if a.Annotations != b.Annotations {
+			return b.Annotations - a.Annotations
+		}
+		if a.Files != b.Files {
+			return b.Files - a.Files
+		}
+		return strings.Compare(a.Updater, b.Updater)
+	})
+
+	return tally, nil
+}
+
+// danglingComments returns the indexes of depup comments on their own line that annotate no line at all,
+// i.e. neither a following line nor a block below them is annotated with their package before the next
+// depup comment or the end of the file
+func danglingComments(analysis []LineAnalysis) map[int]bool {
+	dangling := map[int]bool{}
+	for i, line := range analysis {
+		name := firstPackage(line.Annotation)
+		if name == "" || line.Package == name {
+			// No depup comment, or an inline comment annotating its own line
+			continue
+		}
+
+		associated := false
+		for _, next := range analysis[i+1:] {
+			if next.Line == line.Line {
+				continue
+			}
+			if next.Annotation != "" {
+				// Another depup comment ends the search, it may only hold the version itself
+				associated = next.Package == name && next.Version != ""
+				break
+			}
+			if next.Package == name {
+				associated = true
+				break
+			}
+		}
+		dangling[i] = !associated
+	}
+
+	return dangling
+}
+
+// firstPackage returns the first package of the comma separated package names of a depup comment
+func firstPackage(annotation string) string {
+	name, _, _ := strings.Cut(annotation, ",")
+	return name
+}
+
+// findAnnotationConflicts returns the packages annotated with differing versions among the annotations of a file
+// Annotations sharing the same version are a legitimate way to keep lines in sync and are not reported.
+func findAnnotationConflicts(file string, annotations []Annotation) []AnnotationConflict {
+	var conflicts []AnnotationConflict
+	var seen []string
+	for _, annotation := range annotations {
+		if annotation.Ignored() || slices.Contains(seen, annotation.Package) {
+			continue
+		}
+		seen = append(seen, annotation.Package)
+
+		conflict := AnnotationConflict{File: file, Package: annotation.Package}
+		differs := false
+		for _, other := range annotations {
+			if other.Package != annotation.Package || other.Ignored() {
+				continue
+			}
+			conflict.Annotations = append(conflict.Annotations, other)
+			differs = differs || other.Version != annotation.Version
+		}
+		if differs {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	return conflicts
+}
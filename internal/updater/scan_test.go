@@ -0,0 +1,251 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdater_Scan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml":    "# depup package=app\nimage: app:1.0.0\n# depup pakage=typo\n# depup package=db\ndb: TODO\nredis: 6.0.0 # depup package=redis\n",
+		"main.tf":     "# depup package=aws\nversion = \"4.0.0\"\n",
+		"config.json": "{\"version\": \"1.0.0\"}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	updater := NewUpdater()
+	result, err := updater.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	appPath := filepath.Join(dir, "app.yaml")
+	tfPath := filepath.Join(dir, "main.tf")
+	expected := &ScanResult{
+		Files: []string{appPath, filepath.Join(dir, "config.json"), tfPath},
+		Annotations: []Annotation{
+			{File: appPath, Line: 2, Package: "app", Version: "1.0.0", Content: "image: app:1.0.0", Updater: "yaml"},
+			{File: appPath, Line: 5, Package: "db", Content: "db: TODO", Updater: "yaml"},
+			{File: appPath, Line: 6, Package: "redis", Version: "6.0.0", Content: "redis: 6.0.0 # depup package=redis", Updater: "yaml"},
+			{File: tfPath, Line: 2, Package: "aws", Version: "4.0.0", Content: "version = \"4.0.0\"", Updater: "hcl"},
+		},
+		Malformed: []MalformedComment{
+			{File: appPath, Line: 3, Content: "# depup pakage=typo"},
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Scan() = %+v, want %+v", result, expected)
+	}
+}
+
+func TestUpdater_Scan_Unversioned(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []Annotation
+	}{
+		{
+			name:     "placeholder version",
+			content:  "# depup package=app\nversion: TODO\n",
+			expected: []Annotation{{Line: 2, Package: "app", Content: "version: TODO"}},
+		},
+		{
+			name:     "comment at the end of the file",
+			content:  "version: 1.0.0\n# depup package=app",
+			expected: []Annotation{{Line: 2, Package: "app", Content: "# depup package=app"}},
+		},
+		{
+			name:    "comment followed by another comment",
+			content: "# depup package=app\n# depup package=db\ndb: 5.0.0\n",
+			expected: []Annotation{
+				{Line: 1, Package: "app", Content: "# depup package=app"},
+				{Line: 3, Package: "db", Version: "5.0.0", Content: "db: 5.0.0"},
+			},
+		},
+		{
+			name:    "comment followed by an inline comment",
+			content: "# depup package=app,sidecar\ndb: 5.0.0 # depup package=db\n",
+			expected: []Annotation{
+				{Line: 1, Package: "app", Content: "# depup package=app,sidecar"},
+				{Line: 2, Package: "db", Version: "5.0.0", Content: "db: 5.0.0 # depup package=db"},
+			},
+		},
+		{
+			name:     "version within a block scalar",
+			content:  "# depup package=app\nscript: |\n  curl -LO https://example.com/app-1.2.3.tar.gz\n",
+			expected: []Annotation{{Line: 3, Package: "app", Version: "1.2.3", Content: "  curl -LO https://example.com/app-1.2.3.tar.gz"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath, err := createTempFileWithContent(tt.content, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := NewUpdater().Scan(filePath)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			for i := range tt.expected {
+				tt.expected[i].File = filePath
+				tt.expected[i].Updater = "yaml"
+			}
+			if !reflect.DeepEqual(result.Annotations, tt.expected) {
+				t.Errorf("Scan() annotations = %+v, want %+v", result.Annotations, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdater_Scan_StrictSemver(t *testing.T) {
+	filePath, err := createTempFileWithContent("# depup package=a\na: 1.2.3\n# depup package=b\nb: 01.2.3\n"+
+		"# depup package=c\nc: 1.2.3.4\n# depup package=d scheme=partial\nd: 1.2\n# depup package=e\ne: 1.2\n", ".yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	tests := []struct {
+		name     string
+		strict   bool
+		expected []string
+	}{
+		{name: "lenient", strict: false, expected: nil},
+		{name: "strict", strict: true, expected: []string{"b", "c", "d", "e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewUpdater(WithStrictSemver(tt.strict)).Scan(filePath)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			var nonStrict []string
+			for _, annotation := range result.NonStrict {
+				nonStrict = append(nonStrict, annotation.Package)
+			}
+			if !reflect.DeepEqual(nonStrict, tt.expected) {
+				t.Errorf("Scan() non-strict packages = %v, want %v", nonStrict, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdater_Scan_DedupeAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		dedupe   bool
+		expected map[string][]int
+	}{
+		{
+			name:     "differing versions",
+			content:  "# depup package=app\napi: app:1.0.0\n# depup package=app\nworker: app:1.1.0\n",
+			dedupe:   true,
+			expected: map[string][]int{"app": {2, 4}},
+		},
+		{
+			name:     "shared version",
+			content:  "# depup package=app\napi: app:1.0.0\n# depup package=app\nworker: app:1.0.0\n",
+			dedupe:   true,
+			expected: map[string][]int{},
+		},
+		{
+			name:     "line without version",
+			content:  "# depup package=app\napi: app:1.0.0\n# depup package=app\nworker: TODO\n",
+			dedupe:   true,
+			expected: map[string][]int{},
+		},
+		{
+			name:     "disabled",
+			content:  "# depup package=app\napi: app:1.0.0\n# depup package=app\nworker: app:1.1.0\n",
+			dedupe:   false,
+			expected: map[string][]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath, err := createTempFileWithContent(tt.content, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := NewUpdater(WithDedupeAnnotations(tt.dedupe)).Scan(filePath)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			conflicts := map[string][]int{}
+			for _, conflict := range result.Conflicts {
+				for _, annotation := range conflict.Annotations {
+					conflicts[conflict.Package] = append(conflicts[conflict.Package], annotation.Line)
+				}
+			}
+			if !reflect.DeepEqual(conflicts, tt.expected) {
+				t.Errorf("Scan() conflicts = %v, want %v", conflicts, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdater_CountByUpdater(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml":      "# depup package=app\nimage: app:1.0.0\nredis: 6.0.0 # depup package=redis\n",
+		"db.yml":        "# depup package=db\ndb: TODO\n",
+		"plain.yaml":    "name: plain\n",
+		"infra/main.tf": "# depup package=aws\nversion = \"4.0.0\"\n",
+		"infra/vars.tf": "# depup package=gcp\nversion = \"5.0.0\"\n",
+		".env":          "# depup package=node\nNODE_VERSION=20.1.0\n",
+		"config.json":   "{\"version\": \"1.0.0\"}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	updater := NewUpdater(WithRecursive(true))
+	result, err := updater.Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	counts, err := updater.CountByUpdater(result)
+	if err != nil {
+		t.Fatalf("CountByUpdater() error = %v", err)
+	}
+
+	expected := []UpdaterCount{
+		{Updater: "yaml", Files: 3, Annotations: 3},
+		{Updater: "hcl", Files: 2, Annotations: 2},
+		{Updater: "dotenv", Files: 1, Annotations: 1},
+		{Updater: "json", Files: 1, Annotations: 0},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("CountByUpdater() = %+v, want %+v", counts, expected)
+	}
+}
+
+func TestUpdater_Scan_MissingEntrypoint(t *testing.T) {
+	if _, err := NewUpdater().Scan(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Scan() expected error for missing entrypoint")
+	}
+}
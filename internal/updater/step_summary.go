@@ -0,0 +1,42 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReportStepSummary writes a Markdown table of the changes, e.g. for the job summary of a GitHub Actions step
+// The rows follow the grouping of the change report, and the table ends with a blank line so summaries can be appended.
+func (r *Reporter) ReportStepSummary(changes []Change, dryRun bool) {
+	title := "### depup"
+	if dryRun {
+		title += " (dry run)"
+	}
+	fmt.Fprintf(r.out, "%s\n\n", title)
+
+	if len(changes) == 0 {
+		fmt.Fprint(r.out, "No versions were updated.\n\n")
+		return
+	}
+
+	rows := changes
+	if r.options.GroupBy == GroupByPackage {
+		rows = nil
+		for _, group := range groupChangesByPackage(changes) {
+			rows = append(rows, group...)
+		}
+	}
+
+	fmt.Fprintln(r.out, "| Package | Old version | New version | File | Line |")
+	fmt.Fprintln(r.out, "| --- | --- | --- | --- | ---: |")
+	for _, change := range rows {
+		fmt.Fprintf(r.out, "| %s | %s | %s | %s | %d |\n", markdownCell(change.Package), markdownCell(change.OldVersion),
+			markdownCell(change.NewVersion), markdownCell(r.displayPath(change.File)), change.Line)
+	}
+	fmt.Fprintln(r.out)
+}
+
+// markdownCell escapes the pipes of a value, so it stays within its cell of a Markdown table
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
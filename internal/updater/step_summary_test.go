@@ -0,0 +1,60 @@
+package updater
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReporter_ReportStepSummary(t *testing.T) {
+	changes := []Change{
+		{File: "/repo/a.yaml", Line: 2, Package: "redis", OldVersion: "6.0.0", NewVersion: "7.0.0"},
+		{File: "/repo/a.yaml", Line: 5, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		{File: "/repo/b|c.tf", Line: 3, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+	}
+
+	tests := []struct {
+		name     string
+		changes  []Change
+		groupBy  string
+		dryRun   bool
+		expected string
+	}{
+		{
+			name:    "grouped by file",
+			changes: changes,
+			expected: "### depup\n\n" +
+				"| Package | Old version | New version | File | Line |\n" +
+				"| --- | --- | --- | --- | ---: |\n" +
+				"| redis | 6.0.0 | 7.0.0 | a.yaml | 2 |\n" +
+				"| app | 1.0.0 | 2.0.0 | a.yaml | 5 |\n" +
+				"| app | 1.0.0 | 2.0.0 | b\\|c.tf | 3 |\n\n",
+		},
+		{
+			name:    "grouped by package in a dry run",
+			changes: changes,
+			groupBy: GroupByPackage,
+			dryRun:  true,
+			expected: "### depup (dry run)\n\n" +
+				"| Package | Old version | New version | File | Line |\n" +
+				"| --- | --- | --- | --- | ---: |\n" +
+				"| app | 1.0.0 | 2.0.0 | a.yaml | 5 |\n" +
+				"| app | 1.0.0 | 2.0.0 | b\\|c.tf | 3 |\n" +
+				"| redis | 6.0.0 | 7.0.0 | a.yaml | 2 |\n\n",
+		},
+		{
+			name:     "no changes",
+			expected: "### depup\n\nNo versions were updated.\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			NewReporter(&out, ReportOptions{BaseDir: "/repo", GroupBy: tt.groupBy}).ReportStepSummary(tt.changes, tt.dryRun)
+
+			if out.String() != tt.expected {
+				t.Errorf("ReportStepSummary() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
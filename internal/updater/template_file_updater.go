@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// templateTagPattern matches Jinja and Go template expressions and statements on a single line
+var /* const */ templateTagPattern = regexp.MustCompile(`\{\{.*?\}\}|\{%.*?%\}`)
+
+// templateTagPlaceholder stands in for a template tag while a line is processed
+const templateTagPlaceholder = "\x00"
+
+// TemplateFileUpdater updates templates of YAML files, e.g. Ansible .yaml.j2 files
+// Lines are processed like YAML, but template tags ({{ ... }} and {% ... %}) are never changed
+type TemplateFileUpdater struct {
+	supportedFileExtensions map[string]struct{}
+	yaml                    *YamlFileUpdater
+}
+
+func NewTemplateFileUpdater() *TemplateFileUpdater {
+	return &TemplateFileUpdater{
+		supportedFileExtensions: map[string]struct{}{
+			".j2":     {},
+			".jinja":  {},
+			".jinja2": {},
+			".tmpl":   {},
+		},
+		yaml: NewYamlFileUpdater(),
+	}
+}
+
+func (u *TemplateFileUpdater) Name() string {
+	return "template"
+}
+
+func (u *TemplateFileUpdater) Supports(fileExtension string) bool {
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
+}
+
+func (u *TemplateFileUpdater) GetSupportedExtensions() []string {
+	extensions := make([]string, 0, len(u.supportedFileExtensions))
+	for ext := range u.supportedFileExtensions {
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+func (u *TemplateFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	// Read file and prepare data
+	lines, format, err := readFileLines(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Process lines with the YAML options of this run and build output
+	yaml := *u.yaml
+	yaml.quoteStyle = options.QuoteStyle
+	yaml.scheme = options.Scheme
+	yaml.canonical = options.CanonicalVersions
+	yaml.leadingZeros = options.AllowLeadingZeros
+	yaml.prefixAuto = options.VersionPrefixAuto
+	yaml.updateMode = options.UpdateMode
+	processor := *u
+	processor.yaml = &yaml
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
+
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
+		err = os.WriteFile(filePath, []byte(outputContent), 0644)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+		}
+	}
+
+	return outputContent, changes, nil
+}
+
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *TemplateFileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
+
+// parseDepupComment returns the directive of a depup comment found outside of template tags
+func (u *TemplateFileUpdater) parseDepupComment(line string) (directive, bool) {
+	masked, _ := maskTemplateTags(line)
+	return u.yaml.parseDepupComment(masked)
+}
+
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *TemplateFileUpdater) depupComment(packageName string) string {
+	return u.yaml.depupComment(packageName)
+}
+
+// depupComments returns the syntax depup comments are recognized by
+func (u *TemplateFileUpdater) depupComments() commentSyntax {
+	return u.yaml.depupComments()
+}
+
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *TemplateFileUpdater) setDepupComments(comments commentSyntax) {
+	u.yaml.setDepupComments(comments)
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *TemplateFileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	masked, tags := maskTemplateTags(line)
+	return restoreTemplateTags(u.yaml.processInlineDepupComment(masked, packages), line, tags)
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *TemplateFileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	maskedComment, _ := maskTemplateTags(commentLine)
+	masked, tags := maskTemplateTags(currentLine)
+	return restoreTemplateTags(u.yaml.processSeparateLineDepupComment(maskedComment, masked, packages), currentLine, tags)
+}
+
+// maskTemplateTags replaces every template tag of the line with a placeholder
+// Returns the masked line and the replaced tags in order
+func maskTemplateTags(line string) (string, []string) {
+	tags := templateTagPattern.FindAllString(line, -1)
+	if len(tags) == 0 {
+		return line, nil
+	}
+
+	return templateTagPattern.ReplaceAllLiteralString(line, templateTagPlaceholder), tags
+}
+
+// restoreTemplateTags puts the template tags back into the processed line
+// If the update touched a placeholder, the line is left unchanged so no tag is ever modified
+func restoreTemplateTags(result lineResult, line string, tags []string) lineResult {
+	return restorePlaceholders(result, line, templateTagPlaceholder, tags)
+}
+
+// restorePlaceholders puts the masked values back into the processed line in order
+// If the update touched a placeholder, the line is left unchanged so no masked value is ever modified
+func restorePlaceholders(result lineResult, line, placeholder string, values []string) lineResult {
+	if !result.changed() {
+		result.line = line
+		return result
+	}
+
+	if strings.Count(result.line, placeholder) != len(values) {
+		return lineResult{line: line, packageName: result.packageName, version: result.version}
+	}
+
+	for _, value := range values {
+		result.line = strings.Replace(result.line, placeholder, value, 1)
+	}
+
+	return result
+}
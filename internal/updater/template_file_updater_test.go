@@ -0,0 +1,130 @@
+package updater
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTemplateFileUpdater_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		packages       []Package
+		options        FileUpdaterOptions
+		expectedOutput string
+		expectUpdated  bool
+		expectError    bool
+	}{
+		{
+			name:           "Version next to a Jinja expression",
+			fileContent:    "# depup package=app\nimage: {{ registry }}/app:1.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app\nimage: {{ registry }}/app:2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Version inside a Jinja expression is not changed",
+			fileContent:    "# depup package=app\nimage: app:{{ app_version | default('1.0.0') }}\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app\nimage: app:{{ app_version | default('1.0.0') }}\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Version after an expression holding the same version",
+			fileContent:    "image: {{ '1.0.0' }} app:1.0.0 # depup package=app\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "image: {{ '1.0.0' }} app:2.0.0 # depup package=app\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Hash inside an expression is not a comment",
+			fileContent:    "tag: 1.0.0 {{ '#' }} # depup package=app\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "tag: 2.0.0 {{ '#' }} # depup package=app\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Version in a statement is not changed",
+			fileContent:    "{% set version = '1.0.0' %} # depup package=app\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "{% set version = '1.0.0' %} # depup package=app\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Jinja comment as annotation",
+			fileContent:    "{# depup package=app #}\nversion: 1.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "{# depup package=app #}\nversion: 2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive touching an expression is skipped",
+			fileContent:    "# depup package=app replace=newrepo/app:{version}\nimage: {{ registry }}/app:1.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=newrepo/app:{version}\nimage: {{ registry }}/app:1.0.0\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temp file with test content
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".j2")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			updater := NewTemplateFileUpdater()
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			content, err := os.ReadFile(tempFile)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(content) != tt.expectedOutput {
+				t.Errorf("File content = %q, expectedOutput %q", string(content), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestTemplateFileUpdater_Supports(t *testing.T) {
+	updater := NewTemplateFileUpdater()
+
+	for _, ext := range []string{".j2", ".jinja", ".jinja2", ".tmpl"} {
+		if !updater.Supports(ext) {
+			t.Errorf("Supports(%q) = false, expected true", ext)
+		}
+	}
+	if updater.Supports(".yaml") {
+		t.Error("Supports(\".yaml\") = true, expected false")
+	}
+}
@@ -1,24 +1,52 @@
 package updater
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 )
 
-var /* const */ namePattern = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
-var /* const */ versionPattern = regexp.MustCompile(`(["']?)(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?(["']?)`)
+var /* const */ namePattern = regexp.MustCompile(`^(?:@[a-zA-Z0-9-][a-zA-Z0-9._~-]*/)?[a-zA-Z0-9-][a-zA-Z0-9._~-]*$`)
+var /* const */ versionPattern = regexp.MustCompile(`((?:["'][ \t]*)?)(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?((?:[ \t]*["'])?)`)
+
+// composeVersion builds the version string from the submatches of versionPattern
+func composeVersion(versionMatches []string) string {
+	version := versionMatches[2] + "." + versionMatches[3] + "." + versionMatches[4]
+	if prerelease := versionMatches[5]; prerelease != "" {
+		version += "-" + prerelease
+	}
+	if buildmetadata := versionMatches[6]; buildmetadata != "" {
+		version += "+" + buildmetadata
+	}
+
+	return version
+}
 
 // Package represents a dependency package with a name and version
 // to be updated in configuration files
 type Package struct {
 	Name    string // Name of the package identifier
 	Version string // Version of the package (semantic version format)
+	File    string // Glob restricting the package to matching files (relative path or base name), empty matches all files
+	Source  string // Origin of the version (SourceFlag, SourceConfig or SourceResolver followed by the datasource), reported with changes
 }
 
+// Origins of package versions
+const (
+	SourceFlag     = "flag"      // Passed on the command line
+	SourceConfig   = "config"    // Literal version from the configuration file
+	SourceResolver = "resolver:" // Resolved from a datasource, followed by the datasource name
+)
+
 func (p *Package) String() string {
 	return fmt.Sprintf("%s=%s", p.Name, p.Version)
 }
@@ -26,12 +54,15 @@ func (p *Package) String() string {
 func (p *Package) Validate() error {
 	var errs []error
 
-	if !versionPattern.MatchString(p.Version) {
+	if !versionPattern.MatchString(p.Version) && !isIntegerVersion(p.Version) {
 		errs = append(errs, fmt.Errorf("invalid version format: %s", p.Version))
 	}
 	if !namePattern.MatchString(p.Name) {
 		errs = append(errs, fmt.Errorf("invalid name format: %s", p.Name))
 	}
+	if _, err := path.Match(filepath.ToSlash(p.File), ""); err != nil {
+		errs = append(errs, fmt.Errorf("invalid file pattern %s: %w", p.File, err))
+	}
 	if len(errs) == 0 {
 		return nil
 	}
@@ -39,14 +70,72 @@ func (p *Package) Validate() error {
 	return errors.Join(errs...)
 }
 
+// IsVersion reports whether the value is a complete version depup can write, e.g. 1.2.3, v1.2.3-rc.1 or 20231201
+// Unlike Validate, values that merely contain a version, like 1.2.3.post1, are rejected
+func IsVersion(value string) bool {
+	_, version := splitVersionPrefix(value)
+	match, ok := findSemver(version)
+	return ok && match.text == version || isIntegerVersion(version)
+}
+
+// ValidateStrict validates the package like Validate and additionally requires a strict semantic version:
+// exactly three components without leading zeros, optionally followed by pre-release and build metadata
+func (p *Package) ValidateStrict() error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if !isStrictSemver(p.Version) {
+		return fmt.Errorf("version %s is not a strict semantic version (MAJOR.MINOR.PATCH without leading zeros)", p.Version)
+	}
+
+	return nil
+}
+
+// hasPackage reports whether a package with the given name is in the list
+func hasPackage(packages []Package, name string) bool {
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 // FileUpdaterOptions contains configuration for file update operations
 type FileUpdaterOptions struct {
-	DryRun bool // When true, changes are not written to files
+	DryRun                 bool       // When true, changes are not written to files
+	LineEnding             string     // Line ending for written files ("\n" or "\r\n"), empty preserves the existing one
+	FinalNewline           *bool      // Whether written files end with a newline, nil preserves the existing state
+	Charset                string     // Charset for written files ("utf-8" or "utf-8-bom"), empty preserves the existing one
+	ForceWrite             bool       // When true, files with annotated versions are written even if no version changed
+	CanonicalVersions      bool       // When true, versions with the same semver precedence are equal, e.g. "1.2" and "1.2.0"
+	AllowLeadingZeros      bool       // When true, semantic versions may have zero-padded components, e.g. "01.2.3"
+	UpdateMode             string     // Treatment of constraint operators (UpdateModeRange, UpdateModeConstraint, UpdateModePin), empty selects UpdateModeRange
+	VersionPrefixAuto      bool       // When true, the prefix of the version in the file, e.g. "v" or "release-", is kept for the new version
+	Scheme                 string     // Version scheme for comments without a scheme attribute (SchemeSemver, SchemePartial, SchemeInteger or SchemeGomod), empty selects SchemeSemver
+	QuoteStyle             string     // Quoting of updated YAML versions (QuoteStyleDouble, QuoteStyleSingle, QuoteStyleNone), empty preserves the existing quotes
+	CommentPosition        string     // Position of depup comments on their own line (CommentPositionAbove or CommentPositionBelow), empty selects CommentPositionAbove
+	SyncCommentVersion     bool       // When true, the version attribute of depup comments is set to the new version
+	NestedVersions         bool       // When true, an annotated YAML mapping key without a value annotates its first version-like child
+	ErrorOnMultipleMatches bool       // When true, updating a line holding several versions for a single package fails
+	QuarantineOnConflict   bool       // When true, lines holding several versions for a single package are left unchanged and reported as skipped
+	OnSkip                 func(Skip) // Called for every annotated version a guard of its depup comment leaves unchanged, may be nil
+}
+
+// reportSkip passes the skip to OnSkip, if set
+func (o FileUpdaterOptions) reportSkip(skip Skip) {
+	if o.OnSkip != nil {
+		o.OnSkip(skip)
+	}
 }
 
 // FileUpdater is an interface that defines the behavior of a concrete updater
 // Implementations handle different file formats (yaml, json, etc.)
 type FileUpdater interface {
+	// Name returns a short name identifying the updater in diagnostics
+	Name() string
+
 	// Supports checks if the updater supports the given file extension
 	Supports(fileExtension string) bool
 
@@ -54,8 +143,32 @@ type FileUpdater interface {
 	GetSupportedExtensions() []string
 
 	// UpdateFile updates the dependencies in the specified file
-	// Returns the updated content, whether changes were made, and any error
-	UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, bool, error)
+	// Returns the updated content, the changes that were made, and any error
+	UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error)
+}
+
+// supportsExtension reports whether the file extension is one of the supported extensions
+// Supported extensions may be glob patterns, matched against the extension itself and against a file name
+// with that extension, so ".tf*" supports ".tfvars" as well
+func supportsExtension(supportedExtensions map[string]struct{}, fileExtension string) bool {
+	if _, ok := supportedExtensions[fileExtension]; ok {
+		return true
+	}
+
+	fileName := "file" + fileExtension
+	for pattern := range supportedExtensions {
+		if !strings.ContainsAny(pattern, "*?[") {
+			continue
+		}
+		if matched, err := filepath.Match(pattern, fileExtension); err == nil && matched {
+			return true
+		}
+		if matched, err := filepath.Match(pattern, fileName); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Option represents a function that configures the Updater
@@ -80,9 +193,277 @@ func WithRecursive(recursive bool) Option {
 
 // WithFileExtensions specifies which file extensions to process
 // This restricts the updater to only handle files with the specified extensions
+// Runs fail before scanning any file if one of the extensions has no updater.
 func WithFileExtensions(extensions []string) Option {
 	return func(u *Updater) {
 		u.fileExtensions = extensions
+		u.checkExtensions = true
+	}
+}
+
+// WithExcludes sets glob patterns of files and directories to skip when scanning a directory
+// Patterns are matched against the path relative to the scanned directory and against the base name
+func WithExcludes(patterns []string) Option {
+	return func(u *Updater) {
+		u.excludes = patterns
+	}
+}
+
+// WithOutput sets the writer used for reporting changes and dry-run content
+func WithOutput(out io.Writer) Option {
+	return func(u *Updater) {
+		u.out = out
+	}
+}
+
+// WithRelativePaths configures the updater to report paths relative to the working directory
+// Files are still processed using absolute paths
+func WithRelativePaths(relativePaths bool) Option {
+	return func(u *Updater) {
+		u.relativePaths = relativePaths
+	}
+}
+
+// WithRespectEditorConfig configures the updater to apply .editorconfig rules to written files
+// When enabled, end_of_line, insert_final_newline and charset override the existing file layout
+func WithRespectEditorConfig(respectEditorConfig bool) Option {
+	return func(u *Updater) {
+		u.respectEditorConfig = respectEditorConfig
+	}
+}
+
+// WithGroupBy sets how the change report is grouped (GroupByFile or GroupByPackage)
+func WithGroupBy(groupBy string) Option {
+	return func(u *Updater) {
+		u.groupBy = groupBy
+	}
+}
+
+// WithForceWrite configures the updater to rewrite annotated files even if no version changed
+// Files without an annotation for one of the packages are never written
+func WithForceWrite(forceWrite bool) Option {
+	return func(u *Updater) {
+		u.forceWrite = forceWrite
+	}
+}
+
+// WithTransactional configures the updater to compute all updates in memory before writing any file
+// Files are only written if every file could be updated, and written files are restored if a later write fails.
+// It cannot be combined with WithForceWrite, unchanged files are never written.
+func WithTransactional(transactional bool) Option {
+	return func(u *Updater) {
+		u.transactional = transactional
+	}
+}
+
+// WithVerify configures the updater to read every written file back and fail if it differs from the expected content
+// or lacks a new version, catching filesystem or encoding surprises
+func WithVerify(verify bool) Option {
+	return func(u *Updater) {
+		u.verify = verify
+	}
+}
+
+// WithScheme sets the version scheme used for depup comments without a scheme attribute
+func WithScheme(scheme string) Option {
+	return func(u *Updater) {
+		u.scheme = scheme
+	}
+}
+
+// WithReportFormat sets the format of the report (FormatText, FormatJSON, FormatSARIF, FormatLines or FormatJSONLines)
+func WithReportFormat(format string) Option {
+	return func(u *Updater) {
+		u.reportFormat = format
+	}
+}
+
+// WithShowSource configures text reports to state where each new version came from
+func WithShowSource(showSource bool) Option {
+	return func(u *Updater) {
+		u.showSource = showSource
+	}
+}
+
+// WithStrictSemver configures the updater to reject package versions that aren't strict semantic versions
+// Scans additionally report annotated versions in files that aren't written as strict semantic versions
+func WithStrictSemver(strictSemver bool) Option {
+	return func(u *Updater) {
+		u.strictSemver = strictSemver
+	}
+}
+
+// WithDedupeAnnotations configures scans to report packages annotated on lines with differing versions in one file
+func WithDedupeAnnotations(dedupeAnnotations bool) Option {
+	return func(u *Updater) {
+		u.dedupeAnnotations = dedupeAnnotations
+	}
+}
+
+// WithProgress configures the updater to write the position and path of every processed file to w
+// Progress is written separately from reports, e.g. to stderr, and a nil writer disables it
+func WithProgress(w io.Writer) Option {
+	return func(u *Updater) {
+		u.progress = w
+	}
+}
+
+// WithMaxFileSize configures the updater to skip files larger than size bytes with a warning
+// This protects against reading huge files matched by accident, a size of 0 disables the limit
+func WithMaxFileSize(size int64) Option {
+	return func(u *Updater) {
+		u.maxFileSize = size
+	}
+}
+
+// WithWarnings configures where warnings like skipped files are written, os.Stderr by default
+// A nil writer discards warnings
+func WithWarnings(w io.Writer) Option {
+	return func(u *Updater) {
+		u.warnings = w
+	}
+}
+
+// WithCompactJSON configures JSON reports to be written on a single line instead of indented
+func WithCompactJSON(compactJSON bool) Option {
+	return func(u *Updater) {
+		u.compactJSON = compactJSON
+	}
+}
+
+// WithPrettyJSON configures JSON reports to be indented, including JSON lines reports, which are compact by default
+// WithCompactJSON takes precedence if both are set
+func WithPrettyJSON(prettyJSON bool) Option {
+	return func(u *Updater) {
+		u.prettyJSON = prettyJSON
+	}
+}
+
+// WithSARIFLevel sets the level of results in SARIF reports, e.g. SARIFLevelError to fail code scanning
+func WithSARIFLevel(level string) Option {
+	return func(u *Updater) {
+		u.sarifLevel = level
+	}
+}
+
+// WithCanonicalVersions configures the updater to treat versions with the same semver precedence as equal
+// Missing components count as zero and build metadata is ignored, so "1.2" is not rewritten to "1.2.0"
+func WithCanonicalVersions(canonical bool) Option {
+	return func(u *Updater) {
+		u.canonicalVersions = canonical
+	}
+}
+
+// WithAllowLeadingZeros configures the updater to find versions with zero-padded components, e.g. "01.2.3"
+// Such versions aren't semantic versions and are left alone by default. The padding is dropped when they are updated.
+func WithAllowLeadingZeros(allow bool) Option {
+	return func(u *Updater) {
+		u.allowLeadingZeros = allow
+	}
+}
+
+// WithUpdateMode sets how updates of YAML and HCL constraints like ">= 4.0.0, < 5.0.0" treat their operators
+// UpdateModeRange, the default, only updates the lower bound. UpdateModeConstraint replaces the constraint by the
+// updated lower bound with its operator, and UpdateModePin by the new version alone. The mode attribute of
+// depup comments overrides it, e.g. "# depup package=aws mode=pin".
+func WithUpdateMode(mode string) Option {
+	return func(u *Updater) {
+		u.updateMode = mode
+	}
+}
+
+// WithFileMode sets the permissions of the files an update writes, e.g. 0644 regardless of their current mode
+// A zero mode keeps the permissions of each file, which is the default.
+func WithFileMode(mode os.FileMode) Option {
+	return func(u *Updater) {
+		u.fileMode = mode
+	}
+}
+
+// WithVersionPrefixAuto configures the updater to keep a short prefix of annotated versions, e.g. "v" or "release-"
+// The prefix found in the file is applied to the new version, replacing any prefix of the package version
+func WithVersionPrefixAuto(prefixAuto bool) Option {
+	return func(u *Updater) {
+		u.versionPrefixAuto = prefixAuto
+	}
+}
+
+// WithSyncCommentVersion configures the updater to set the version attribute of depup comments to the new version,
+// e.g. # depup package=app version=1.2.3 follows the annotated value. Comments without the attribute are left as is.
+func WithSyncCommentVersion(syncCommentVersion bool) Option {
+	return func(u *Updater) {
+		u.syncCommentVersion = syncCommentVersion
+	}
+}
+
+// WithNestedVersions configures the updater to look for the version of an annotated YAML mapping key without a
+// value in its children, e.g. the tag: key nested below an annotated image: key
+func WithNestedVersions(nestedVersions bool) Option {
+	return func(u *Updater) {
+		u.nestedVersions = nestedVersions
+	}
+}
+
+// WithErrorOnMultipleMatches configures the updater to fail instead of updating the first of several versions
+// on a line annotated with a single package. Lines naming a package for each version are not ambiguous.
+func WithErrorOnMultipleMatches(errorOnMultipleMatches bool) Option {
+	return func(u *Updater) {
+		u.errorOnMultipleMatches = errorOnMultipleMatches
+	}
+}
+
+// WithQuarantineOnConflict configures the updater to write updates it can't apply confidently to a .rej file
+// next to the file instead, like patch does. Lines holding several versions for a single package and lines
+// a guard of their depup comment keeps unchanged are left intact and reported as skipped.
+func WithQuarantineOnConflict(quarantineOnConflict bool) Option {
+	return func(u *Updater) {
+		u.quarantineOnConflict = quarantineOnConflict
+	}
+}
+
+// WithQuoteStyle sets the quoting applied to YAML versions that are updated
+// An empty style preserves the existing quotes
+func WithQuoteStyle(quoteStyle string) Option {
+	return func(u *Updater) {
+		u.quoteStyle = quoteStyle
+	}
+}
+
+// WithCommentPosition sets whether depup comments on their own line annotate the version above or below them
+// An empty position selects CommentPositionAbove
+func WithCommentPosition(position string) Option {
+	return func(u *Updater) {
+		u.commentPosition = position
+	}
+}
+
+// WithCommentPrefixes replaces the comment prefixes updaters recognize depup comments by, keyed by updater name,
+// e.g. {"dotenv": {";"}} for INI-style files. The first prefix starts comments written by Annotate.
+// Unknown names and updaters without comments, like the JSON updater, are ignored.
+func WithCommentPrefixes(prefixes map[string][]string) Option {
+	return func(u *Updater) {
+		for _, updater := range u.updaters {
+			commented, ok := updater.(commentSyntaxUpdater)
+			if ok && len(prefixes[updater.Name()]) > 0 {
+				commented.setDepupComments(commented.depupComments().withPrefixes(prefixes[updater.Name()]))
+			}
+		}
+	}
+}
+
+// WithCommentRegex replaces the built-in depup comment syntax of all updaters with a custom pattern, e.g. @dep\{(\S+)\}
+// The first capture group of the pattern holds the package name, and attributes like scheme=partial may follow
+// the match. A nil pattern keeps the built-in syntax. Comments written by Annotate keep the built-in syntax.
+func WithCommentRegex(pattern *regexp.Regexp) Option {
+	return func(u *Updater) {
+		if pattern == nil {
+			return
+		}
+		for _, updater := range u.updaters {
+			if commented, ok := updater.(commentSyntaxUpdater); ok {
+				commented.setDepupComments(commented.depupComments().withPattern(pattern))
+			}
+		}
 	}
 }
 
@@ -93,9 +474,50 @@ type Updater struct {
 	updaters []FileUpdater
 
 	// configuration options
-	dryRun         bool     // When true, changes are not written to files
-	recursive      bool     // When true, subdirectories are processed
-	fileExtensions []string // List of file extensions to consider for updates
+	dryRun                 bool     // When true, changes are not written to files
+	recursive              bool     // When true, subdirectories are processed
+	fileExtensions         []string // List of file extensions to consider for updates
+	checkExtensions        bool     // When true, runs fail up front if a file extension has no updater
+	excludes               []string // Glob patterns of files and directories to skip
+	root                   string   // Directory of the current run, file patterns are relative to it
+	relativePaths          bool     // When true, reported paths are relative to the working directory
+	groupBy                string   // Grouping of the change report
+	reportFormat           string   // Format of the report, FormatText if empty
+	showSource             bool     // When true, text reports state where each new version came from
+	compactJSON            bool     // When true, JSON reports are written on a single line
+	prettyJSON             bool     // When true, JSON reports are indented, including JSON lines reports
+	sarifLevel             string   // Level of SARIF results, SARIFLevelWarning if empty
+	forceWrite             bool     // When true, annotated files are written even if unchanged
+	transactional          bool     // When true, files are only written if all of them can be updated
+	verify                 bool     // When true, written files are read back and compared with the expected content
+	scheme                 string   // Default version scheme of depup comments
+	canonicalVersions      bool     // When true, versions with the same semver precedence are not rewritten
+	allowLeadingZeros      bool     // When true, zero-padded versions like 01.2.3 are found and updated
+	updateMode             string   // Treatment of constraint operators, empty only updates lower bounds
+	versionPrefixAuto      bool     // When true, prefixes of annotated versions are kept for new versions
+	syncCommentVersion     bool     // When true, the version attribute of depup comments follows the new version
+	nestedVersions         bool     // When true, annotated YAML mapping keys annotate the version of a nested child
+	errorOnMultipleMatches bool     // When true, updating one of several versions of a line fails
+	quarantineOnConflict   bool     // When true, updates that can't be applied confidently are written to .rej files
+	strictSemver           bool     // When true, only strict semantic versions are accepted
+	dedupeAnnotations      bool     // When true, scans report packages annotated with differing versions in one file
+	quoteStyle             string   // Quoting of updated YAML versions, empty preserves the existing quotes
+	commentPosition        string   // Position of depup comments on their own line relative to the version
+	maxFileSize            int64    // Files larger than this many bytes are skipped, 0 disables the limit
+
+	respectEditorConfig bool        // When true, .editorconfig rules are applied to written files
+	fileMode            os.FileMode // Permissions written files are set to, 0 keeps their permissions
+
+	// reporting
+	out      io.Writer // Destination for reports and dry-run output
+	progress io.Writer // Destination for per-file progress, nil disables it
+	warnings io.Writer // Destination for warnings like skipped files, nil discards them
+	planned  *Plan     // Files changed by the current run
+	changes  []Change  // Changes collected during the last run
+
+	// synchronization
+	fileLocks fileLocks  // Ensures a single writer per file
+	mu        sync.Mutex // Guards changes and the plan
 }
 
 // NewUpdater creates a new instance of the Updater with the provided options
@@ -106,11 +528,19 @@ func NewUpdater(options ...Option) *Updater {
 			NewYamlFileUpdater(),
 			NewHclFileUpdater(),
 			NewDotEnvFileUpdater(),
+			NewTemplateFileUpdater(),
+			NewPackageJsonUpdater(),
+			NewGemfileUpdater(),
+			NewConfFileUpdater(),
+			NewBazelUpdater(),
+			NewJsonFileUpdater(),
 		},
 		// Default values
 		dryRun:         false,
 		recursive:      true,
 		fileExtensions: []string{},
+		out:            os.Stdout,
+		warnings:       os.Stderr,
 	}
 
 	for _, updater := range u.updaters {
@@ -128,100 +558,536 @@ func NewUpdater(options ...Option) *Updater {
 // Update processes the entrypoint (file or directory) and updates dependencies
 // based on the provided packages list and configuration options
 func (u *Updater) Update(entrypoint string, packages []Package) error {
+	return u.UpdateContext(context.Background(), entrypoint, packages)
+}
+
+// UpdateContext is like Update, but stops scanning and processing files once the context is done
+// Files that were already written stay written and are reported
+func (u *Updater) UpdateContext(ctx context.Context, entrypoint string, packages []Package) error {
+	reporter, err := u.newReporter()
+	if err != nil {
+		return err
+	}
+
+	// Plans only hold changed files, so transactional runs can't rewrite unchanged annotated files
+	if u.transactional && u.forceWrite && !u.dryRun {
+		return errors.New("force write cannot be combined with transactional writes, which only write changed files")
+	}
+
+	// In transactional mode, nothing is written until every file has been updated in memory
+	plan, err := u.plan(ctx, entrypoint, packages, u.dryRun || u.transactional)
+	if plan == nil {
+		return err
+	}
+	if u.transactional && !u.dryRun {
+		u.changes = nil
+		if err == nil {
+			err = u.writeAll(plan)
+		}
+	}
+
+	// Write the updates that couldn't be applied confidently next to their files
+	if u.quarantineOnConflict && !u.dryRun && err == nil {
+		err = u.writeRejects(plan.Skipped, packages)
+	}
+
+	// In dry-run mode, output what would change and the permissions that would be kept or set instead of modifying files
+	if u.dryRun {
+		for _, file := range plan.Files {
+			var mode os.FileMode
+			if fileInfo, err := os.Stat(file.Path); err == nil {
+				mode = fileInfo.Mode()
+				u.setChangeMode(file.Path, mode)
+			}
+			if u.fileMode != 0 {
+				u.setChangeMode(file.Path, u.fileMode)
+			}
+			reporter.ReportDryRun(file.Path, file.Content, mode)
+		}
+	}
+
+	// Report what has been written, even if processing stopped early
+	if reportErr := reporter.ReportRun(u.changes, plan.Skipped, u.dryRun); reportErr != nil && err == nil {
+		err = fmt.Errorf("cannot write report: %w", reportErr)
+	}
+
+	return err
+}
+
+// setChangeMode records the permissions of a file in its changes
+// Files are written in place, so their permissions are kept by an update unless WithFileMode sets them.
+func (u *Updater) setChangeMode(filePath string, mode os.FileMode) {
+	for i := range u.changes {
+		if u.changes[i].File == filePath {
+			u.changes[i].Mode = formatFileMode(mode)
+		}
+	}
+}
+
+// Plan computes the changes an update would make without writing files or printing anything
+func (u *Updater) Plan(entrypoint string, packages []Package) (*Plan, error) {
+	return u.PlanContext(context.Background(), entrypoint, packages)
+}
+
+// PlanContext is like Plan but stops discovering and processing files once ctx is done
+func (u *Updater) PlanContext(ctx context.Context, entrypoint string, packages []Package) (*Plan, error) {
+	plan, err := u.plan(ctx, entrypoint, packages, true)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// plan processes the entrypoint and returns the files that changed or would change.
+// The plan is nil if the packages or the entrypoint are invalid.
+func (u *Updater) plan(ctx context.Context, entrypoint string, packages []Package, dryRun bool) (*Plan, error) {
 	var errs []error
 	for _, pkg := range packages {
-		if err := pkg.Validate(); err != nil {
+		validate := pkg.Validate
+		if u.strictSemver {
+			validate = pkg.ValidateStrict
+		}
+		if err := validate(); err != nil {
 			errs = append(errs, fmt.Errorf("invalid package %s: %w", pkg, err))
 		}
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("invalid packages: %w", errors.Join(errs...))
+		return nil, fmt.Errorf("invalid packages: %w", errors.Join(errs...))
+	}
+
+	// Fail before walking if a configured extension has no updater, rather than on the first file with it
+	if err := u.validateExtensions(); err != nil {
+		return nil, err
 	}
 
 	// Verify the entrypoint exists
 	fileInfo, err := os.Stat(entrypoint)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Convert to absolute path for consistency in error messages and processing
 	entrypoint, err = filepath.Abs(entrypoint)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// Reset the state of any previous run
+	u.changes = nil
+	u.planned = &Plan{}
+
 	// Prepare options for file updaters
 	updaterOptions := FileUpdaterOptions{
-		DryRun: u.dryRun,
+		DryRun:                 dryRun,
+		ForceWrite:             u.forceWrite,
+		Scheme:                 u.scheme,
+		CanonicalVersions:      u.canonicalVersions,
+		AllowLeadingZeros:      u.allowLeadingZeros,
+		UpdateMode:             u.updateMode,
+		VersionPrefixAuto:      u.versionPrefixAuto,
+		QuoteStyle:             u.quoteStyle,
+		CommentPosition:        u.commentPosition,
+		SyncCommentVersion:     u.syncCommentVersion,
+		NestedVersions:         u.nestedVersions,
+		ErrorOnMultipleMatches: u.errorOnMultipleMatches,
+		QuarantineOnConflict:   u.quarantineOnConflict,
+		OnSkip:                 u.recordSkip,
+	}
+
+	// File patterns of packages are relative to the scanned directory
+	u.root = entrypoint
+	if !fileInfo.IsDir() {
+		u.root = filepath.Dir(entrypoint)
 	}
 
+	err = u.processEntrypoint(ctx, entrypoint, fileInfo, packages, updaterOptions)
+
+	return u.planned, err
+}
+
+// Apply writes the files of a plan and reports the changes like Update
+// Together with Plan.Select, this applies only some of the changes of a plan, e.g. the ones confirmed by a user
+func (u *Updater) Apply(plan *Plan) error {
+	reporter, err := u.newReporter()
+	if err != nil {
+		return err
+	}
+
+	u.changes = nil
+	if u.transactional {
+		err = u.writeAll(plan)
+	} else {
+		for _, file := range plan.Files {
+			if err = u.writePlannedFile(file); err != nil {
+				break
+			}
+			u.changes = append(u.changes, file.Changes...)
+		}
+	}
+
+	// Report what has been written, even if writing stopped early
+	if reportErr := reporter.ReportRun(u.changes, plan.Skipped, false); reportErr != nil && err == nil {
+		err = fmt.Errorf("cannot write report: %w", reportErr)
+	}
+
+	return err
+}
+
+// writePlannedFile writes the planned content of a file while holding its lock
+func (u *Updater) writePlannedFile(file PlannedFile) error {
+	unlock := u.fileLocks.lock(file.Path)
+	defer unlock()
+
+	if err := os.WriteFile(file.Path, []byte(file.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write updated content to %s: %w", file.Path, err)
+	}
+	if err := u.applyFileMode(file.Path); err != nil {
+		return err
+	}
+	if u.verify {
+		return verifyWrite(file.Path, file.Content, file.Changes)
+	}
+	return nil
+}
+
+// applyFileMode sets the permissions of a written file to the mode of WithFileMode, if any
+// Files are written in place, so without a mode they keep their permissions.
+func (u *Updater) applyFileMode(filePath string) error {
+	if u.fileMode == 0 {
+		return nil
+	}
+	if err := os.Chmod(filePath, u.fileMode); err != nil {
+		return fmt.Errorf("cannot set the mode of %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// verifyWrite reads a written file back and checks it holds the expected content and the new version of every change
+func verifyWrite(filePath, expected string, changes []Change) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("verification of %s failed: cannot read it back: %w", filePath, err)
+	}
+	if string(content) != expected {
+		return fmt.Errorf("verification of %s failed: content read back differs from the written content", filePath)
+	}
+	for _, change := range changes {
+		if !strings.Contains(expected, change.NewVersion) {
+			return fmt.Errorf("verification of %s failed: new version %s of %s is missing", filePath, change.NewVersion, change.Package)
+		}
+	}
+	return nil
+}
+
+// writeAll writes all files of the plan or none of them
+// The original contents are kept in memory, and the written files are restored if a write fails.
+// Changes are only recorded once every file has been written.
+func (u *Updater) writeAll(plan *Plan) error {
+	originals := make([][]byte, len(plan.Files))
+	for i, file := range plan.Files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			return fmt.Errorf("cannot back up %s: %w", file.Path, err)
+		}
+		originals[i] = content
+	}
+
+	for i, file := range plan.Files {
+		err := u.writePlannedFile(file)
+		if err == nil {
+			continue
+		}
+
+		// Restore the files written so far
+		var restoreErrs []error
+		for j, written := range plan.Files[:i] {
+			if err := os.WriteFile(written.Path, originals[j], 0644); err != nil {
+				restoreErrs = append(restoreErrs, fmt.Errorf("cannot restore %s: %w", written.Path, err))
+			}
+		}
+		if len(restoreErrs) > 0 {
+			return errors.Join(append([]error{err}, restoreErrs...)...)
+		}
+		return fmt.Errorf("%w, restored %d written file(s)", err, i)
+	}
+
+	u.changes = plan.Changes()
+	return nil
+}
+
+// processEntrypoint processes the entrypoint file or the matching files in the entrypoint directory
+func (u *Updater) processEntrypoint(ctx context.Context, entrypoint string, fileInfo os.FileInfo, packages []Package, updaterOptions FileUpdaterOptions) error {
+	files, err := u.discover(ctx, entrypoint, fileInfo)
+	if err != nil {
+		return err
+	}
+
+	// Progress paths are displayed like the paths of reports
+	reporter, err := u.newReporter()
+	if err != nil {
+		return err
+	}
+
+	// Files are processed one at a time, so tools watching directory mtimes never see concurrent writes
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if u.progress != nil {
+			fmt.Fprintf(u.progress, "[%d/%d] %s\n", i+1, len(files), reporter.displayPath(file))
+		}
+		if err := u.processFile(file, packages, updaterOptions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Discover returns the files an update of the entrypoint would consider, without reading them
+// Paths are absolute and in processing order
+func (u *Updater) Discover(entrypoint string) ([]string, error) {
+	if err := u.validateExtensions(); err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	entrypoint, err = filepath.Abs(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.discover(context.Background(), entrypoint, fileInfo)
+}
+
+// discover lists the files of the entrypoint that match the configured extensions and excludes
+func (u *Updater) discover(ctx context.Context, entrypoint string, fileInfo os.FileInfo) ([]string, error) {
 	// Handle single file case
 	if !fileInfo.IsDir() {
-		return u.processFile(entrypoint, packages, updaterOptions)
+		if !u.isFileExtensionSupported(entrypoint) {
+			return nil, nil
+		}
+		return []string{entrypoint}, nil
 	}
 
+	var files []string
+
 	// Handle directory case
-	if fileInfo.IsDir() && !u.recursive {
-		// Process only files in the top-level directory when recursive is false
-		files, err := os.ReadDir(entrypoint)
+	if !u.recursive {
+		// Only consider files in the top-level directory when recursive is false
+		entries, err := os.ReadDir(entrypoint)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		for _, file := range files {
-			if !file.IsDir() {
-				ext := filepath.Ext(file.Name())
-				for _, allowedExt := range u.fileExtensions {
-					if ext == allowedExt {
-						err = u.processFile(filepath.Join(entrypoint, file.Name()), packages, updaterOptions)
-						if err != nil {
-							return err
-						}
-						break
-					}
-				}
+		for _, entry := range entries {
+			path := filepath.Join(entrypoint, entry.Name())
+			if !entry.IsDir() && u.hasAllowedExtension(path) && !u.isExcluded(entrypoint, path) {
+				files = append(files, path)
 			}
 		}
-	} else if u.recursive {
-		// Process all files recursively when recursive flag is true
-		err = filepath.Walk(entrypoint, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+
+		return files, nil
+	}
+
+	// Walk all files recursively when recursive flag is true
+	err := filepath.Walk(entrypoint, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Abort large scans once the context is done
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if path != entrypoint && u.isExcluded(entrypoint, path) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+
+		if !info.IsDir() && u.hasAllowedExtension(path) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// validateExtensions fails if an extension passed with WithFileExtensions has no updater, naming all of them
+func (u *Updater) validateExtensions() error {
+	if !u.checkExtensions {
+		return nil
+	}
+	if unsupported := u.unsupportedExtensions(); len(unsupported) > 0 {
+		return fmt.Errorf("no updater found for file extensions: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
 
-			if !info.IsDir() {
-				ext := filepath.Ext(path)
-				for _, allowedExt := range u.fileExtensions {
-					if ext == allowedExt {
-						err = u.processFile(path, packages, updaterOptions)
-						if err != nil {
-							return err
-						}
-						break
-					}
+// unsupportedExtensions returns the configured extensions that no updater handles
+// Glob patterns are supported if they match an extension or file name of one of the updaters.
+func (u *Updater) unsupportedExtensions() []string {
+	var unsupported []string
+	for _, pattern := range u.fileExtensions {
+		if !u.isExtensionHandled(pattern) && !slices.Contains(unsupported, pattern) {
+			unsupported = append(unsupported, pattern)
+		}
+	}
+	return unsupported
+}
+
+// isExtensionHandled reports whether an updater handles the configured extension, file name or glob pattern
+func (u *Updater) isExtensionHandled(pattern string) bool {
+	for _, updater := range u.updaters {
+		if updater.Supports(strings.ToLower(pattern)) {
+			return true
+		}
+		if matcher, ok := updater.(FileNameMatcher); ok && matcher.SupportsFileName(pattern) {
+			return true
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			for _, supported := range updater.GetSupportedExtensions() {
+				if matched, err := filepath.Match(pattern, supported); err == nil && matched {
+					return true
 				}
 			}
+		}
+	}
+	return false
+}
 
-			return nil
-		})
+// hasAllowedExtension checks if the file extension matches one of the configured extensions, ignoring case
+// Entries may also name a file, e.g. "Gemfile" for files without an extension
+func (u *Updater) hasAllowedExtension(path string) bool {
+	ext := fileExtension(path)
+	fileName := filepath.Base(path)
+	for _, allowedExt := range u.fileExtensions {
+		if ext == strings.ToLower(allowedExt) || fileName == allowedExt || hasCompoundExtension(fileName, allowedExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCompoundExtension reports whether the file name ends with an extension of several parts like ".tf.json",
+// ignoring case. Single-part extensions and glob patterns never match.
+func hasCompoundExtension(fileName, extension string) bool {
+	if !strings.HasPrefix(extension, ".") || strings.Count(extension, ".") < 2 || strings.ContainsAny(extension, "*?[") {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(fileName), strings.ToLower(extension))
+}
+
+// isExcluded checks if a path matches one of the exclude patterns
+// Patterns are matched against the slash separated path relative to the root and against the base name
+func (u *Updater) isExcluded(root, path string) bool {
+	for _, pattern := range u.excludes {
+		if matchesPathPattern(root, path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPathPattern checks if a glob pattern matches the slash separated path relative to the root or the base name
+// Patterns are slash separated as well, so vendor/* and vendor\* match the same files on Windows
+func matchesPathPattern(root, filePath, pattern string) bool {
+	pattern = filepath.ToSlash(pattern)
+	if relPath, err := filepath.Rel(root, filePath); err == nil {
+		if matched, _ := path.Match(pattern, filepath.ToSlash(relPath)); matched {
+			return true
+		}
+	}
+
+	matched, _ := path.Match(pattern, filepath.Base(filePath))
+	return matched
+}
+
+// packagesForFile returns the packages that apply to the file, dropping packages restricted to other files
+func (u *Updater) packagesForFile(filePath string, packages []Package) []Package {
+	filtered := make([]Package, 0, len(packages))
+	for _, pkg := range packages {
+		if pkg.File == "" || matchesPathPattern(u.root, filePath, pkg.File) {
+			filtered = append(filtered, pkg)
+		}
+	}
+	return filtered
+}
+
+// Changes returns the changes collected during the last call to Update
+func (u *Updater) Changes() []Change {
+	return u.changes
+}
+
+// Skipped returns the annotated versions left unchanged by a guard during the last call to Update
+func (u *Updater) Skipped() []Skip {
+	if u.planned == nil {
+		return nil
+	}
+	return u.planned.Skipped
+}
+
+// recordSkip adds a skipped version to the plan of the current run
+func (u *Updater) recordSkip(skip Skip) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.planned.Skipped = append(u.planned.Skipped, skip)
+}
+
+// ChangedFiles returns the sorted list of files changed during the last call to Update
+func (u *Updater) ChangedFiles() []string {
+	seen := map[string]struct{}{}
+	var files []string
+	for _, change := range u.changes {
+		if _, ok := seen[change.File]; ok {
+			continue
+		}
+		seen[change.File] = struct{}{}
+		files = append(files, change.File)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// newReporter creates the reporter for a run based on the configured options
+func (u *Updater) newReporter() (*Reporter, error) {
+	options := ReportOptions{GroupBy: u.groupBy, Format: u.reportFormat, ShowSource: u.showSource, CompactJSON: u.compactJSON, PrettyJSON: u.prettyJSON, SARIFLevel: u.sarifLevel, FileMode: u.fileMode}
+
+	if u.relativePaths {
+		workingDir, err := os.Getwd()
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("cannot determine working directory: %w", err)
 		}
+		options.BaseDir = workingDir
 	}
 
-	return nil
+	return NewReporter(u.out, options), nil
 }
 
 // isFileExtensionSupported checks if the file extension is in the configured extensions list
 // Returns true if the file should be processed, false otherwise
 func (u *Updater) isFileExtensionSupported(filePath string) bool {
-	fileExtension := strings.ToLower(filepath.Ext(filePath))
+	extension := fileExtension(filePath)
 	fileName := filepath.Base(filePath)
 
 	for _, pattern := range u.fileExtensions {
-		// First check exact extension match
-		if pattern == fileExtension {
+		// First check exact extension or file name match
+		if strings.ToLower(pattern) == extension || pattern == fileName || hasCompoundExtension(fileName, pattern) {
 			return true
 		}
 
@@ -236,6 +1102,53 @@ func (u *Updater) isFileExtensionSupported(filePath string) bool {
 	return false
 }
 
+// FileNameMatcher is implemented by FileUpdaters that handle files by name rather than extension
+type FileNameMatcher interface {
+	// SupportsFileName reports whether the updater handles files with the given base name
+	SupportsFileName(fileName string) bool
+}
+
+// commentSyntaxUpdater is implemented by FileUpdaters recognizing depup comments in the comments of their file format
+type commentSyntaxUpdater interface {
+	// depupComments returns the syntax depup comments are recognized by
+	depupComments() commentSyntax
+
+	// setDepupComments replaces the syntax depup comments are recognized by
+	setDepupComments(comments commentSyntax)
+}
+
+// getFileUpdaterForPath returns the appropriate FileUpdater for a file
+// Updaters matching the file name take precedence over updaters supporting a compound extension of the file,
+// e.g. ".tf.json" for override.tf.json, which take precedence over updaters matching the extension
+func (u *Updater) getFileUpdaterForPath(filePath string) (FileUpdater, error) {
+	fileName := filepath.Base(filePath)
+	for _, updater := range u.updaters {
+		if matcher, ok := updater.(FileNameMatcher); ok && matcher.SupportsFileName(fileName) {
+			return updater, nil
+		}
+	}
+	for _, updater := range u.updaters {
+		for _, extension := range updater.GetSupportedExtensions() {
+			if hasCompoundExtension(fileName, extension) {
+				return updater, nil
+			}
+		}
+	}
+
+	return u.getFileUpdater(fileExtension(filePath))
+}
+
+// fileExtension returns the lower-cased extension of a file, so config.YAML is handled like config.yaml
+// Updaters declare their extensions in lower case.
+func fileExtension(filePath string) string {
+	return strings.ToLower(filepath.Ext(filePath))
+}
+
+// Updaters returns the registered file updaters in lookup order
+func (u *Updater) Updaters() []FileUpdater {
+	return u.updaters
+}
+
 // getFileUpdater returns the appropriate FileUpdater for a given file extension
 // Returns an error if no suitable updater is found
 func (u *Updater) getFileUpdater(fileExtension string) (FileUpdater, error) {
@@ -255,21 +1168,78 @@ func (u *Updater) processFile(filePath string, packages []Package, options FileU
 		return nil // Exit silently if file extension is not supported
 	}
 
+	// Only pass the packages that apply to this file
+	packages = u.packagesForFile(filePath, packages)
+	if len(packages) == 0 {
+		return nil
+	}
+
+	// Skip huge files before reading them
+	if u.maxFileSize > 0 {
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return err
+		}
+		if fileInfo.Size() > u.maxFileSize {
+			if u.warnings != nil {
+				fmt.Fprintf(u.warnings, "Warning: skipped %s: size of %d bytes exceeds the maximum file size of %d bytes\n", filePath, fileInfo.Size(), u.maxFileSize)
+			}
+			return nil
+		}
+	}
+
 	// Get the appropriate updater for this file type
-	updater, err := u.getFileUpdater(filepath.Ext(filePath))
+	updater, err := u.getFileUpdaterForPath(filePath)
 	if err != nil {
 		return fmt.Errorf("no updater found for file extension: %s", filepath.Ext(filePath))
 	}
 
-	// Perform the update operation
-	updatedContent, hasBeenUpdated, err := updater.UpdateFile(filePath, packages, options)
+	// Apply .editorconfig rules for this file on top of the shared options
+	if u.respectEditorConfig {
+		options, err = applyEditorConfig(filePath, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Perform the update operation while holding the lock for this file
+	unlock := u.fileLocks.lock(filePath)
+	updatedContent, changes, err := updater.UpdateFile(filePath, packages, options)
+	unlock()
 	if err != nil {
 		return err
 	}
 
-	// In dry-run mode, output what would change instead of modifying files
-	if u.dryRun && hasBeenUpdated {
-		fmt.Printf("Dry run mode - updated content for %s:\n%s\n", filePath, updatedContent)
+	if len(changes) == 0 {
+		return nil
+	}
+	if !options.DryRun {
+		if err := u.applyFileMode(filePath); err != nil {
+			return err
+		}
+	}
+	if u.verify && !options.DryRun {
+		if err := verifyWrite(filePath, updatedContent, changes); err != nil {
+			return err
+		}
+	}
+
+	// Record where the new versions came from
+	for i := range changes {
+		for _, pkg := range packages {
+			if pkg.Name == changes[i].Package {
+				changes[i].Source = pkg.Source
+				break
+			}
+		}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.changes = append(u.changes, changes...)
+	if u.planned != nil {
+		u.planned.Files = append(u.planned.Files, PlannedFile{Path: filePath, Content: updatedContent, Changes: changes})
 	}
 
 	return nil
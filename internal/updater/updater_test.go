@@ -1,10 +1,20 @@
 package updater
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // MockFileUpdater is a mock implementation of the FileUpdater interface for testing
@@ -24,6 +34,10 @@ func NewMockFileUpdater(extensions []string, shouldFail, shouldUpdate bool) *Moc
 	}
 }
 
+func (m *MockFileUpdater) Name() string {
+	return "mock"
+}
+
 func (m *MockFileUpdater) GetSupportedExtensions() []string {
 	return m.supportedExtensions
 }
@@ -37,14 +51,77 @@ func (m *MockFileUpdater) Supports(fileExtension string) bool {
 	return false
 }
 
-func (m *MockFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, bool, error) {
+func (m *MockFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
 	if m.shouldFail {
-		return "", false, errors.New("mock update failure")
+		return "", nil, errors.New("mock update failure")
 	}
 
 	updatedContent := "updated content"
 	m.updatedFiles[filePath] = updatedContent
-	return updatedContent, m.shouldUpdate, nil
+	if !m.shouldUpdate {
+		return updatedContent, nil, nil
+	}
+
+	var changes []Change
+	for _, pkg := range packages {
+		changes = append(changes, Change{File: filePath, Line: 1, Package: pkg.Name, OldVersion: "0.0.0", NewVersion: pkg.Version})
+	}
+	return updatedContent, changes, nil
+}
+
+func TestPackage_ValidateStrict(t *testing.T) {
+	tests := []struct {
+		version      string
+		expectLoose  bool
+		expectStrict bool
+	}{
+		{version: "1.2.3", expectLoose: true, expectStrict: true},
+		{version: "1.2.3-rc.1+build.5", expectLoose: true, expectStrict: true},
+		{version: "1.01.0", expectLoose: false, expectStrict: false},
+		{version: "1.2", expectLoose: false, expectStrict: false},
+		{version: "01.2.3", expectLoose: true, expectStrict: false},
+		{version: "1.2.3.4", expectLoose: true, expectStrict: false},
+		{version: "v1.2.3", expectLoose: true, expectStrict: false},
+		{version: "20231201", expectLoose: true, expectStrict: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			pkg := Package{Name: "app", Version: tt.version}
+
+			if err := pkg.Validate(); (err == nil) != tt.expectLoose {
+				t.Errorf("Validate() error = %v, expected valid %v", err, tt.expectLoose)
+			}
+			if err := pkg.ValidateStrict(); (err == nil) != tt.expectStrict {
+				t.Errorf("ValidateStrict() error = %v, expected valid %v", err, tt.expectStrict)
+			}
+		})
+	}
+}
+
+func TestIsVersion(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "1.2.3", expected: true},
+		{value: "v1.2.3-rc.1+build.5", expected: true},
+		{value: "release-1.2.3", expected: true},
+		{value: "20231201", expected: true},
+		{value: "1.2.3.post1", expected: false},
+		{value: "1.2.3.4", expected: false},
+		{value: "1.2", expected: false},
+		{value: "latest", expected: false},
+		{value: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if result := IsVersion(tt.value); result != tt.expected {
+				t.Errorf("IsVersion(%q) = %v, expected %v", tt.value, result, tt.expected)
+			}
+		})
+	}
 }
 
 func TestNewUpdater(t *testing.T) {
@@ -141,6 +218,8 @@ func TestUpdater_isFileExtensionSupported(t *testing.T) {
 		{"test.yaml", true},
 		{"test.yml", true},
 		{"test.json", true},
+		{"test.YAML", true},
+		{"test.Yml", true},
 		{"test.toml", false},
 		{"test.txt", false},
 		{"test", false},
@@ -156,6 +235,97 @@ func TestUpdater_isFileExtensionSupported(t *testing.T) {
 	}
 }
 
+func TestUpdater_Update_UnsupportedExtensions(t *testing.T) {
+	tests := []struct {
+		name        string
+		extensions  []string
+		expectedErr string
+	}{
+		{name: "supported", extensions: []string{".yaml", ".YML", "Gemfile", "MODULE.bazel", ".tf*"}},
+		{name: "single unsupported", extensions: []string{".toml", ".yaml"}, expectedErr: "no updater found for file extensions: .toml"},
+		{name: "every unsupported one", extensions: []string{".toml", ".conf", ".ini", ".yaml", ".toml"}, expectedErr: "no updater found for file extensions: .toml, .ini"},
+		{name: "unmatched glob", extensions: []string{".x*"}, expectedErr: "no updater found for file extensions: .x*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			filePath := filepath.Join(tempDir, "config.yaml")
+			if err := os.WriteFile(filePath, []byte("version: 1.0.0 # depup package=app\n"), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			updater := NewUpdater(WithOutput(io.Discard), WithFileExtensions(tt.extensions))
+			err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}})
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Fatalf("Update() error = %v, expected %q", err, tt.expectedErr)
+			}
+
+			// The run fails before any file is updated
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != "version: 1.0.0 # depup package=app\n" {
+				t.Errorf("file content = %q, expected it to be unchanged", string(content))
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_UppercaseExtensions(t *testing.T) {
+	files := map[string]string{
+		"config.YAML": "# depup package=app\nversion: 1.0.0\n",
+		"main.TF":     "# depup package=app\nversion = \"1.0.0\"\n",
+	}
+	expected := map[string]string{
+		"config.YAML": "# depup package=app\nversion: 2.0.0\n",
+		"main.TF":     "# depup package=app\nversion = \"2.0.0\"\n",
+	}
+
+	tests := []struct {
+		name       string
+		entrypoint func(dir string) string
+		files      []string
+	}{
+		{name: "directory", entrypoint: func(dir string) string { return dir }, files: []string{"config.YAML", "main.TF"}},
+		{name: "YAML file", entrypoint: func(dir string) string { return filepath.Join(dir, "config.YAML") }, files: []string{"config.YAML"}},
+		{name: "TF file", entrypoint: func(dir string) string { return filepath.Join(dir, "main.TF") }, files: []string{"main.TF"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to create %s: %v", name, err)
+				}
+			}
+
+			updater := NewUpdater(WithOutput(io.Discard), WithFileExtensions([]string{".yaml", ".tf"}))
+			if err := updater.Update(tt.entrypoint(tempDir), []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			for _, name := range tt.files {
+				content, err := os.ReadFile(filepath.Join(tempDir, name))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(content) != expected[name] {
+					t.Errorf("%s = %q, expected %q", name, string(content), expected[name])
+				}
+			}
+		})
+	}
+}
+
 func TestUpdater_getFileUpdater(t *testing.T) {
 	mockYaml := NewMockFileUpdater([]string{".yaml", ".yml"}, false, true)
 	mockJson := NewMockFileUpdater([]string{".json"}, false, true)
@@ -201,6 +371,54 @@ func TestUpdater_getFileUpdater(t *testing.T) {
 	}
 }
 
+func TestUpdater_Update_TerraformJSON(t *testing.T) {
+	files := map[string]string{
+		"main.tf":                          "# depup package=aws\nversion = \"4.0.0\"\n",
+		"override.tf.json":                 "{\"terraform\": {\"required_providers\": {\"aws\": {\"version\": \"4.0.0\"}}}}\n",
+		"override.tf.json.depup.yaml":      "/terraform/required_providers/aws/version: aws\n",
+		"terraform.tfvars.json":            "{\"app_version\": \"4.0.0\"}\n",
+		"terraform.tfvars.json.depup.yaml": "/app_version: aws\n",
+		"package.json":                     "{\"version\": \"4.0.0\"}\n",
+	}
+	expected := map[string]string{
+		"main.tf":               "# depup package=aws\nversion = \"5.0.0\"\n",
+		"override.tf.json":      "{\"terraform\": {\"required_providers\": {\"aws\": {\"version\": \"5.0.0\"}}}}\n",
+		"terraform.tfvars.json": "{\"app_version\": \"5.0.0\"}\n",
+		"package.json":          "{\"version\": \"4.0.0\"}\n",
+	}
+
+	tempDir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	// Compound extensions select the Terraform JSON files, but not other JSON files
+	updater := NewUpdater(WithOutput(io.Discard), WithFileExtensions([]string{".tf", ".tf.json", ".TFVARS.JSON"}))
+	if err := updater.Update(tempDir, []Package{{Name: "aws", Version: "5.0.0"}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(content) != want {
+			t.Errorf("%s = %q, expected %q", name, string(content), want)
+		}
+	}
+
+	fileUpdater, err := updater.getFileUpdaterForPath(filepath.Join(tempDir, "override.tf.json"))
+	if err != nil {
+		t.Fatalf("getFileUpdaterForPath() error = %v", err)
+	}
+	if fileUpdater.Name() != "json" {
+		t.Errorf("getFileUpdaterForPath() = %s updater, expected the json updater", fileUpdater.Name())
+	}
+}
+
 func TestUpdater_Update_SingleFile(t *testing.T) {
 	// Create a temporary test file
 	tempDir := t.TempDir()
@@ -349,6 +567,153 @@ func TestUpdater_Update_DryRun(t *testing.T) {
 	}
 }
 
+func TestUpdater_Update_DryRunFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't support Unix permissions")
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "text", format: FormatText, expected: "Dry run mode - updated content for values.yaml (mode 0666 kept):\n"},
+		{name: "json", format: FormatJSON, expected: `"mode": "0666"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			filePath := filepath.Join(tempDir, "values.yaml")
+			if err := os.WriteFile(filePath, []byte("version: 1.0.0 # depup package=app\n"), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+			// Set the mode explicitly, WriteFile is subject to the umask
+			if err := os.Chmod(filePath, 0666); err != nil {
+				t.Fatalf("failed to change the mode of the test file: %v", err)
+			}
+
+			var output bytes.Buffer
+			updater := NewUpdater(WithDryRun(true), WithOutput(&output), WithRelativePaths(true), WithReportFormat(tt.format))
+			t.Chdir(tempDir)
+			if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			if !strings.Contains(output.String(), tt.expected) {
+				t.Errorf("Update() output = %q, expected it to contain %q", output.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_FileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't support Unix permissions")
+	}
+
+	tests := []struct {
+		name     string
+		options  []Option
+		expected os.FileMode
+	}{
+		{name: "mode is kept by default", expected: 0600},
+		{name: "forced mode", options: []Option{WithFileMode(0644)}, expected: 0644},
+		{name: "forced mode with all-or-nothing writes", options: []Option{WithFileMode(0640), WithTransactional(true)}, expected: 0640},
+		{name: "dry run leaves the mode alone", options: []Option{WithFileMode(0644), WithDryRun(true)}, expected: 0600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			filePath := filepath.Join(tempDir, "values.yaml")
+			if err := os.WriteFile(filePath, []byte("version: 1.0.0 # depup package=app\n"), 0600); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+			if err := os.Chmod(filePath, 0600); err != nil {
+				t.Fatalf("failed to change the mode of the test file: %v", err)
+			}
+
+			updater := NewUpdater(append([]Option{WithOutput(io.Discard)}, tt.options...)...)
+			if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				t.Fatalf("failed to stat test file: %v", err)
+			}
+			if fileInfo.Mode().Perm() != tt.expected {
+				t.Errorf("file mode = %04o, expected %04o", fileInfo.Mode().Perm(), tt.expected)
+			}
+		})
+	}
+
+	t.Run("dry run reports the forced mode", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "values.yaml")
+		if err := os.WriteFile(filePath, []byte("version: 1.0.0 # depup package=app\n"), 0600); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+
+		var output bytes.Buffer
+		updater := NewUpdater(WithDryRun(true), WithOutput(&output), WithRelativePaths(true), WithFileMode(0644))
+		t.Chdir(tempDir)
+		if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+
+		expected := "Dry run mode - updated content for values.yaml (mode 0644 set):\n"
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Update() output = %q, expected it to contain %q", output.String(), expected)
+		}
+		if changes := updater.Changes(); len(changes) != 1 || changes[0].Mode != "0644" {
+			t.Errorf("Changes() = %+v, expected mode 0644", changes)
+		}
+	})
+}
+
+func TestUpdater_Update_MaxFileSize(t *testing.T) {
+	content := "# depup package=example\nversion: 1.0.0\n"
+
+	tests := []struct {
+		name            string
+		maxFileSize     int64
+		expectProcessed bool
+		expectWarning   bool
+	}{
+		{name: "below the limit", maxFileSize: int64(len(content)) + 1, expectProcessed: true},
+		{name: "at the limit", maxFileSize: int64(len(content)), expectProcessed: true},
+		{name: "above the limit", maxFileSize: int64(len(content)) - 1, expectProcessed: false, expectWarning: true},
+		{name: "no limit", maxFileSize: 0, expectProcessed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.yaml")
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			var warnings bytes.Buffer
+			mockUpdater := NewMockFileUpdater([]string{".yaml"}, false, true)
+			updater := NewUpdater(WithDryRun(true), WithOutput(io.Discard), WithMaxFileSize(tt.maxFileSize), WithWarnings(&warnings))
+			updater.updaters = []FileUpdater{mockUpdater}
+
+			if err := updater.Update(filePath, []Package{{Name: "example", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			if _, processed := mockUpdater.updatedFiles[filePath]; processed != tt.expectProcessed {
+				t.Errorf("file processed = %v, expected %v", processed, tt.expectProcessed)
+			}
+			if hasWarning := strings.Contains(warnings.String(), "Warning: skipped "+filePath); hasWarning != tt.expectWarning {
+				t.Errorf("warnings = %q, expected a warning: %v", warnings.String(), tt.expectWarning)
+			}
+		})
+	}
+}
+
 func TestUpdater_Update_Errors(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -398,3 +763,971 @@ func TestUpdater_Update_Errors(t *testing.T) {
 		t.Errorf("unsupported file was updated")
 	}
 }
+
+func TestUpdater_Update_RelativePaths(t *testing.T) {
+	tempDir := t.TempDir()
+	subDir := filepath.Join(tempDir, "config")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+
+	filePath := filepath.Join(subDir, "app.yaml")
+	err := os.WriteFile(filePath, []byte("# depup package=app\nversion: 1.0.0\n"), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	t.Chdir(tempDir)
+
+	packages := []Package{{Name: "app", Version: "2.0.0"}}
+
+	var absOutput bytes.Buffer
+	updater := NewUpdater(WithDryRun(true), WithOutput(&absOutput))
+	if err := updater.Update(filePath, packages); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if !strings.Contains(absOutput.String(), filePath) {
+		t.Errorf("expected absolute path in output, got %q", absOutput.String())
+	}
+
+	var relOutput bytes.Buffer
+	updater = NewUpdater(WithOutput(&relOutput), WithRelativePaths(true))
+	if err := updater.Update("config", packages); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	expected := "Updated " + filepath.Join("config", "app.yaml") + ":2 app 1.0.0 -> 2.0.0\n"
+	if relOutput.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, relOutput.String())
+	}
+
+	// Internal change records keep absolute paths
+	changes := updater.Changes()
+	if len(changes) != 1 || changes[0].File != filePath {
+		t.Errorf("expected one change with absolute path %s, got %+v", filePath, changes)
+	}
+}
+
+func TestUpdater_Update_ForceWrite(t *testing.T) {
+	tests := []struct {
+		name          string
+		forceWrite    bool
+		expectWritten map[string]bool
+	}{
+		{
+			name:       "force write rewrites annotated files",
+			forceWrite: true,
+			expectWritten: map[string]bool{
+				"current.yaml": true,
+				"other.yaml":   false,
+				"plain.yaml":   false,
+			},
+		},
+		{
+			name:       "unchanged files are not written by default",
+			forceWrite: false,
+			expectWritten: map[string]bool{
+				"current.yaml": false,
+				"other.yaml":   false,
+				"plain.yaml":   false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := map[string]string{
+				"current.yaml": "# depup package=app\nversion: 2.0.0\n",
+				"other.yaml":   "# depup package=other\nversion: 1.0.0\n",
+				"plain.yaml":   "version: 1.0.0\n",
+			}
+
+			// Backdate all files so a write is visible in the modification time
+			past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			for name, content := range files {
+				path := filepath.Join(tempDir, name)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to create test file: %v", err)
+				}
+				if err := os.Chtimes(path, past, past); err != nil {
+					t.Fatalf("failed to backdate test file: %v", err)
+				}
+			}
+
+			updater := NewUpdater(WithForceWrite(tt.forceWrite), WithOutput(io.Discard))
+			if err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			for name, expectWritten := range tt.expectWritten {
+				info, err := os.Stat(filepath.Join(tempDir, name))
+				if err != nil {
+					t.Fatalf("failed to stat %s: %v", name, err)
+				}
+				if written := !info.ModTime().Equal(past); written != expectWritten {
+					t.Errorf("%s written = %v, expected %v", name, written, expectWritten)
+				}
+			}
+
+			if len(updater.Changes()) != 0 {
+				t.Errorf("expected no changes, got %+v", updater.Changes())
+			}
+		})
+	}
+}
+
+func TestUpdater_Discover(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		"app.yaml",
+		"values.yml",
+		"notes.txt",
+		"generated.gen.yaml",
+		"deploy/service.yaml",
+		"deploy/legacy/old.yaml",
+		"vendor/lib/chart.yaml",
+		"infra/main.tf",
+	} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		options    []Option
+		entrypoint string
+		expected   []string
+	}{
+		{
+			name:       "recursive with excludes",
+			options:    []Option{WithExcludes([]string{"vendor", "*.gen.yaml", "deploy/legacy"})},
+			entrypoint: tempDir,
+			expected:   []string{"app.yaml", "deploy/service.yaml", "infra/main.tf", "values.yml"},
+		},
+		{
+			name:       "non recursive",
+			options:    []Option{WithRecursive(false), WithFileExtensions([]string{".yaml"})},
+			entrypoint: tempDir,
+			expected:   []string{"app.yaml", "generated.gen.yaml"},
+		},
+		{
+			name:       "single file",
+			entrypoint: filepath.Join(tempDir, "infra", "main.tf"),
+			expected:   []string{"infra/main.tf"},
+		},
+		{
+			name:       "single unsupported file",
+			entrypoint: filepath.Join(tempDir, "notes.txt"),
+			expected:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := NewUpdater(tt.options...).Discover(tt.entrypoint)
+			if err != nil {
+				t.Fatalf("Discover failed: %v", err)
+			}
+
+			var relFiles []string
+			for _, file := range files {
+				relFile, err := filepath.Rel(tempDir, file)
+				if err != nil {
+					t.Fatalf("unexpected path %s: %v", file, err)
+				}
+				relFiles = append(relFiles, filepath.ToSlash(relFile))
+			}
+
+			if strings.Join(relFiles, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Discover() = %v, expected %v", relFiles, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdater_Apply_Transactional(t *testing.T) {
+	const original = "# depup package=app\nversion: 1.0.0\n"
+	const updated = "# depup package=app\nversion: 2.0.0\n"
+
+	tests := []struct {
+		name          string
+		transactional bool
+		expectedFirst string
+	}{
+		{name: "transactional restores written files", transactional: true, expectedFirst: original},
+		{name: "default keeps written files", transactional: false, expectedFirst: updated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			first := filepath.Join(tempDir, "a.yaml")
+			second := filepath.Join(tempDir, "b.yaml")
+			for _, path := range []string{first, second} {
+				if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+					t.Fatalf("failed to create test file: %v", err)
+				}
+			}
+
+			updater := NewUpdater(WithOutput(io.Discard), WithTransactional(tt.transactional))
+			plan, err := updater.Plan(tempDir, []Package{{Name: "app", Version: "2.0.0"}})
+			if err != nil {
+				t.Fatalf("Plan failed: %v", err)
+			}
+			if len(plan.Files) != 2 || plan.Files[0].Path != first {
+				t.Fatalf("Plan files = %+v, expected a.yaml and b.yaml", plan.Files)
+			}
+
+			// Writing the second file fails once it has been replaced by a directory
+			if err := os.Remove(second); err != nil {
+				t.Fatalf("failed to remove test file: %v", err)
+			}
+			if err := os.Mkdir(second, 0755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+
+			if err := updater.Apply(plan); err == nil {
+				t.Fatalf("Apply expected an error for the second file")
+			}
+
+			content, err := os.ReadFile(first)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedFirst {
+				t.Errorf("a.yaml = %q, expected %q", string(content), tt.expectedFirst)
+			}
+			if changes := updater.Changes(); tt.transactional && len(changes) != 0 {
+				t.Errorf("Changes() = %+v, expected none after a rollback", changes)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_Transactional(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "test.yaml")
+	if err := os.WriteFile(filePath, []byte("# depup package=app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	updater := NewUpdater(WithOutput(&out), WithTransactional(true))
+	if err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if expected := "# depup package=app\nversion: 2.0.0\n"; string(content) != expected {
+		t.Errorf("test.yaml = %q, expected %q", string(content), expected)
+	}
+	if len(updater.Changes()) != 1 || !strings.Contains(out.String(), "1.0.0 -> 2.0.0") {
+		t.Errorf("Update reported %q with changes %+v, expected one change", out.String(), updater.Changes())
+	}
+}
+
+func TestUpdater_Update_TransactionalForceWrite(t *testing.T) {
+	const original = "# depup package=app\nversion: 2.0.0\n"
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "test.yaml")
+	if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	staleTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filePath, staleTime, staleTime); err != nil {
+		t.Fatalf("failed to age test file: %v", err)
+	}
+
+	// Unchanged files aren't part of the plan, so the combination is rejected rather than silently ignored
+	updater := NewUpdater(WithOutput(io.Discard), WithTransactional(true), WithForceWrite(true))
+	err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}})
+	if err == nil || !strings.Contains(err.Error(), "force write cannot be combined with transactional writes") {
+		t.Fatalf("Update() error = %v, expected the combination to be rejected", err)
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("failed to stat test file: %v", err)
+	}
+	if !fileInfo.ModTime().Equal(staleTime) {
+		t.Errorf("file was written although the run was rejected")
+	}
+
+	// Dry runs write nothing either way
+	updater = NewUpdater(WithOutput(io.Discard), WithTransactional(true), WithForceWrite(true), WithDryRun(true))
+	if err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+		t.Errorf("Update() dry run error = %v", err)
+	}
+}
+
+func TestUpdater_Update_Verify(t *testing.T) {
+	tests := []struct {
+		name          string
+		mock          bool
+		expectedError string
+	}{
+		{
+			name: "written content matches",
+		},
+		{
+			// The mock reports updated content without writing it, like a filesystem silently dropping the write
+			name:          "written content differs",
+			mock:          true,
+			expectedError: "content read back differs from the written content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.yaml")
+			if err := os.WriteFile(filePath, []byte("# depup package=app\nversion: 1.0.0\n"), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			updater := NewUpdater(WithOutput(io.Discard), WithVerify(true))
+			if tt.mock {
+				updater.updaters = []FileUpdater{NewMockFileUpdater([]string{".yaml"}, false, true)}
+			}
+
+			err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}})
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("Update failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Update() error = %v, expected it to contain %q", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestVerifyWrite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.yaml")
+	if err := os.WriteFile(filePath, []byte("version: 2.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		expected      string
+		changes       []Change
+		expectedError string
+	}{
+		{name: "matching content", expected: "version: 2.0.0\n", changes: []Change{{Package: "app", NewVersion: "2.0.0"}}},
+		{name: "differing content", expected: "version: 2.0.0\r\n", expectedError: "content read back differs"},
+		{name: "missing version", expected: "version: 2.0.0\n", changes: []Change{{Package: "app", NewVersion: "3.0.0"}}, expectedError: "new version 3.0.0 of app is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyWrite(filePath, tt.expected, tt.changes)
+			if tt.expectedError == "" && err != nil || tt.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedError)) {
+				t.Errorf("verifyWrite() error = %v, expected error %q", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+// directoryTrackingUpdater records the highest number of files of a directory processed at the same time
+type directoryTrackingUpdater struct {
+	*MockFileUpdater
+	mu        sync.Mutex
+	active    map[string]int
+	maxActive int
+}
+
+func (d *directoryTrackingUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
+	dir := filepath.Dir(filePath)
+	d.mu.Lock()
+	d.active[dir]++
+	d.maxActive = max(d.maxActive, d.active[dir])
+	d.mu.Unlock()
+
+	// Give overlapping calls a chance to be observed
+	time.Sleep(time.Millisecond)
+
+	d.mu.Lock()
+	d.active[dir]--
+	d.mu.Unlock()
+	return "", nil, nil
+}
+
+func TestUpdater_Update_DirectoryProcessedSerially(t *testing.T) {
+	// Tools watching directory mtimes rely on files of a directory never being processed at the same time
+	tempDir := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		for i := range 5 {
+			path := filepath.Join(tempDir, dir, fmt.Sprintf("file%d.yaml", i))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte("# depup package=app\nversion: 1.0.0\n"), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+		}
+	}
+
+	tracker := &directoryTrackingUpdater{MockFileUpdater: NewMockFileUpdater([]string{".yaml"}, false, true), active: map[string]int{}}
+	updater := NewUpdater(WithOutput(io.Discard))
+	updater.updaters = []FileUpdater{tracker}
+
+	if err := updater.Update(tempDir, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if tracker.maxActive != 1 {
+		t.Errorf("up to %d files of a directory were processed at the same time, expected 1", tracker.maxActive)
+	}
+}
+
+func TestUpdater_UpdateContext_Canceled(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "app.yaml")
+	if err := os.WriteFile(filePath, []byte("# depup package=app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	updater := NewUpdater(WithOutput(io.Discard))
+	err := updater.UpdateContext(ctx, tempDir, []Package{{Name: "app", Version: "2.0.0"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("UpdateContext() error = %v, expected context.Canceled", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if string(content) != "# depup package=app\nversion: 1.0.0\n" {
+		t.Errorf("file was modified after cancellation: %q", string(content))
+	}
+}
+
+func TestUpdater_Update_PackageFilePattern(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"frontend/app.yaml": "# depup package=app\nversion: 1.0.0\n",
+		"backend/app.yaml":  "# depup package=app\nversion: 1.0.0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		packages []Package
+		expected map[string]string
+	}{
+		{
+			name:     "relative path glob",
+			packages: []Package{{Name: "app", Version: "2.0.0", File: "frontend/*.yaml"}},
+			expected: map[string]string{
+				"frontend/app.yaml": "2.0.0",
+				"backend/app.yaml":  "1.0.0",
+			},
+		},
+		{
+			name: "different versions per file",
+			packages: []Package{
+				{Name: "app", Version: "3.0.0", File: "backend/app.yaml"},
+				{Name: "app", Version: "4.0.0", File: "frontend/app.yaml"},
+			},
+			expected: map[string]string{
+				"frontend/app.yaml": "4.0.0",
+				"backend/app.yaml":  "3.0.0",
+			},
+		},
+		{
+			name:     "base name glob matches all",
+			packages: []Package{{Name: "app", Version: "5.0.0", File: "app.yaml"}},
+			expected: map[string]string{
+				"frontend/app.yaml": "5.0.0",
+				"backend/app.yaml":  "5.0.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := NewUpdater(WithOutput(io.Discard))
+			if err := updater.Update(tempDir, tt.packages); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+
+			for name, version := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if expected := "# depup package=app\nversion: " + version + "\n"; string(content) != expected {
+					t.Errorf("%s = %q, expected %q", name, string(content), expected)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_FromGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		ext         string
+		fileContent string
+		expected    string
+		skipped     []Skip
+		output      string
+	}{
+		{
+			name:        "YAML matching guard",
+			ext:         ".yaml",
+			fileContent: "# depup package=app from=1.2.3\nversion: 1.2.3\n",
+			expected:    "# depup package=app from=1.2.3\nversion: 2.0.0\n",
+			output:      "Updated app.yaml:2 app 1.2.3 -> 2.0.0\n",
+		},
+		{
+			name:        "YAML mismatching guard",
+			ext:         ".yaml",
+			fileContent: "# depup package=app from=1.2.3\nversion: 1.2.4\n",
+			expected:    "# depup package=app from=1.2.3\nversion: 1.2.4\n",
+			skipped:     []Skip{{Line: 2, Package: "app", Version: "1.2.4", Reason: "current version 1.2.4 doesn't match from=1.2.3"}},
+			output:      "Skipped app.yaml:2 app: current version 1.2.4 doesn't match from=1.2.3\n",
+		},
+		{
+			name:        "YAML inline mismatching guard",
+			ext:         ".yaml",
+			fileContent: "version: 1.2.4 # depup package=app from=1.2.3\n",
+			expected:    "version: 1.2.4 # depup package=app from=1.2.3\n",
+			skipped:     []Skip{{Line: 1, Package: "app", Version: "1.2.4", Reason: "current version 1.2.4 doesn't match from=1.2.3"}},
+			output:      "Skipped app.yaml:1 app: current version 1.2.4 doesn't match from=1.2.3\n",
+		},
+		{
+			name:        "YAML inline guard after an inline annotated line",
+			ext:         ".yaml",
+			fileContent: "b: 1.0.0 # depup package=app\nc: 1.0.0 # depup package=app from=0.9.0\n",
+			expected:    "b: 2.0.0 # depup package=app\nc: 1.0.0 # depup package=app from=0.9.0\n",
+			skipped:     []Skip{{Line: 2, Package: "app", Version: "1.0.0", Reason: "current version 1.0.0 doesn't match from=0.9.0"}},
+			output:      "Updated app.yaml:1 app 1.0.0 -> 2.0.0\nSkipped app.yaml:2 app: current version 1.0.0 doesn't match from=0.9.0\n",
+		},
+		{
+			name:        "YAML inline guard after an inline replace template",
+			ext:         ".yaml",
+			fileContent: "b: app:1.0.0 # depup package=app replace=newrepo/app:{version}\nc: 1.0.0 # depup package=app from=0.9.0\n",
+			expected:    "b: newrepo/app:2.0.0 # depup package=app replace=newrepo/app:{version}\nc: 1.0.0 # depup package=app from=0.9.0\n",
+			skipped:     []Skip{{Line: 2, Package: "app", Version: "1.0.0", Reason: "current version 1.0.0 doesn't match from=0.9.0"}},
+			output:      "Updated app.yaml:1 app 1.0.0 -> 2.0.0\nSkipped app.yaml:2 app: current version 1.0.0 doesn't match from=0.9.0\n",
+		},
+		{
+			name:        "YAML already at target version",
+			ext:         ".yaml",
+			fileContent: "# depup package=app from=1.2.3\nversion: 2.0.0\n",
+			expected:    "# depup package=app from=1.2.3\nversion: 2.0.0\n",
+		},
+		{
+			name:        "HCL matching guard",
+			ext:         ".tf",
+			fileContent: "# depup package=app from=1.2.3\nversion = \"1.2.3\"\n",
+			expected:    "# depup package=app from=1.2.3\nversion = \"2.0.0\"\n",
+			output:      "Updated app.tf:2 app 1.2.3 -> 2.0.0\n",
+		},
+		{
+			name:        "HCL mismatching guard",
+			ext:         ".tf",
+			fileContent: "version = \"1.0.0\" // depup package=app from=1.2.3\n",
+			expected:    "version = \"1.0.0\" // depup package=app from=1.2.3\n",
+			skipped:     []Skip{{Line: 1, Package: "app", Version: "1.0.0", Reason: "current version 1.0.0 doesn't match from=1.2.3"}},
+			output:      "Skipped app.tf:1 app: current version 1.0.0 doesn't match from=1.2.3\n",
+		},
+		{
+			name:        "dotenv matching guard",
+			ext:         ".env",
+			fileContent: "APP_VERSION=1.2.3 # depup package=app from=1.2.3\n",
+			expected:    "APP_VERSION=2.0.0 # depup package=app from=1.2.3\n",
+			output:      "Updated app.env:1 app 1.2.3 -> 2.0.0\n",
+		},
+		{
+			name:        "dotenv mismatching guard",
+			ext:         ".env",
+			fileContent: "# depup package=app from=1.2.3\nAPP_VERSION=1.3.0\n",
+			expected:    "# depup package=app from=1.2.3\nAPP_VERSION=1.3.0\n",
+			skipped:     []Skip{{Line: 2, Package: "app", Version: "1.3.0", Reason: "current version 1.3.0 doesn't match from=1.2.3"}},
+			output:      "Skipped app.env:2 app: current version 1.3.0 doesn't match from=1.2.3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			filePath := filepath.Join(tempDir, "app"+tt.ext)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			var out bytes.Buffer
+			t.Chdir(tempDir)
+			updater := NewUpdater(WithFileExtensions([]string{tt.ext}), WithOutput(&out), WithRelativePaths(true))
+			if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expected)
+			}
+
+			for i := range tt.skipped {
+				tt.skipped[i].File = filePath
+			}
+			if !reflect.DeepEqual(updater.Skipped(), tt.skipped) {
+				t.Errorf("Skipped() = %+v, expected %+v", updater.Skipped(), tt.skipped)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output = %q, expected %q", out.String(), tt.output)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_AllowLeadingZeros(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		fileContent     string
+		allow           bool
+		expectedContent string
+	}{
+		{
+			name:            "Padded version is left alone by default",
+			fileName:        "values.yaml",
+			fileContent:     "# depup package=app\nversion: 01.2.3\n",
+			expectedContent: "# depup package=app\nversion: 01.2.3\n",
+		},
+		{
+			name:            "Padded version is updated when allowed",
+			fileName:        "values.yaml",
+			fileContent:     "# depup package=app\nversion: 01.2.3\n",
+			allow:           true,
+			expectedContent: "# depup package=app\nversion: 2.0.0\n",
+		},
+		{
+			name:            "Padded components and quotes in HCL",
+			fileName:        "main.tf",
+			fileContent:     "version = \"1.02.003\" # depup package=app\n",
+			allow:           true,
+			expectedContent: "version = \"2.0.0\" # depup package=app\n",
+		},
+		{
+			name:            "Unpadded version when allowed",
+			fileName:        ".env",
+			fileContent:     "APP_VERSION=1.2.3 # depup package=app\n",
+			allow:           true,
+			expectedContent: "APP_VERSION=2.0.0 # depup package=app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			updater := NewUpdater(WithOutput(io.Discard), WithAllowLeadingZeros(tt.allow))
+			if err := updater.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_CommentPrefixes(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		fileContent     string
+		prefixes        map[string][]string
+		expectedContent string
+	}{
+		{
+			name:            "Semicolon comments above and inline",
+			file:            "app.env",
+			fileContent:     "; depup package=app\nAPP_VERSION=1.0.0\nDB_VERSION=1.0.0 ; depup package=app\n",
+			prefixes:        map[string][]string{"dotenv": {";"}},
+			expectedContent: "; depup package=app\nAPP_VERSION=2.0.0\nDB_VERSION=2.0.0 ; depup package=app\n",
+		},
+		{
+			name:            "Several prefixes",
+			file:            "values.yaml",
+			fileContent:     "; depup package=app\nversion: 1.0.0\nother: 1.0.0 # depup package=app\n",
+			prefixes:        map[string][]string{"yaml": {"#", ";"}},
+			expectedContent: "; depup package=app\nversion: 2.0.0\nother: 2.0.0 # depup package=app\n",
+		},
+		{
+			name:            "Replaced prefixes are no longer recognized",
+			file:            "app.env",
+			fileContent:     "# depup package=app\nAPP_VERSION=1.0.0\n",
+			prefixes:        map[string][]string{"dotenv": {";"}},
+			expectedContent: "# depup package=app\nAPP_VERSION=1.0.0\n",
+		},
+		{
+			name:            "Other updaters keep their prefixes",
+			file:            "values.yaml",
+			fileContent:     "# depup package=app\nversion: 1.0.0\n; depup package=app\nother: 1.0.0\n",
+			prefixes:        map[string][]string{"dotenv": {";"}, "unknown": {";"}},
+			expectedContent: "# depup package=app\nversion: 2.0.0\n; depup package=app\nother: 1.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			u := NewUpdater(WithOutput(io.Discard), WithCommentPrefixes(tt.prefixes))
+			if err := u.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_CommentRegex(t *testing.T) {
+	tests := []struct {
+		name            string
+		file            string
+		fileContent     string
+		expectedContent string
+	}{
+		{
+			name:            "YAML above and inline",
+			file:            "values.yaml",
+			fileContent:     "# @dep{app}\nversion: 1.0.0\nimage: app:1.0.0 # @dep{app}\n",
+			expectedContent: "# @dep{app}\nversion: 2.0.0\nimage: app:2.0.0 # @dep{app}\n",
+		},
+		{
+			name:            "Attributes follow the match",
+			file:            "values.yaml",
+			fileContent:     "# @dep{app} scheme=partial\nversion: \"1.0\"\n",
+			expectedContent: "# @dep{app} scheme=partial\nversion: \"2.0\"\n",
+		},
+		{
+			name:            "HCL without a comment prefix",
+			file:            "main.tf",
+			fileContent:     "version = \"1.0.0\" @dep{app}\n",
+			expectedContent: "version = \"2.0.0\" @dep{app}\n",
+		},
+		{
+			name:            "Dotenv",
+			file:            "app.env",
+			fileContent:     "# @dep{app}\nAPP_VERSION=1.0.0\n",
+			expectedContent: "# @dep{app}\nAPP_VERSION=2.0.0\n",
+		},
+		{
+			name:            "Built-in syntax is replaced",
+			file:            "values.yaml",
+			fileContent:     "version: 1.0.0 # depup package=app\n",
+			expectedContent: "version: 1.0.0 # depup package=app\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.fileContent), 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			u := NewUpdater(WithOutput(io.Discard), WithCommentRegex(regexp.MustCompile(`@dep\{([^}]+)\}`)))
+			if err := u.Update(filePath, []Package{{Name: "app", Version: "2.0.0"}}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("file content = %q, expected %q", string(content), tt.expectedContent)
+			}
+		})
+	}
+}
+
+func TestUpdater_Update_CommentRegexOptionalGroup(t *testing.T) {
+	const original = "x: 1.0.0 # foo\n"
+	filePath := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	// The package group doesn't participate in the match, so the line holds no depup comment
+	u := NewUpdater(WithOutput(io.Discard), WithCommentRegex(regexp.MustCompile(`(x)?foo`)))
+	if err := u.Update(filePath, []Package{{Name: "b", Version: "3.0.0"}}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if string(content) != original {
+		t.Errorf("file content = %q, expected %q", string(content), original)
+	}
+}
+
+func TestSupportsExtension(t *testing.T) {
+	// Every line-based updater must treat exact extensions and glob patterns the same way
+	updaters := map[string]func(extensions map[string]struct{}) FileUpdater{
+		"yaml": func(extensions map[string]struct{}) FileUpdater {
+			u := NewYamlFileUpdater()
+			u.supportedFileExtensions = extensions
+			return u
+		},
+		"hcl": func(extensions map[string]struct{}) FileUpdater {
+			u := NewHclFileUpdater()
+			u.supportedFileExtensions = extensions
+			return u
+		},
+		"dotenv": func(extensions map[string]struct{}) FileUpdater {
+			u := NewDotEnvFileUpdater()
+			u.supportedFileExtensions = extensions
+			return u
+		},
+	}
+
+	tests := []struct {
+		name      string
+		supported []string
+		extension string
+		expected  bool
+	}{
+		{name: "exact match", supported: []string{".conf"}, extension: ".conf", expected: true},
+		{name: "exact mismatch", supported: []string{".conf"}, extension: ".cfg", expected: false},
+		{name: "glob on extension", supported: []string{".tf*"}, extension: ".tfvars", expected: true},
+		{name: "glob on file name", supported: []string{"*.json"}, extension: ".json", expected: true},
+		{name: "character class", supported: []string{".y[a]ml"}, extension: ".yaml", expected: true},
+		{name: "glob mismatch", supported: []string{".tf*"}, extension: ".hcl", expected: false},
+		{name: "empty extension", supported: []string{".tf*"}, extension: "", expected: false},
+	}
+
+	for name, newUpdater := range updaters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				extensions := map[string]struct{}{}
+				for _, extension := range tt.supported {
+					extensions[extension] = struct{}{}
+				}
+
+				if result := newUpdater(extensions).Supports(tt.extension); result != tt.expected {
+					t.Errorf("Supports(%q) with %v = %v, expected %v", tt.extension, tt.supported, result, tt.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestMatchesPathPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		path     string
+		pattern  string
+		expected bool
+		windows  bool // Drive letters and UNC paths are only absolute on Windows
+	}{
+		{name: "relative path", root: "/repo", path: "/repo/vendor/app.yaml", pattern: "vendor/*", expected: true},
+		{name: "separator aware pattern", root: "/repo", path: "/repo/vendor/app.yaml", pattern: filepath.FromSlash("vendor/*"), expected: true},
+		{name: "star stops at separator", root: "/repo", path: "/repo/vendor/nested/app.yaml", pattern: "vendor/*", expected: false},
+		{name: "base name", root: "/repo", path: "/repo/deploy/app.yaml", pattern: "*.yaml", expected: true},
+		{name: "mismatch", root: "/repo", path: "/repo/deploy/app.yaml", pattern: "vendor/*", expected: false},
+		{name: "drive letter", root: `C:\proj`, path: `C:\proj\vendor\app.yaml`, pattern: "vendor/*", expected: true, windows: true},
+		{name: "drive letter backslash pattern", root: `C:\proj`, path: `C:\proj\vendor\app.yaml`, pattern: `vendor\*`, expected: true, windows: true},
+		{name: "other drive", root: `C:\proj`, path: `D:\proj\vendor\app.yaml`, pattern: "vendor/*", expected: false, windows: true},
+		{name: "UNC path", root: `\\server\share\proj`, path: `\\server\share\proj\vendor\app.yaml`, pattern: "vendor/*", expected: true, windows: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.windows && runtime.GOOS != "windows" {
+				t.Skip("Windows paths are only supported on Windows")
+			}
+
+			root, path := filepath.FromSlash(tt.root), filepath.FromSlash(tt.path)
+			if matched := matchesPathPattern(root, path, tt.pattern); matched != tt.expected {
+				t.Errorf("matchesPathPattern(%q, %q, %q) = %v, expected %v", root, path, tt.pattern, matched, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateFile(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		ext     string
+		line    string
+		updater FileUpdater
+	}{
+		{name: "yaml", ext: ".yaml", line: "key%d: 1.0.0 # depup package=app\nplain%d: 1.0.0\n", updater: NewYamlFileUpdater()},
+		{name: "hcl", ext: ".tf", line: "key%d = \"1.0.0\" # depup package=app\nplain%d = \"1.0.0\"\n", updater: NewHclFileUpdater()},
+		{name: "dotenv", ext: ".env", line: "KEY_%d=\"1.0.0\" # depup package=app\nPLAIN_%d=1.0.0 # depup package=app\n", updater: NewDotEnvFileUpdater()},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var content strings.Builder
+			for i := 0; i < 1000; i++ {
+				fmt.Fprintf(&content, bm.line, i, i)
+			}
+			tempFile, err := createTempFileWithContent(content.String(), bm.ext)
+			if err != nil {
+				b.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			packages := []Package{{Name: "app", Version: "2.0.0"}}
+			for b.Loop() {
+				if _, _, err := bm.updater.UpdateFile(tempFile, packages, FileUpdaterOptions{DryRun: true}); err != nil {
+					b.Fatalf("UpdateFile() error = %v", err)
+				}
+			}
+		})
+	}
+}
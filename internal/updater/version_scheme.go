@@ -0,0 +1,467 @@
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported version schemes, selected with the scheme attribute of a depup comment
+const (
+	SchemeSemver  = "semver"  // MAJOR.MINOR.PATCH with optional pre-release and build metadata
+	SchemePartial = "partial" // MAJOR.MINOR with an optional PATCH, as used in constraints like "~> 4.0"
+	SchemeInteger = "integer" // A plain number, e.g. a date stamp like 20231201 or a build number
+	SchemeGomod   = "gomod"   // A Go module version, including pseudo-versions and the +incompatible suffix
+)
+
+// Parts of a semantic version incremented by BumpVersion
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// partialVersionPattern matches versions with two or three numeric components
+var /* const */ partialVersionPattern = regexp.MustCompile(`((?:["'][ \t]*)?)(0|[1-9]\d*)\.(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?((?:[ \t]*["'])?)`)
+
+// integerVersionPattern matches a run of digits, standalone integers are selected by findIntegerVersion
+var /* const */ integerVersionPattern = regexp.MustCompile(`\d+`)
+
+// goVersionPattern matches Go module versions, i.e. semantic versions whose pre-release may be a pseudo-version
+// like 0.0.0-20210101000000-abcdef123456, followed by an optional +incompatible suffix
+var /* const */ goVersionPattern = regexp.MustCompile(`((?:["'][ \t]*)?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(\+incompatible)?((?:[ \t]*["'])?)`)
+
+// versionPrefixPattern matches a short prefix in front of a version, like "v", "release-" or "app_v"
+// It is kept conservative, so only a single word followed by a dash or underscore and an optional "v" count.
+var /* const */ versionPrefixPattern = regexp.MustCompile(`^(?:[A-Za-z][A-Za-z0-9]{0,15}[-_])?[vV]?`)
+
+// strictSemverPattern matches a complete semantic version as defined by semver.org
+var /* const */ strictSemverPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// versionMatch is a version found in a line
+type versionMatch struct {
+	text       string // Entire match including quotes
+	startQuote string // Opening quote including inner whitespace, empty if unquoted
+	version    string // The version itself
+	endQuote   string // Closing quote including inner whitespace, empty if unquoted
+}
+
+// versionScheme describes how versions are found in a line and how target versions are written
+type versionScheme struct {
+	find    func(line string) (versionMatch, bool)
+	format  func(current, target string) string
+	compare func(a, b string) int // Orders versions to refuse downgrades, nil if the scheme allows them
+}
+
+// versionSchemes holds all known schemes by name
+var /* const */ versionSchemes = map[string]versionScheme{
+	SchemeSemver: {
+		find: findSemver,
+		format: func(current, target string) string {
+			return target
+		},
+	},
+	SchemePartial: {
+		find:   findPartialVersion,
+		format: formatPartialVersion,
+	},
+	SchemeInteger: {
+		find: findIntegerVersion,
+		format: func(current, target string) string {
+			return target
+		},
+		compare: compareIntegers,
+	},
+	SchemeGomod: {
+		find:   findGoVersion,
+		format: formatGoVersion,
+	},
+}
+
+// lookupScheme returns the scheme of a directive, falling back to the default scheme
+// An empty default selects SchemeSemver. With leadingZeros, semantic versions may have zero-padded components.
+func lookupScheme(d directive, defaultScheme string, leadingZeros bool) (versionScheme, bool) {
+	name := d.attributes["scheme"]
+	if name == "" {
+		name = defaultScheme
+	}
+	if name == "" {
+		name = SchemeSemver
+	}
+
+	scheme, ok := versionSchemes[name]
+	if ok && name == SchemeSemver && leadingZeros {
+		scheme.find = findSemverLeadingZeros
+	}
+	return scheme, ok
+}
+
+// countVersions returns the number of versions of the scheme in the content
+func countVersions(scheme versionScheme, content string) int {
+	count := 0
+	for {
+		match, ok := scheme.find(content)
+		if !ok {
+			return count
+		}
+		count++
+		content = content[strings.Index(content, match.text)+len(match.text):]
+	}
+}
+
+// findSemver finds a semantic version
+// A version preceded by a digit is part of a zero-padded version like 01.2.3, which isn't a semantic version.
+func findSemver(line string) (versionMatch, bool) {
+	for _, location := range versionPattern.FindAllStringSubmatchIndex(line, -1) {
+		if location[0] > 0 && isDigit(line[location[0]-1]) {
+			continue
+		}
+
+		versionMatches := submatches(line, location)
+		return versionMatch{
+			text:       versionMatches[0],
+			startQuote: versionMatches[1],
+			version:    composeVersion(versionMatches),
+			endQuote:   versionMatches[7],
+		}, true
+	}
+
+	return versionMatch{}, false
+}
+
+// leadingZeroVersionPattern is versionPattern with zero-padded major, minor and patch components, e.g. 01.02.003
+var /* const */ leadingZeroVersionPattern = regexp.MustCompile(strings.ReplaceAll(versionPattern.String(), `>0|[1-9]\d*)`, `>\d+)`))
+
+// findSemverLeadingZeros finds a semantic version whose major, minor and patch components may be zero-padded
+// The version of the match has the padding removed, so 01.2.3 equals 1.2.3, while the text keeps it.
+func findSemverLeadingZeros(line string) (versionMatch, bool) {
+	versionMatches := leadingZeroVersionPattern.FindStringSubmatch(line)
+	if versionMatches == nil {
+		return versionMatch{}, false
+	}
+	for i := 2; i <= 4; i++ {
+		if trimmed := strings.TrimLeft(versionMatches[i], "0"); trimmed != "" {
+			versionMatches[i] = trimmed
+		} else {
+			versionMatches[i] = "0"
+		}
+	}
+
+	return versionMatch{
+		text:       versionMatches[0],
+		startQuote: versionMatches[1],
+		version:    composeVersion(versionMatches),
+		endQuote:   versionMatches[7],
+	}, true
+}
+
+// submatches returns the submatches of a regular expression match given by its indices, unmatched groups are empty
+func submatches(line string, location []int) []string {
+	result := make([]string, len(location)/2)
+	for i := range result {
+		if location[2*i] >= 0 {
+			result[i] = line[location[2*i]:location[2*i+1]]
+		}
+	}
+	return result
+}
+
+// isDigit reports whether the byte is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// findPartialVersion finds a version with two or three numeric components
+func findPartialVersion(line string) (versionMatch, bool) {
+	versionMatches := partialVersionPattern.FindStringSubmatch(line)
+	if versionMatches == nil {
+		return versionMatch{}, false
+	}
+
+	version := versionMatches[2] + "." + versionMatches[3]
+	if versionMatches[4] != "" {
+		version += "." + versionMatches[4]
+	}
+
+	return versionMatch{
+		text:       versionMatches[0],
+		startQuote: versionMatches[1],
+		version:    version,
+		endQuote:   versionMatches[5],
+	}, true
+}
+
+// findIntegerVersion finds the first standalone integer, i.e. digits that aren't part of a word or a dotted version
+// e.g. 20231201 in "BUILD_DATE=20231201" but nothing in "version: 1.2.3" or "image: app2"
+func findIntegerVersion(line string) (versionMatch, bool) {
+	for _, index := range integerVersionPattern.FindAllStringIndex(line, -1) {
+		start, end := index[0], index[1]
+		if start > 0 && isVersionTokenByte(line[start-1]) || end < len(line) && isVersionTokenByte(line[end]) {
+			continue
+		}
+
+		match := versionMatch{text: line[start:end], version: line[start:end]}
+		if start > 0 && end < len(line) && strings.ContainsRune(`"'`, rune(line[start-1])) && line[start-1] == line[end] {
+			match.startQuote = line[start-1 : start]
+			match.endQuote = line[end : end+1]
+			match.text = line[start-1 : end+1]
+		}
+		return match, true
+	}
+
+	return versionMatch{}, false
+}
+
+// findGoVersion finds a Go module version, keeping pseudo-versions and the +incompatible suffix in one match
+// e.g. 0.0.0-20210101000000-abcdef123456 in "golang.org/x/net v0.0.0-20210101000000-abcdef123456"
+func findGoVersion(line string) (versionMatch, bool) {
+	for _, location := range goVersionPattern.FindAllStringSubmatchIndex(line, -1) {
+		if location[0] > 0 && isDigit(line[location[0]-1]) {
+			continue
+		}
+
+		versionMatches := submatches(line, location)
+		return versionMatch{
+			text:       versionMatches[0],
+			startQuote: versionMatches[1],
+			version:    strings.Join(versionMatches[2:5], ".") + versionMatches[5] + versionMatches[6],
+			endQuote:   versionMatches[7],
+		}, true
+	}
+
+	return versionMatch{}, false
+}
+
+// formatGoVersion keeps the +incompatible suffix of the current version if the target has no build metadata
+// Only major versions 2 and above can be incompatible, so e.g. current "2.1.0+incompatible" and target "2.2.0"
+// result in "2.2.0+incompatible", while target "1.0.0" is written unchanged
+func formatGoVersion(current, target string) string {
+	if !strings.HasSuffix(current, "+incompatible") || strings.Contains(target, "+") {
+		return target
+	}
+
+	major, _, _ := strings.Cut(trimVPrefix(target), ".")
+	if number, err := strconv.Atoi(major); err != nil || number < 2 {
+		return target
+	}
+	return target + "+incompatible"
+}
+
+// isVersionTokenByte reports whether the byte continues a word or dotted version around an integer
+func isVersionTokenByte(b byte) bool {
+	return b == '.' || b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// isIntegerVersion reports whether the version is a plain number as written by SchemeInteger
+func isIntegerVersion(version string) bool {
+	match, ok := findIntegerVersion(version)
+	return ok && match.text == version
+}
+
+// compareIntegers compares two integers of arbitrary length numerically and returns -1, 0 or 1
+func compareIntegers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInts(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// splitVersionPrefix splits a prefix matching versionPrefixPattern from a value starting with a version
+// e.g. "release-1.2.3" results in "release-" and "1.2.3", values without a prefix are returned unchanged
+func splitVersionPrefix(value string) (string, string) {
+	prefix := versionPrefixPattern.FindString(value)
+	if prefix == "" || len(prefix) == len(value) || value[len(prefix)] < '0' || value[len(prefix)] > '9' {
+		return "", value
+	}
+	return prefix, value[len(prefix):]
+}
+
+// vPrefix returns the leading "v" or "V" of a value starting with a version, or an empty string if there is none
+func vPrefix(value string) string {
+	if len(value) > 1 && (value[0] == 'v' || value[0] == 'V') && value[1] >= '0' && value[1] <= '9' {
+		return value[:1]
+	}
+	return ""
+}
+
+// trimVPrefix removes a leading "v" or "V" from a version, e.g. "v1.2.3" results in "1.2.3"
+// A "v" in front of a version in a file is never part of the match, so it stays whether the target has one or not
+func trimVPrefix(version string) string {
+	return version[len(vPrefix(version)):]
+}
+
+// packageVersion returns the version of the package to write, without its prefix if the prefix of the file is kept
+func packageVersion(pkg Package, prefixAuto bool) string {
+	if !prefixAuto {
+		return pkg.Version
+	}
+	_, version := splitVersionPrefix(pkg.Version)
+	return version
+}
+
+// formatPartialVersion shortens the target to the number of components of the current version
+// e.g. current "4.0" and target "4.5.2" result in "4.5"
+func formatPartialVersion(current, target string) string {
+	core, _, _ := strings.Cut(target, "+")
+	core, _, _ = strings.Cut(core, "-")
+
+	components := strings.Split(core, ".")
+	precision := strings.Count(current, ".") + 1
+	if precision >= len(components) {
+		return target
+	}
+
+	return strings.Join(components[:precision], ".")
+}
+
+// versionsEqual checks if the current version already equals the target version
+// In canonical mode, versions with the same semver precedence are equal, e.g. "1.2" and "1.2.0"
+func versionsEqual(current, target string, canonical bool) bool {
+	if !canonical {
+		return current == target
+	}
+	return compareVersions(current, target) == 0
+}
+
+// CompareVersions compares two versions by semver precedence and returns -1, 0 or 1
+// Prefixes like "v" are ignored, so "v1.2.0" equals "1.2.0"
+func CompareVersions(a, b string) int {
+	_, a = splitVersionPrefix(a)
+	_, b = splitVersionPrefix(b)
+	return compareVersions(a, b)
+}
+
+// compareVersions compares two versions by semver precedence and returns -1, 0 or 1
+// Missing numeric components count as zero and build metadata is ignored
+func compareVersions(a, b string) int {
+	aCore, aPrerelease := splitVersion(a)
+	bCore, bPrerelease := splitVersion(b)
+
+	// Compare the numeric components, padding the shorter version with zeros
+	for i := 0; i < max(len(aCore), len(bCore)); i++ {
+		aComponent, bComponent := "0", "0"
+		if i < len(aCore) {
+			aComponent = aCore[i]
+		}
+		if i < len(bCore) {
+			bComponent = bCore[i]
+		}
+		if result := compareIdentifiers(aComponent, bComponent); result != 0 {
+			return result
+		}
+	}
+
+	// A version without pre-release has a higher precedence than one with
+	switch {
+	case aPrerelease == "" && bPrerelease == "":
+		return 0
+	case aPrerelease == "":
+		return 1
+	case bPrerelease == "":
+		return -1
+	}
+
+	aIdentifiers := strings.Split(aPrerelease, ".")
+	bIdentifiers := strings.Split(bPrerelease, ".")
+	for i := 0; i < min(len(aIdentifiers), len(bIdentifiers)); i++ {
+		if result := compareIdentifiers(aIdentifiers[i], bIdentifiers[i]); result != 0 {
+			return result
+		}
+	}
+
+	return compareInts(len(aIdentifiers), len(bIdentifiers))
+}
+
+// splitVersion returns the numeric components and the pre-release of a version, dropping build metadata
+func splitVersion(version string) ([]string, string) {
+	version, _, _ = strings.Cut(version, "+")
+	core, prerelease, _ := strings.Cut(version, "-")
+	return strings.Split(core, "."), prerelease
+}
+
+// compareIdentifiers compares numeric identifiers numerically and other identifiers lexically
+// Numeric identifiers have a lower precedence than alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	aNumber, aErr := strconv.Atoi(a)
+	bNumber, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(aNumber, bNumber)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareInts returns -1, 0 or 1 depending on the order of a and b
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// BumpVersion increments the part of a semantic version, resetting the lower parts and dropping
+// pre-release and build metadata, e.g. bumping the minor part of v1.2.3-rc.1 results in v1.3.0
+// A prefix like "v" is kept.
+func BumpVersion(version, part string) (string, error) {
+	prefix, core := splitVersionPrefix(version)
+	match, ok := findSemver(core)
+	if !ok || match.text != core {
+		return "", fmt.Errorf("cannot bump %q: not a semantic version", version)
+	}
+
+	// The major, minor and patch groups of versionPattern follow the quote group
+	versionMatches := versionPattern.FindStringSubmatch(core)
+	numbers := make([]int, 3)
+	for i := range numbers {
+		number, err := strconv.Atoi(versionMatches[i+2])
+		if err != nil {
+			return "", fmt.Errorf("cannot bump %q: %w", version, err)
+		}
+		numbers[i] = number
+	}
+
+	switch part {
+	case BumpMajor:
+		numbers = []int{numbers[0] + 1, 0, 0}
+	case BumpMinor:
+		numbers = []int{numbers[0], numbers[1] + 1, 0}
+	case BumpPatch:
+		numbers = []int{numbers[0], numbers[1], numbers[2] + 1}
+	default:
+		return "", fmt.Errorf("invalid part %q: expected %q, %q or %q", part, BumpMajor, BumpMinor, BumpPatch)
+	}
+
+	return fmt.Sprintf("%s%d.%d.%d", prefix, numbers[0], numbers[1], numbers[2]), nil
+}
+
+// isStrictSemver reports whether the version is a strict semantic version, e.g. 1.2.3 but not 1.2 or 1.02.3
+func isStrictSemver(version string) bool {
+	return strictSemverPattern.MatchString(version)
+}
+
+// isStrictSemverIn reports whether the version is written as a strict semantic version in the line
+// The version must not be part of a longer number, such as 1.2.3 in 01.2.3 or 1.2.3.4
+func isStrictSemverIn(line, version string) bool {
+	index := strings.Index(line, version)
+	if !isStrictSemver(version) || index < 0 {
+		return false
+	}
+
+	end := index + len(version)
+	continuesNumber := func(c byte) bool { return c == '.' || c >= '0' && c <= '9' }
+
+	return (index == 0 || !continuesNumber(line[index-1])) && (end == len(line) || !continuesNumber(line[end]))
+}
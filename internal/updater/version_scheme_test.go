@@ -0,0 +1,410 @@
+package updater
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFormatPartialVersion(t *testing.T) {
+	tests := []struct {
+		current  string
+		target   string
+		expected string
+	}{
+		{current: "4.0", target: "4.5.2", expected: "4.5"},
+		{current: "4.0", target: "5.0.0-rc.1", expected: "5.0"},
+		{current: "4.0.1", target: "4.5.2", expected: "4.5.2"},
+		{current: "4.0.1", target: "4.5.2+build", expected: "4.5.2+build"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.target, func(t *testing.T) {
+			if result := formatPartialVersion(tt.current, tt.target); result != tt.expected {
+				t.Errorf("formatPartialVersion(%q, %q) = %q, expected %q", tt.current, tt.target, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindIntegerVersion(t *testing.T) {
+	tests := []struct {
+		line            string
+		expectOk        bool
+		expectedVersion string
+		expectedText    string
+	}{
+		{line: "SNAPSHOT=20231201", expectOk: true, expectedVersion: "20231201", expectedText: "20231201"},
+		{line: `build: "42"`, expectOk: true, expectedVersion: "42", expectedText: `"42"`},
+		{line: "image: app2:7", expectOk: true, expectedVersion: "7", expectedText: "7"},
+		{line: "version: 1.2.3", expectOk: false},
+		{line: "name: v2", expectOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			match, ok := findIntegerVersion(tt.line)
+			if ok != tt.expectOk {
+				t.Fatalf("findIntegerVersion(%q) ok = %v, expected %v", tt.line, ok, tt.expectOk)
+			}
+			if match.version != tt.expectedVersion || match.text != tt.expectedText {
+				t.Errorf("findIntegerVersion(%q) = %q (%q), expected %q (%q)", tt.line, match.version, match.text, tt.expectedVersion, tt.expectedText)
+			}
+		})
+	}
+}
+
+func TestFindGoVersion(t *testing.T) {
+	tests := []struct {
+		line            string
+		expectOk        bool
+		expectedVersion string
+		expectedText    string
+	}{
+		{line: "golang.org/x/net v0.0.0-20210101000000-abcdef123456", expectOk: true, expectedVersion: "0.0.0-20210101000000-abcdef123456", expectedText: "0.0.0-20210101000000-abcdef123456"},
+		{line: "version: v1.2.4-0.20210101000000-012345678901", expectOk: true, expectedVersion: "1.2.4-0.20210101000000-012345678901", expectedText: "1.2.4-0.20210101000000-012345678901"},
+		{line: "version: v2.1.0+incompatible", expectOk: true, expectedVersion: "2.1.0+incompatible", expectedText: "2.1.0+incompatible"},
+		{line: `version: "2.1.0+incompatible"`, expectOk: true, expectedVersion: "2.1.0+incompatible", expectedText: `"2.1.0+incompatible"`},
+		{line: "version: v1.2.3-rc.1", expectOk: true, expectedVersion: "1.2.3-rc.1", expectedText: "1.2.3-rc.1"},
+		{line: "version: 01.2.3", expectOk: false},
+		{line: "version: 1.2", expectOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			match, ok := findGoVersion(tt.line)
+			if ok != tt.expectOk {
+				t.Fatalf("findGoVersion(%q) ok = %v, expected %v", tt.line, ok, tt.expectOk)
+			}
+			if match.version != tt.expectedVersion || match.text != tt.expectedText {
+				t.Errorf("findGoVersion(%q) = %q (%q), expected %q (%q)", tt.line, match.version, match.text, tt.expectedVersion, tt.expectedText)
+			}
+		})
+	}
+}
+
+func TestFormatGoVersion(t *testing.T) {
+	tests := []struct {
+		current  string
+		target   string
+		expected string
+	}{
+		{current: "2.1.0+incompatible", target: "2.2.0", expected: "2.2.0+incompatible"},
+		{current: "2.1.0+incompatible", target: "v3.0.0", expected: "v3.0.0+incompatible"},
+		{current: "2.1.0+incompatible", target: "2.2.0+incompatible", expected: "2.2.0+incompatible"},
+		{current: "2.1.0+incompatible", target: "1.5.0", expected: "1.5.0"},
+		{current: "2.1.0", target: "2.2.0", expected: "2.2.0"},
+		{current: "0.0.0-20210101000000-abcdef123456", target: "0.0.0-20220101000000-fedcba654321", expected: "0.0.0-20220101000000-fedcba654321"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.target, func(t *testing.T) {
+			if got := formatGoVersion(tt.current, tt.target); got != tt.expected {
+				t.Errorf("formatGoVersion(%q, %q) = %q, expected %q", tt.current, tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindSemver_LeadingZeros(t *testing.T) {
+	tests := []struct {
+		line            string
+		leadingZeros    bool
+		expectOk        bool
+		expectedVersion string
+		expectedText    string
+	}{
+		{line: "version: 1.2.3", expectOk: true, expectedVersion: "1.2.3", expectedText: "1.2.3"},
+		{line: "version: 01.2.3", expectOk: false},
+		{line: "image: app:v01.2.3", expectOk: false},
+		{line: "versions: 01.2.3 1.4.0", expectOk: true, expectedVersion: "1.4.0", expectedText: "1.4.0"},
+		{line: "version: 10.2.3", expectOk: true, expectedVersion: "10.2.3", expectedText: "10.2.3"},
+		{line: "version: 01.2.3", leadingZeros: true, expectOk: true, expectedVersion: "1.2.3", expectedText: "01.2.3"},
+		{line: `version: "1.02.003-rc.1"`, leadingZeros: true, expectOk: true, expectedVersion: "1.2.3-rc.1", expectedText: `"1.02.003-rc.1"`},
+		{line: "version: 00.0.0", leadingZeros: true, expectOk: true, expectedVersion: "0.0.0", expectedText: "00.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			scheme, _ := lookupScheme(directive{}, SchemeSemver, tt.leadingZeros)
+			match, ok := scheme.find(tt.line)
+			if ok != tt.expectOk {
+				t.Fatalf("find(%q) ok = %v, expected %v", tt.line, ok, tt.expectOk)
+			}
+			if match.version != tt.expectedVersion || match.text != tt.expectedText {
+				t.Errorf("find(%q) = %q (%q), expected %q (%q)", tt.line, match.version, match.text, tt.expectedVersion, tt.expectedText)
+			}
+		})
+	}
+}
+
+func TestCompareIntegers(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "20231201", b: "20240115", expected: -1},
+		{a: "100", b: "99", expected: 1},
+		{a: "007", b: "7", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"<=>"+tt.b, func(t *testing.T) {
+			if result := compareIntegers(tt.a, tt.b); result != tt.expected {
+				t.Errorf("compareIntegers(%q, %q) = %d, expected %d", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitVersionPrefix(t *testing.T) {
+	tests := []struct {
+		value           string
+		expectedPrefix  string
+		expectedVersion string
+	}{
+		{value: "v1.2.3", expectedPrefix: "v", expectedVersion: "1.2.3"},
+		{value: "release-1.2.3", expectedPrefix: "release-", expectedVersion: "1.2.3"},
+		{value: "app_v1.2.3", expectedPrefix: "app_v", expectedVersion: "1.2.3"},
+		{value: "1.2.3", expectedPrefix: "", expectedVersion: "1.2.3"},
+		{value: "my-long-release-1.2.3", expectedPrefix: "", expectedVersion: "my-long-release-1.2.3"},
+		{value: "release-", expectedPrefix: "", expectedVersion: "release-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			prefix, version := splitVersionPrefix(tt.value)
+			if prefix != tt.expectedPrefix || version != tt.expectedVersion {
+				t.Errorf("splitVersionPrefix(%q) = %q, %q, expected %q, %q", tt.value, prefix, version, tt.expectedPrefix, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestTrimVPrefix(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{version: "v1.2.3", expected: "1.2.3"},
+		{version: "V1.2.3", expected: "1.2.3"},
+		{version: "1.2.3", expected: "1.2.3"},
+		{version: "release-1.2.3", expected: "release-1.2.3"},
+		{version: "v", expected: "v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if version := trimVPrefix(tt.version); version != tt.expected {
+				t.Errorf("trimVPrefix(%q) = %q, expected %q", tt.version, version, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountVersions(t *testing.T) {
+	tests := []struct {
+		content  string
+		scheme   string
+		expected int
+	}{
+		{content: "image: app:1.0.0", scheme: SchemeSemver, expected: 1},
+		{content: "image: app:1.0.0 lib:2.0.0-rc.1", scheme: SchemeSemver, expected: 2},
+		{content: "image: app:latest", scheme: SchemeSemver, expected: 0},
+		{content: "versions: 4.0, 4.1.2", scheme: SchemePartial, expected: 2},
+		{content: "range: 20231201-20240101", scheme: SchemeInteger, expected: 0},
+		{content: "build: 41 next: 42", scheme: SchemeInteger, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if count := countVersions(versionSchemes[tt.scheme], tt.content); count != tt.expected {
+				t.Errorf("countVersions(%q) = %d, expected %d", tt.content, count, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		part        string
+		expected    string
+		expectError bool
+	}{
+		{version: "1.2.3", part: BumpPatch, expected: "1.2.4"},
+		{version: "1.2.3", part: BumpMinor, expected: "1.3.0"},
+		{version: "1.2.3", part: BumpMajor, expected: "2.0.0"},
+		{version: "1.2.3-rc.1", part: BumpPatch, expected: "1.2.4"},
+		{version: "1.2.3-rc.1+build.5", part: BumpMinor, expected: "1.3.0"},
+		{version: "0.9.9+build", part: BumpMajor, expected: "1.0.0"},
+		{version: "v1.2.3", part: BumpPatch, expected: "v1.2.4"},
+		{version: "release-1.9.0", part: BumpMinor, expected: "release-1.10.0"},
+		{version: "1.2", part: BumpPatch, expectError: true},
+		{version: "1.2.3.post1", part: BumpPatch, expectError: true},
+		{version: "1.2.3", part: "build", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.part, func(t *testing.T) {
+			version, err := BumpVersion(tt.version, tt.part)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("BumpVersion(%q, %q) error = %v, expectError %v", tt.version, tt.part, err, tt.expectError)
+			}
+			if version != tt.expected {
+				t.Errorf("BumpVersion(%q, %q) = %q, expected %q", tt.version, tt.part, version, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDirective(t *testing.T) {
+	pattern := newCommentPattern("#")
+
+	tests := []struct {
+		name               string
+		pattern            *regexp.Regexp // Comment pattern, the built-in "#" pattern if nil
+		line               string
+		expectOk           bool
+		expectedPackage    string
+		expectedPackages   []string
+		expectedAttributes map[string]string
+	}{
+		{
+			name:               "package only",
+			line:               "# depup package=aws",
+			expectOk:           true,
+			expectedPackage:    "aws",
+			expectedAttributes: map[string]string{},
+		},
+		{
+			name:               "with scheme attribute",
+			line:               "# depup package=aws scheme=partial",
+			expectOk:           true,
+			expectedPackage:    "aws",
+			expectedAttributes: map[string]string{"scheme": "partial"},
+		},
+		{
+			name:               "free text ends the attributes",
+			line:               "# depup package=aws see docs scheme=partial",
+			expectOk:           true,
+			expectedPackage:    "aws",
+			expectedAttributes: map[string]string{},
+		},
+		{
+			name:               "comma separated packages",
+			line:               "# depup package=app,sidecar scheme=partial",
+			expectOk:           true,
+			expectedPackage:    "app",
+			expectedPackages:   []string{"app", "sidecar"},
+			expectedAttributes: map[string]string{"scheme": "partial"},
+		},
+		{
+			name:               "repeated package attributes",
+			line:               "# depup package=app package=sidecar,proxy",
+			expectOk:           true,
+			expectedPackage:    "app",
+			expectedPackages:   []string{"app", "sidecar", "proxy"},
+			expectedAttributes: map[string]string{},
+		},
+		{
+			name:               "empty list entries are dropped",
+			line:               "# depup package=,app,",
+			expectOk:           true,
+			expectedPackage:    "app",
+			expectedAttributes: map[string]string{},
+		},
+		{
+			name:               "replace template",
+			line:               "# depup package=app replace=newrepo/app:{version}",
+			expectOk:           true,
+			expectedPackage:    "app",
+			expectedAttributes: map[string]string{"replace": "newrepo/app:{version}"},
+		},
+		{
+			name:     "replace template without placeholder",
+			line:     "# depup package=app replace=x",
+			expectOk: false,
+		},
+		{
+			name:     "no package name",
+			line:     "# depup package=,",
+			expectOk: false,
+		},
+		{
+			name:     "no depup comment",
+			line:     "# just a comment",
+			expectOk: false,
+		},
+		{
+			name:               "custom pattern",
+			pattern:            regexp.MustCompile(`@dep\{([^}]+)\}`),
+			line:               "version: 1.0.0 # @dep{app}",
+			expectOk:           true,
+			expectedPackage:    "app",
+			expectedAttributes: map[string]string{},
+		},
+		{
+			name:     "custom pattern without participating package group",
+			pattern:  regexp.MustCompile(`(x)?foo`),
+			line:     "x: 1.0.0 # foo",
+			expectOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentPattern := pattern
+			if tt.pattern != nil {
+				commentPattern = tt.pattern
+			}
+			d, ok := parseDirective(commentPattern, tt.line)
+			if ok != tt.expectOk {
+				t.Fatalf("parseDirective() ok = %v, expected %v", ok, tt.expectOk)
+			}
+			if !ok {
+				return
+			}
+
+			if d.packageName != tt.expectedPackage {
+				t.Errorf("parseDirective() package = %q, expected %q", d.packageName, tt.expectedPackage)
+			}
+			if !reflect.DeepEqual(d.packageNames, tt.expectedPackages) {
+				t.Errorf("parseDirective() packages = %v, expected %v", d.packageNames, tt.expectedPackages)
+			}
+			if len(d.attributes) != len(tt.expectedAttributes) {
+				t.Errorf("parseDirective() attributes = %v, expected %v", d.attributes, tt.expectedAttributes)
+			}
+			for key, value := range tt.expectedAttributes {
+				if d.attributes[key] != value {
+					t.Errorf("parseDirective() attribute %s = %q, expected %q", key, d.attributes[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "1.2", b: "1.2.0", expected: 0},
+		{a: "1.2.0+build.1", b: "1.2.0", expected: 0},
+		{a: "1.2.0", b: "1.10.0", expected: -1},
+		{a: "2.0.0", b: "1.99.99", expected: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0", expected: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", expected: -1},
+		{a: "1.0.0-alpha.beta", b: "1.0.0-alpha.1", expected: 1},
+		{a: "1.0.0-rc.2", b: "1.0.0-rc.10", expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"<>"+tt.b, func(t *testing.T) {
+			if result := compareVersions(tt.a, tt.b); result != tt.expected {
+				t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
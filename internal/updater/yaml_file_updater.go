@@ -1,16 +1,29 @@
 package updater
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 )
 
+// Supported quote styles for updated YAML versions
+const (
+	QuoteStyleDouble = "double" // "1.2.3"
+	QuoteStyleSingle = "single" // '1.2.3'
+	QuoteStyleNone   = "none"   // 1.2.3
+)
+
 type YamlFileUpdater struct {
 	supportedFileExtensions map[string]struct{}
-	commentPattern          *regexp.Regexp
+	comments                commentSyntax
+	quoteStyle              string // Quoting applied to updated versions, empty preserves the existing quotes
+	scheme                  string // Version scheme used for comments without a scheme attribute
+	canonical               bool   // When true, versions with the same semver precedence are equal
+	leadingZeros            bool   // When true, semantic versions may have zero-padded components like 01.2.3
+	prefixAuto              bool   // When true, the prefix of the version in the file is kept for the new version
+	nestedVersions          bool   // When true, annotated mapping keys without a value annotate their first version-like child
+	updateMode              string // Treatment of constraint operators for comments without a mode attribute
 }
 
 func NewYamlFileUpdater() *YamlFileUpdater {
@@ -19,13 +32,16 @@ func NewYamlFileUpdater() *YamlFileUpdater {
 			".yaml": {},
 			".yml":  {},
 		},
-		commentPattern: regexp.MustCompile(`#\s*depup\s+package=([^\s]+)`),
+		comments: newCommentSyntax("#"),
 	}
 }
 
+func (u *YamlFileUpdater) Name() string {
+	return "yaml"
+}
+
 func (u *YamlFileUpdater) Supports(fileExtension string) bool {
-	_, ok := u.supportedFileExtensions[fileExtension]
-	return ok
+	return supportsExtension(u.supportedFileExtensions, fileExtension)
 }
 
 func (u *YamlFileUpdater) GetSupportedExtensions() []string {
@@ -36,200 +52,390 @@ func (u *YamlFileUpdater) GetSupportedExtensions() []string {
 	return extensions
 }
 
-func (u *YamlFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, bool, error) {
+func (u *YamlFileUpdater) UpdateFile(filePath string, packages []Package, options FileUpdaterOptions) (string, []Change, error) {
 	// Read file and prepare data
-	lines, endsWithNewline, err := u.readFileContent(filePath)
+	lines, format, err := readFileLines(filePath)
 	if err != nil {
-		return "", false, err
+		return "", nil, err
 	}
 
-	// Process lines and build output
-	outputContent, updated := u.processLines(lines, packages, endsWithNewline)
+	// Process lines with the quote style and scheme of this run and build output
+	processor := *u
+	processor.quoteStyle = options.QuoteStyle
+	processor.scheme = options.Scheme
+	processor.canonical = options.CanonicalVersions
+	processor.leadingZeros = options.AllowLeadingZeros
+	processor.prefixAuto = options.VersionPrefixAuto
+	processor.nestedVersions = options.NestedVersions
+	processor.updateMode = options.UpdateMode
+	results := processLines(&processor, lines, packages, options.CommentPosition)
+	outputLines, changes, err := collectResults(results, filePath, options)
+	if err != nil {
+		return "", nil, err
+	}
+	outputContent := format.withOptions(options).render(outputLines)
 
-	// Write changes if needed
-	if updated && !options.DryRun {
+	// Write changes if needed, or rewrite annotated files if forced
+	if !options.DryRun && (len(changes) > 0 || options.ForceWrite && hasAnnotatedVersion(results, packages)) {
 		err = os.WriteFile(filePath, []byte(outputContent), 0644)
 		if err != nil {
-			return "", false, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
+			return "", nil, fmt.Errorf("failed to write updated content to %s: %w", filePath, err)
 		}
 	}
 
-	return outputContent, updated, nil
+	return outputContent, changes, nil
 }
 
-// readFileContent reads a file and returns its lines and whether it ends with a newline
-func (u *YamlFileUpdater) readFileContent(filePath string) ([]string, bool, error) {
-	// Read file content first to check for trailing newline
-	fileContent, err := os.ReadFile(filePath)
+// AnalyzeFile describes how each line of the file is interpreted
+func (u *YamlFileUpdater) AnalyzeFile(filePath string, packages []Package) ([]LineAnalysis, error) {
+	lines, _, err := readFileLines(filePath)
 	if err != nil {
-		return nil, false, fmt.Errorf("cannot read file %s: %w", filePath, err)
+		return nil, err
 	}
 
-	// Check if file ends with newline
-	endsWithNewline := len(fileContent) > 0 && (fileContent[len(fileContent)-1] == '\n')
+	return analyzeLines(u, lines, packages, CommentPositionAbove), nil
+}
 
-	// Open file for reading
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, false, fmt.Errorf("cannot open file %s: %w", filePath, err)
+// parseDepupComment returns the directive of a depup comment found in the line
+func (u *YamlFileUpdater) parseDepupComment(line string) (directive, bool) {
+	return u.comments.parse(line)
+}
+
+// depupComment returns a depup comment for the package, written by Annotate
+func (u *YamlFileUpdater) depupComment(packageName string) string {
+	return u.comments.depupComment(packageName)
+}
+
+// depupComments returns the syntax depup comments are recognized by
+func (u *YamlFileUpdater) depupComments() commentSyntax {
+	return u.comments
+}
+
+// setDepupComments replaces the syntax depup comments are recognized by
+func (u *YamlFileUpdater) setDepupComments(comments commentSyntax) {
+	u.comments = comments
+}
+
+// processInlineDepupComment handles the case where a depup comment is on the same line as the version
+func (u *YamlFileUpdater) processInlineDepupComment(line string, packages []Package) lineResult {
+	result := lineResult{line: line}
+
+	lineContent, comment, depupDirective, ok := u.comments.splitInlineComment(line)
+	if !ok {
+		return result
 	}
-	defer file.Close()
 
-	// Create a scanner to read line by line
-	scanner := bufio.NewScanner(file)
+	// Look for the versions in the line content and try to update them
+	updated := u.updateContent(lineContent, depupDirective, packages)
 
-	// Read lines
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	// Reconstruct the line with updated version
+	if updated.changed() {
+		updated.line += comment
+	} else {
+		updated.line = line
 	}
 
-	// Check for scanner errors
-	if err = scanner.Err(); err != nil {
-		return nil, false, fmt.Errorf("error reading file %s: %w", filePath, err)
+	return updated
+}
+
+// processSeparateLineDepupComment handles the case where a depup comment is on its own line above or below the version
+func (u *YamlFileUpdater) processSeparateLineDepupComment(commentLine, currentLine string, packages []Package) lineResult {
+	result := lineResult{line: currentLine}
+
+	depupDirective, ok := u.parseDepupComment(commentLine)
+	if !ok {
+		return result
 	}
 
-	return lines, endsWithNewline, nil
+	// Look for the versions in the current line and try to update them
+	return u.updateContent(currentLine, depupDirective, packages)
 }
 
-// processLines processes all lines and returns the modified content and update status
-func (u *YamlFileUpdater) processLines(lines []string, packages []Package, endsWithNewline bool) (string, bool) {
-	var output strings.Builder
-	updated := false
+// updateContent updates the versions of the directive's packages in the line content
+// A single package within a flow sequence updates the entry of its image, e.g. app:1.0.0 in [lib:2.0.0, app:1.0.0],
+// or the lower bound of a list of constraints, e.g. >=1.2.3 in [">=1.2.3", "<2.0.0"]
+// Quoted constraint expressions like ">= 1.2.3, < 2.0.0" are only handled as constraints outside of range mode.
+func (u *YamlFileUpdater) updateContent(content string, d directive, packages []Package) lineResult {
+	// Anchor and alias names aren't versions, e.g. &tag-1.2.3, so only the anchored value is updated
+	if masked, names := maskAnchors(content); len(names) > 0 {
+		return restorePlaceholders(u.updateContent(masked, d, packages), content, yamlAnchorPlaceholder, names)
+	}
 
-	for i := 0; i < len(lines); i++ {
-		currentLine := lines[i]
-		modifiedLine := currentLine
-		lineUpdated := false
+	mode, ok := lookupUpdateMode(d, u.updateMode)
+	if !ok {
+		return lineResult{line: content, packageName: d.packageName}
+	}
 
-		// Check for inline depup comment
-		if newLine, lineWasUpdated := u.processInlineDepupComment(currentLine, packages); lineWasUpdated {
-			modifiedLine = newLine
-			lineUpdated = true
-		} else if i > 0 {
-			// Check for depup comment in previous line
-			if newLine, lineWasUpdated := u.processPreviousLineDepupComment(lines[i-1], currentLine, packages); lineWasUpdated {
-				modifiedLine = newLine
-				lineUpdated = true
-			}
+	if elements, ok := parseConstraintList(content); ok && len(d.packageNames) == 0 {
+		return u.updateConstraint(content, elements, d, packages)
+	}
+	if mode != UpdateModeRange && len(d.packageNames) == 0 {
+		if elements, ok := parseConstraintExpression(content); ok {
+			return u.updateConstraint(content, elements, d, packages)
 		}
+	}
+	if start, end, ok := flowSequenceEntry(content, d); ok {
+		result := u.updateLine(content[start:end], d, packages)
+		result.line = content[:start] + result.line + content[end:]
+		return result
+	}
 
-		// Add the current line to output
-		output.WriteString(modifiedLine)
+	return updatePackages(content, d, u.scheme, u.leadingZeros, func(content string, d directive) lineResult {
+		return u.updateLine(content, d, packages)
+	})
+}
 
-		// Add newline if not the last line or if the original file had a trailing newline
-		if i < len(lines)-1 || endsWithNewline {
-			output.WriteString("\n")
-		}
+// yamlAnchorPattern matches an anchor or alias like &version or *version, the second group holds the anchor or alias
+// They start a node, so they follow the start of the line, whitespace or a flow indicator.
+var /* const */ yamlAnchorPattern = regexp.MustCompile(`(^|[\s\[{,])([&*][^\s,\[\]{}]+)`)
+
+// yamlAnchorPlaceholder stands in for an anchor or alias while a line is processed
+// It differs from templateTagPlaceholder, as template lines are masked before they are processed as YAML.
+const yamlAnchorPlaceholder = "\x01"
+
+// maskAnchors replaces anchors and aliases with placeholders and returns them in order
+func maskAnchors(content string) (string, []string) {
+	var names []string
+	masked := yamlAnchorPattern.ReplaceAllStringFunc(content, func(match string) string {
+		submatches := yamlAnchorPattern.FindStringSubmatch(match)
+		names = append(names, submatches[2])
+		return submatches[1] + yamlAnchorPlaceholder
+	})
+	return masked, names
+}
 
-		// Update the overall update status
-		updated = updated || lineUpdated
+// updateConstraint updates the lower bound of a constraint and, in range mode, leaves the other elements alone
+// Updates beyond an upper bound are skipped in range mode, and constraints without a lower bound are never changed.
+// Other update modes replace the whole constraint by the updated lower bound.
+func (u *YamlFileUpdater) updateConstraint(content string, elements []constraintElement, d directive, packages []Package) lineResult {
+	mode, _ := lookupUpdateMode(d, u.updateMode)
+	lower, ok := lowerBound(elements)
+	if !ok {
+		return lineResult{line: content, packageName: d.packageName}
 	}
 
-	return output.String(), updated
+	result := u.updateLine(content[lower.start:lower.end], d, packages)
+	if result.change != nil && mode == UpdateModeRange {
+		scheme, _ := lookupScheme(d, u.scheme, u.leadingZeros)
+		if skip := checkUpperBounds(content, elements, d, packages, scheme, result.version); skip != nil {
+			return lineResult{line: content, packageName: d.packageName, version: result.version, skip: skip}
+		}
+	}
+
+	if result.change == nil {
+		result.line = content
+		return result
+	}
+	result.line = applyUpdateMode(content, elements, lower, result.line, mode)
+	return result
 }
 
-// processInlineDepupComment handles the case where a depup comment is on the same line as the version
-func (u *YamlFileUpdater) processInlineDepupComment(line string, packages []Package) (string, bool) {
-	inlineCommentRegex := regexp.MustCompile(`(.*?)(\s*#.*)$`)
-	inlineMatches := inlineCommentRegex.FindStringSubmatch(line)
+// updateLine finds the version of the directive in the line content and updates it
+// The version of the result is empty if no version was found.
+func (u *YamlFileUpdater) updateLine(content string, d directive, packages []Package) lineResult {
+	result := lineResult{line: content, packageName: d.packageName}
 
-	if len(inlineMatches) <= 2 {
-		return line, false
+	scheme, ok := lookupScheme(d, u.scheme, u.leadingZeros)
+	if !ok {
+		return result
+	}
+	match, ok := scheme.find(content)
+	if !ok {
+		return result
 	}
+	result.version = match.version
+	result.matches = countVersions(scheme, content)
 
-	lineContent := inlineMatches[1]
-	comment := inlineMatches[2]
+	// Skip the update if the from= guard doesn't hold or the scheme refuses a downgrade
+	if skip := checkFromGuard(d, packages, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
+	if skip := checkDowngrade(d, packages, scheme, match.version); skip != nil {
+		result.skip = skip
+		return result
+	}
 
-	// Check if it's a depup comment
-	depupMatches := u.commentPattern.FindStringSubmatch(comment)
-	if len(depupMatches) <= 1 {
-		return line, false
+	// Try to update the version
+	updatedContent, change := u.updateVersion(content, d, packages, scheme, match)
+	if change == nil {
+		return result
 	}
 
-	// This is a depup comment
-	packageName := depupMatches[1]
+	result.line = updatedContent
+	result.change = change
+
+	return result
+}
+
+// updateVersion updates the version in a line if the package name of the directive matches
+// With a replace attribute, the whole value containing the version is substituted
+func (u *YamlFileUpdater) updateVersion(line string, d directive, packages []Package, scheme versionScheme, match versionMatch) (string, *Change) {
+	for _, pkg := range packages {
+		if pkg.Name == d.packageName {
+			startQuote := match.startQuote
+			endQuote := match.endQuote
+			currentVersion := match.version
+			targetVersion := scheme.format(currentVersion, packageVersion(pkg, u.prefixAuto))
+
+			if template, ok := d.attributes[replaceAttribute]; ok {
+				updatedLine, changed := replaceMatchedValue(line, match, renderReplaceTemplate(template, targetVersion))
+				if !changed {
+					return line, nil
+				}
+				return updatedLine, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+			}
+
+			// The file keeps its own "v" in front of the version, so the target is written without one
+			targetVersion = trimVPrefix(targetVersion)
+			if versionsEqual(currentVersion, targetVersion, u.canonical) {
+				return line, nil
+			}
+
+			// Normalize the quotes if requested and the version is the whole value
+			if u.quoteStyle != "" && isWholeYamlValue(line, match) {
+				startQuote, endQuote = yamlQuotes(u.quoteStyle)
+			}
+
+			// Replace the entire match, keeping the quotes
+			updatedLine := strings.Replace(line, match.text, startQuote+targetVersion+endQuote, 1)
 
-	// Look for version in the line content
-	versionMatches := versionPattern.FindStringSubmatch(lineContent)
-	if len(versionMatches) <= 3 {
-		return line, false
+			return updatedLine, &Change{Package: pkg.Name, OldVersion: currentVersion, NewVersion: targetVersion}
+		}
 	}
 
-	// Try to update the version
-	updatedContent, updated := u.updateVersion(lineContent, packageName, packages, versionMatches)
-	if !updated {
-		return line, false
+	return line, nil
+}
+
+// isWholeYamlValue reports whether the matched version is the complete scalar value of a mapping or sequence entry
+// Versions embedded in a larger value (e.g. image: nginx:1.2.3) must keep their quoting
+func isWholeYamlValue(line string, match versionMatch) bool {
+	startQuote := strings.TrimSpace(match.startQuote)
+	endQuote := strings.TrimSpace(match.endQuote)
+	if startQuote != endQuote {
+		return false
 	}
 
-	// Reconstruct the line with updated version
-	return updatedContent + comment, true
+	// The value must follow "key:" or "- " and nothing but whitespace may follow it
+	index := strings.Index(line, match.text)
+	before := line[:index]
+	trimmedBefore := strings.TrimRight(before, " \t")
+	after := line[index+len(match.text):]
+
+	return strings.TrimSpace(after) == "" &&
+		len(trimmedBefore) < len(before) &&
+		(strings.HasSuffix(trimmedBefore, ":") || strings.HasSuffix(trimmedBefore, "-"))
 }
 
-// processPreviousLineDepupComment handles the case where a depup comment is on the line before the version
-func (u *YamlFileUpdater) processPreviousLineDepupComment(prevLine, currentLine string, packages []Package) (string, bool) {
-	prevLineMatches := u.commentPattern.FindStringSubmatch(prevLine)
-	if len(prevLineMatches) <= 1 {
-		return currentLine, false
+// flowSequenceEntry returns the range of the entry of a single-line flow sequence whose image repository is the
+// package of the directive, e.g. registry.io/app:1.0.0 in "images: [lib:2.0.0, registry.io/app:1.0.0]" for app.
+// Directives with several packages keep updating the versions in order, so no entry is returned for them.
+func flowSequenceEntry(content string, d directive) (int, int, bool) {
+	open := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if len(d.packageNames) > 0 || open < 0 || end < open || strings.ContainsAny(content[open+1:end], "[]{}") {
+		return 0, 0, false
 	}
 
-	packageName := prevLineMatches[1]
+	start := open + 1
+	for _, entry := range strings.Split(content[start:end], ",") {
+		trimmed := strings.TrimSpace(entry)
+		entryStart := start + strings.Index(entry, trimmed)
+		start += len(entry) + 1
 
-	// Look for version in current line
-	versionMatches := versionPattern.FindStringSubmatch(currentLine)
-	if len(versionMatches) <= 3 {
-		return currentLine, false
+		if imageRepository(strings.Trim(trimmed, `"'`)) == d.packageName {
+			return entryStart, entryStart + len(trimmed), true
+		}
 	}
 
-	// Try to update the version
-	updatedContent, updated := u.updateVersion(currentLine, packageName, packages, versionMatches)
-	if !updated {
-		return currentLine, false
+	return 0, 0, false
+}
+
+// imageRepository returns the last path segment of an image reference without tag and digest, e.g. app for
+// registry.io:5000/team/app:1.0.0@sha256:abc, or an empty string if the reference has no tag
+func imageRepository(reference string) string {
+	reference, _, _ = strings.Cut(reference, "@")
+	name := reference[strings.LastIndex(reference, "/")+1:]
+	repository, _, found := strings.Cut(name, ":")
+	if !found {
+		return ""
 	}
+	return repository
+}
 
-	return updatedContent, true
+// yamlQuotes returns the opening and closing quote for the quote style
+func yamlQuotes(quoteStyle string) (string, string) {
+	switch quoteStyle {
+	case QuoteStyleDouble:
+		return `"`, `"`
+	case QuoteStyleSingle:
+		return "'", "'"
+	default:
+		return "", ""
+	}
 }
 
-// updateVersion updates the version in a line if the package name matches
-func (u *YamlFileUpdater) updateVersion(line, packageName string, packages []Package, versionMatches []string) (string, bool) {
-	for _, pkg := range packages {
-		if pkg.Name == packageName {
-			// assign regex groups to variables
-			startQuote := versionMatches[1]
-			major := versionMatches[2]
-			minor := versionMatches[3]
-			patch := versionMatches[4]
-			prerelease := versionMatches[5]
-			buildmetadata := versionMatches[6]
-			endQuote := versionMatches[7]
-
-			// compose current version
-			currentVersion := major + "." + minor + "." + patch
-			if prerelease != "" {
-				currentVersion += "-" + prerelease
-			}
-			if buildmetadata != "" {
-				currentVersion += "+" + buildmetadata
-			}
+// yamlBlockScalarPattern matches a key or sequence entry starting a literal or folded block scalar, e.g. "script: |"
+// The first capture group holds the indentation of the key, including a leading "- " of a sequence entry
+var /* const */ yamlBlockScalarPattern = regexp.MustCompile(`^(\s*(?:-\s+)?)(?:[^#\s][^#]*?:\s+|-\s+)?[|>][+-]?\d*\s*(?:#.*)?$`)
 
-			cleanCurrent := currentVersion
-			cleanTarget := pkg.Version
+// blockScalarLines returns the number of lines of the block scalar started by the line, 0 if it doesn't start one
+// The block consists of the following lines indented deeper than the key, trailing blank lines excluded
+func (u *YamlFileUpdater) blockScalarLines(lines []string, index int) int {
+	headerMatches := yamlBlockScalarPattern.FindStringSubmatch(lines[index])
+	if headerMatches == nil {
+		return 0
+	}
+	indent := len(headerMatches[1])
 
-			if cleanCurrent == cleanTarget {
-				return line, false
-			}
+	blockLines := 0
+	for i := index + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " \t")) <= indent {
+			break
+		}
+		blockLines = i - index
+	}
+
+	return blockLines
+}
 
-			// Replace using fully normalized target version
-			updatedLine := strings.Replace(
-				line,
-				versionMatches[0], // entire match
-				startQuote+pkg.Version+endQuote,
-				1,
-			)
+// yamlMappingKeyPattern matches a key or sequence entry starting a nested mapping, e.g. "image:" or "- redis:"
+// The first capture group holds the indentation of the key, including a leading "- " of a sequence entry
+var /* const */ yamlMappingKeyPattern = regexp.MustCompile(`^(\s*(?:-\s+)?)[^#\s-][^#]*?:\s*(?:#.*)?$`)
 
-			return updatedLine, true
+// yamlVersionKeyPattern matches a key holding a version, e.g. "version:", "tag:", "appVersion:" or "image_tag:"
+var /* const */ yamlVersionKeyPattern = regexp.MustCompile(`(?i)^\s*(?:-\s+)?["']?[\w.-]*(?:version|tag)["']?\s*:\s*[^\s#]`)
+
+// nestedVersionLine returns the index of the first version-like child of the mapping started by the line,
+// or -1 if nested versions are disabled, the line doesn't start a mapping or the mapping has no such child.
+// The look-ahead is bounded by the mapping, which ends at the first line indented no deeper than its key.
+func (u *YamlFileUpdater) nestedVersionLine(lines []string, index int) int {
+	if !u.nestedVersions {
+		return -1
+	}
+	keyMatches := yamlMappingKeyPattern.FindStringSubmatch(lines[index])
+	if keyMatches == nil {
+		return -1
+	}
+	indent := len(keyMatches[1])
+
+	for i := index + 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " \t")) <= indent {
+			break
+		}
+		if yamlVersionKeyPattern.MatchString(line) {
+			return i
 		}
 	}
 
-	return line, false
+	return -1
 }
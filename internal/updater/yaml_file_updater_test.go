@@ -2,6 +2,8 @@ package updater
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -178,154 +180,1213 @@ func TestYamlFileUpdater_UpdateFile(t *testing.T) {
 			expectUpdated:  false,
 			expectError:    false,
 		},
+		{
+			name:           "Leading whitespace inside double quotes",
+			fileContent:    "# depup package=test-pkg\nversion: \" 1.0.0\"\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=test-pkg\nversion: \" 2.0.0\"\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Trailing whitespace inside single quotes",
+			fileContent:    "# depup package=test-pkg\nversion: '1.0.0  '\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=test-pkg\nversion: '2.0.0  '\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Leading and trailing whitespace inside quotes",
+			fileContent:    "version: \" 1.0.0 \" # depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: \" 2.0.0 \" # depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Colon directive style on previous line",
+			fileContent:    "# depup:package=test-pkg\nversion: 1.0.0\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup:package=test-pkg\nversion: 2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive changes repository and version",
+			fileContent:    "# depup package=app replace=newrepo/app:{version}\nimage: oldrepo/app:1.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=newrepo/app:{version}\nimage: newrepo/app:2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Inline replace directive keeps quotes",
+			fileContent:    "image: \"oldrepo/app:1.0.0\" # depup package=app replace=newrepo/app:{version}\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "image: \"newrepo/app:2.0.0\" # depup package=app replace=newrepo/app:{version}\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive with repository change only",
+			fileContent:    "# depup package=app replace=newrepo/app:{version}\nimage: oldrepo/app:2.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=newrepo/app:{version}\nimage: newrepo/app:2.0.0\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive already applied",
+			fileContent:    "# depup package=app replace=newrepo/app:{version}\nimage: newrepo/app:2.0.0\n",
+			packages:       []Package{{Name: "app", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "# depup package=app replace=newrepo/app:{version}\nimage: newrepo/app:2.0.0\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Replace directive without placeholder is ignored",
+			fileContent:    "image: app:1.0.0 # depup package=app replace=x\n",
+			packages:       []Package{{Name: "app", Version: "1.3.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "image: app:1.0.0 # depup package=app replace=x\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Comment below the version",
+			fileContent:    "version: 1.0.0\n# depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{CommentPosition: CommentPositionBelow},
+			expectedOutput: "version: 2.0.0\n# depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Comment below is ignored by default",
+			fileContent:    "version: 1.0.0\n# depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: 1.0.0\n# depup package=test-pkg\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Comment between versions applies only below",
+			fileContent:    "a: 1.0.0\n# depup package=a\nb: 1.0.0\n# depup package=b\n",
+			packages:       []Package{{Name: "a", Version: "2.0.0"}, {Name: "b", Version: "3.0.0"}},
+			options:        FileUpdaterOptions{CommentPosition: CommentPositionBelow},
+			expectedOutput: "a: 2.0.0\n# depup package=a\nb: 3.0.0\n# depup package=b\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Inline comment takes precedence over comment below",
+			fileContent:    "version: 1.0.0 # depup package=inline\n# depup package=below\n",
+			packages:       []Package{{Name: "inline", Version: "2.0.0"}, {Name: "below", Version: "3.0.0"}},
+			options:        FileUpdaterOptions{CommentPosition: CommentPositionBelow},
+			expectedOutput: "version: 2.0.0 # depup package=inline\n# depup package=below\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Colon directive style inline with spaces",
+			fileContent:    "version: 1.0.0 # depup: package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: 2.0.0 # depup: package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temp file with test content
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			// Create updater
+			updater := NewYamlFileUpdater()
+
+			// Call the method
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+
+			// Check error expectation
+			if (err != nil) != tt.expectError {
+				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+
+			// Check updated flag
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+
+			// Check output content
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			// If not dry run and expected update, check file contents
+			if !tt.options.DryRun && tt.expectUpdated {
+				content, err := os.ReadFile(tempFile)
+				if err != nil {
+					t.Errorf("Failed to read temp file: %v", err)
+					return
+				}
+
+				if string(content) != tt.expectedOutput {
+					t.Errorf("File content = %q, expectedOutput %q", string(content), tt.expectedOutput)
+				}
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_InlineComments(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		packages       []Package
+		options        FileUpdaterOptions
+		expectedOutput string
+		expectUpdated  bool
+		expectError    bool
+	}{
+		{
+			name:           "Basic inline comment",
+			fileContent:    "version: 1.0.0 # depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: 2.0.0 # depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Inline comment with double quotes",
+			fileContent:    "version: \"1.0.0\" # depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: \"2.0.0\" # depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Inline comment with single quotes",
+			fileContent:    "version: '1.0.0' # depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: '2.0.0' # depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+		{
+			name:           "Inline comment with no matching package",
+			fileContent:    "version: 1.0.0 # depup package=test-pkg\n",
+			packages:       []Package{{Name: "other-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "version: 1.0.0 # depup package=test-pkg\n",
+			expectUpdated:  false,
+			expectError:    false,
+		},
+		{
+			name:           "Inline comment with indented line",
+			fileContent:    "  version: 1.0.0 # depup package=test-pkg\n",
+			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
+			options:        FileUpdaterOptions{DryRun: false},
+			expectedOutput: "  version: 2.0.0 # depup package=test-pkg\n",
+			expectUpdated:  true,
+			expectError:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temp file with test content
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			// Create updater
+			updater := NewYamlFileUpdater()
+
+			// Call the method
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+
+			// Check error expectation
+			if (err != nil) != tt.expectError {
+				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+
+			// Check updated flag
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+
+			// Check output content
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			// If not dry run and expected update, check file contents
+			if !tt.options.DryRun && tt.expectUpdated {
+				content, err := os.ReadFile(tempFile)
+				if err != nil {
+					t.Errorf("Failed to read temp file: %v", err)
+					return
+				}
+
+				if string(content) != tt.expectedOutput {
+					t.Errorf("File content = %q, expectedOutput %q", string(content), tt.expectedOutput)
+				}
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_QuoteStyle(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		quoteStyle     string
+		expectedOutput string
+	}{
+		{
+			name:           "Single to double quotes",
+			fileContent:    "# depup package=test-pkg\nversion: '1.0.0'\n",
+			quoteStyle:     QuoteStyleDouble,
+			expectedOutput: "# depup package=test-pkg\nversion: \"2.0.0\"\n",
+		},
+		{
+			name:           "Single to double quotes with inline comment",
+			fileContent:    "version: '1.0.0' # depup package=test-pkg\n",
+			quoteStyle:     QuoteStyleDouble,
+			expectedOutput: "version: \"2.0.0\" # depup package=test-pkg\n",
+		},
+		{
+			name:           "Unquoted to single quotes",
+			fileContent:    "# depup package=test-pkg\nversion: 1.0.0\n",
+			quoteStyle:     QuoteStyleSingle,
+			expectedOutput: "# depup package=test-pkg\nversion: '2.0.0'\n",
+		},
+		{
+			name:           "Double quotes removed",
+			fileContent:    "# depup package=test-pkg\n- \"1.0.0\"\n",
+			quoteStyle:     QuoteStyleNone,
+			expectedOutput: "# depup package=test-pkg\n- 2.0.0\n",
+		},
+		{
+			name:           "Quotes preserved without a style",
+			fileContent:    "# depup package=test-pkg\nversion: '1.0.0'\n",
+			quoteStyle:     "",
+			expectedOutput: "# depup package=test-pkg\nversion: '2.0.0'\n",
+		},
+		{
+			name:           "Embedded version keeps its quotes",
+			fileContent:    "# depup package=test-pkg\nimage: 'nginx:1.0.0'\n",
+			quoteStyle:     QuoteStyleDouble,
+			expectedOutput: "# depup package=test-pkg\nimage: 'nginx:2.0.0'\n",
+		},
+		{
+			name:           "Unchanged line is not normalized",
+			fileContent:    "# depup package=test-pkg\nversion: '2.0.0'\n",
+			quoteStyle:     QuoteStyleDouble,
+			expectedOutput: "# depup package=test-pkg\nversion: '2.0.0'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			updater := NewYamlFileUpdater()
+			output, _, err := updater.UpdateFile(tempFile, []Package{{Name: "test-pkg", Version: "2.0.0"}}, FileUpdaterOptions{QuoteStyle: tt.quoteStyle})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_UpdateModes(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		updateMode     string
+		expectedOutput string
+	}{
+		{
+			name:           "Range updates the lower bound",
+			fileContent:    "version: \">= 1.0.0, < 3.0.0\" # depup package=app\n",
+			updateMode:     UpdateModeRange,
+			expectedOutput: "version: \">= 2.0.0, < 3.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Constraint keeps the operator of the lower bound",
+			fileContent:    "version: \">= 1.0.0, < 3.0.0\" # depup package=app\n",
+			updateMode:     UpdateModeConstraint,
+			expectedOutput: "version: \">= 2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Pin writes the exact version",
+			fileContent:    "version: \">= 1.0.0, < 3.0.0\" # depup package=app\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "version: \"2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Pin a constraint list",
+			fileContent:    "# depup package=app\nversions: [\">=1.0.0\", \"<3.0.0\"]\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "# depup package=app\nversions: [\"2.0.0\"]\n",
+		},
+		{
+			name:           "Pin ignores the upper bound",
+			fileContent:    "version: \">= 1.0.0, < 1.5.0\" # depup package=app\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "version: \"2.0.0\" # depup package=app\n",
+		},
+		{
+			name:           "Mode attribute overrides the default",
+			fileContent:    "version: \">= 1.0.0, < 3.0.0\" # depup package=app mode=pin\n",
+			updateMode:     UpdateModeRange,
+			expectedOutput: "version: \"2.0.0\" # depup package=app mode=pin\n",
+		},
+		{
+			name:           "Unknown mode attribute",
+			fileContent:    "version: \">= 1.0.0, < 3.0.0\" # depup package=app mode=exact\n",
+			expectedOutput: "version: \">= 1.0.0, < 3.0.0\" # depup package=app mode=exact\n",
+		},
+		{
+			name:           "Plain versions are unaffected",
+			fileContent:    "# depup package=app\nimage: app:1.0.0\n",
+			updateMode:     UpdateModePin,
+			expectedOutput: "# depup package=app\nimage: app:2.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true, UpdateMode: tt.updateMode})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_CanonicalVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileContent   string
+		version       string
+		canonical     bool
+		expectUpdated bool
+	}{
+		{
+			name:          "Zero padded partial version is equal",
+			fileContent:   "# depup package=app scheme=partial\nversion: 1.2\n",
+			version:       "1.2.0",
+			canonical:     true,
+			expectUpdated: false,
+		},
+		{
+			name:          "Build metadata is ignored",
+			fileContent:   "# depup package=app\nversion: 1.2.0+build.7\n",
+			version:       "1.2.0",
+			canonical:     true,
+			expectUpdated: false,
+		},
+		{
+			name:          "Build metadata differs without canonical mode",
+			fileContent:   "# depup package=app\nversion: 1.2.0+build.7\n",
+			version:       "1.2.0",
+			canonical:     false,
+			expectUpdated: true,
+		},
+		{
+			name:          "Different versions are still updated",
+			fileContent:   "# depup package=app\nversion: 1.2.0\n",
+			version:       "1.3.0",
+			canonical:     true,
+			expectUpdated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			options := FileUpdaterOptions{CanonicalVersions: tt.canonical}
+			_, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_IntegerScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		expectedOutput string
+		expectedSkip   string
+	}{
+		{
+			name:           "Date stamp",
+			fileContent:    "# depup package=snapshot scheme=integer\nsnapshot: 20231201\n",
+			version:        "20240115",
+			expectedOutput: "# depup package=snapshot scheme=integer\nsnapshot: 20240115\n",
+		},
+		{
+			name:           "Inline comment with quoted value",
+			fileContent:    "build: \"9\" # depup package=snapshot scheme=integer\n",
+			version:        "10",
+			expectedOutput: "build: \"10\" # depup package=snapshot scheme=integer\n",
+		},
+		{
+			name:           "Integer embedded in a tag",
+			fileContent:    "# depup package=snapshot scheme=integer\nimage: registry/app2:20231201\n",
+			version:        "20240115",
+			expectedOutput: "# depup package=snapshot scheme=integer\nimage: registry/app2:20240115\n",
+		},
+		{
+			name:           "Numeric comparison refuses a downgrade",
+			fileContent:    "# depup package=snapshot scheme=integer\nbuild: 100\n",
+			version:        "99",
+			expectedOutput: "# depup package=snapshot scheme=integer\nbuild: 100\n",
+			expectedSkip:   "version 99 is lower than the current version 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			var skipped []Skip
+			options := FileUpdaterOptions{DryRun: true, OnSkip: func(skip Skip) { skipped = append(skipped, skip) }}
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "snapshot", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+
+			reason := ""
+			if len(skipped) > 0 {
+				reason = skipped[0].Reason
+			}
+			if reason != tt.expectedSkip {
+				t.Errorf("UpdateFile() skip reason = %q, expected %q", reason, tt.expectedSkip)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_GomodScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		expectedOutput string
+	}{
+		{
+			name:           "Pseudo-version",
+			fileContent:    "# depup package=net scheme=gomod\nversion: v0.0.0-20210101000000-abcdef123456\n",
+			version:        "0.0.0-20220315120000-fedcba654321",
+			expectedOutput: "# depup package=net scheme=gomod\nversion: v0.0.0-20220315120000-fedcba654321\n",
+		},
+		{
+			name:           "Pseudo-version replaced by a release",
+			fileContent:    "version: \"v1.2.4-0.20210101000000-abcdef123456\" # depup package=net scheme=gomod\n",
+			version:        "1.3.0",
+			expectedOutput: "version: \"v1.3.0\" # depup package=net scheme=gomod\n",
+		},
+		{
+			name:           "Incompatible suffix is kept",
+			fileContent:    "# depup package=net scheme=gomod\nversion: v2.1.0+incompatible\n",
+			version:        "2.2.0",
+			expectedOutput: "# depup package=net scheme=gomod\nversion: v2.2.0+incompatible\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "net", Version: tt.version}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+func TestYamlFileUpdater_BlockScalars(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+		expectUpdated  bool
+	}{
+		{
+			name:           "Literal block with comment above",
+			fileContent:    "steps:\n  # depup package=app\n  - run: |\n      echo installing\n      curl -LO https://example.com/app-1.2.3.tar.gz\n  - run: echo 1.0.0\n",
+			expectedOutput: "steps:\n  # depup package=app\n  - run: |\n      echo installing\n      curl -LO https://example.com/app-2.0.0.tar.gz\n  - run: echo 1.0.0\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Folded block with inline comment",
+			fileContent:    "description: >- # depup package=app\n  Ships app\n\n  version 1.2.3\nother: 1.0.0\n",
+			expectedOutput: "description: >- # depup package=app\n  Ships app\n\n  version 2.0.0\nother: 1.0.0\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Only the first version of the block",
+			fileContent:    "# depup package=app\nscript: |\n  install app 1.2.3\n  install tool 4.5.6\n",
+			expectedOutput: "# depup package=app\nscript: |\n  install app 2.0.0\n  install tool 4.5.6\n",
+			expectUpdated:  true,
+		},
+		{
+			name:           "Versions after the block are not annotated",
+			fileContent:    "# depup package=app\nscript: |\n  echo hello\nversion: 1.2.3\n",
+			expectedOutput: "# depup package=app\nscript: |\n  echo hello\nversion: 1.2.3\n",
+			expectUpdated:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
+				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_MultiplePackages(t *testing.T) {
+	packages := []Package{{Name: "app", Version: "2.0.0"}, {Name: "sidecar", Version: "3.0.0"}}
+
+	tests := []struct {
+		name            string
+		fileContent     string
+		expectedOutput  string
+		expectedChanges []Change
+	}{
+		{
+			name:           "Comma separated packages inline",
+			fileContent:    "image: app:1.0.0 sidecar:1.0.0 # depup package=app,sidecar\n",
+			expectedOutput: "image: app:2.0.0 sidecar:3.0.0 # depup package=app,sidecar\n",
+			expectedChanges: []Change{
+				{Line: 1, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+				{Line: 1, Package: "sidecar", OldVersion: "1.0.0", NewVersion: "3.0.0"},
+			},
+		},
+		{
+			name:           "Repeated package attributes above",
+			fileContent:    "# depup package=app package=sidecar\nimages: [\"app:1.0.0\", \"sidecar:1.5.0\"]\n",
+			expectedOutput: "# depup package=app package=sidecar\nimages: [\"app:2.0.0\", \"sidecar:3.0.0\"]\n",
+			expectedChanges: []Change{
+				{Line: 2, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+				{Line: 2, Package: "sidecar", OldVersion: "1.5.0", NewVersion: "3.0.0"},
+			},
+		},
+		{
+			name:           "Package without a supplied version keeps its version",
+			fileContent:    "# depup package=proxy,sidecar\nimages: proxy:1.0.0 sidecar:1.0.0\n",
+			expectedOutput: "# depup package=proxy,sidecar\nimages: proxy:1.0.0 sidecar:3.0.0\n",
+			expectedChanges: []Change{
+				{Line: 2, Package: "sidecar", OldVersion: "1.0.0", NewVersion: "3.0.0"},
+			},
+		},
+		{
+			name:           "More packages than versions",
+			fileContent:    "image: app:1.0.0 # depup package=app,sidecar\n",
+			expectedOutput: "image: app:2.0.0 # depup package=app,sidecar\n",
+			expectedChanges: []Change{
+				{Line: 1, Package: "app", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, packages, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			for i := range changes {
+				changes[i].File = ""
+			}
+			if !reflect.DeepEqual(changes, tt.expectedChanges) {
+				t.Errorf("UpdateFile() changes = %+v, expected %+v", changes, tt.expectedChanges)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_VersionPrefixAuto(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		prefixAuto     bool
+		expectedOutput string
+	}{
+		{
+			name:           "v prefix",
+			fileContent:    "# depup package=app\nversion: v1.2.3\n",
+			version:        "v1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nversion: v1.2.4\n",
+		},
+		{
+			name:           "Word prefix",
+			fileContent:    "# depup package=app\nversion: release-1.2.3\n",
+			version:        "release-1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nversion: release-1.2.4\n",
+		},
+		{
+			name:           "No prefix in the file",
+			fileContent:    "# depup package=app\nversion: 1.2.3\n",
+			version:        "v1.2.4",
+			prefixAuto:     true,
+			expectedOutput: "# depup package=app\nversion: 1.2.4\n",
+		},
+		{
+			name:           "Prefix in the file is kept without auto detection",
+			fileContent:    "# depup package=app\nversion: release-1.2.3\n",
+			version:        "1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "# depup package=app\nversion: release-1.2.4\n",
+		},
+		{
+			name:           "v in the file with a bare target",
+			fileContent:    "# depup package=app\nimage: app:v1.2.3\n",
+			version:        "1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "# depup package=app\nimage: app:v1.2.4\n",
+		},
+		{
+			name:           "v in the file and the target",
+			fileContent:    "# depup package=app\nversion: \"v1.2.3\"\n",
+			version:        "v1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "# depup package=app\nversion: \"v1.2.4\"\n",
+		},
+		{
+			name:           "Bare file with a v target",
+			fileContent:    "# depup package=app\nversion: 1.2.3\n",
+			version:        "v1.2.4",
+			prefixAuto:     false,
+			expectedOutput: "# depup package=app\nversion: 1.2.4\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			options := FileUpdaterOptions{DryRun: true, VersionPrefixAuto: tt.prefixAuto}
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_FlowSequence(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+		expectChange   bool
+	}{
+		{
+			name:           "Inline comment updates the matching entry only",
+			fileContent:    "images: [lib:2.0.0, app:1.0.0] # depup package=app\n",
+			expectedOutput: "images: [lib:2.0.0, app:3.0.0] # depup package=app\n",
+			expectChange:   true,
+		},
+		{
+			name:           "First entry matches",
+			fileContent:    "images: [app:1.0.0, lib:2.0.0] # depup package=app\n",
+			expectedOutput: "images: [app:3.0.0, lib:2.0.0] # depup package=app\n",
+			expectChange:   true,
+		},
+		{
+			name:           "Comment above and quoted entries with registries",
+			fileContent:    "# depup package=app\nimages: [\"registry.io:5000/lib:2.0.0\", 'registry.io:5000/team/app:1.0.0']\n",
+			expectedOutput: "# depup package=app\nimages: [\"registry.io:5000/lib:2.0.0\", 'registry.io:5000/team/app:3.0.0']\n",
+			expectChange:   true,
+		},
+		{
+			name:           "Sequence entry",
+			fileContent:    "- [lib:2.0.0, app:1.0.0, db:1.0.0] # depup package=app\n",
+			expectedOutput: "- [lib:2.0.0, app:3.0.0, db:1.0.0] # depup package=app\n",
+			expectChange:   true,
+		},
+		{
+			name:           "Repository prefix of another entry doesn't match",
+			fileContent:    "images: [application:2.0.0, app:1.0.0] # depup package=app\n",
+			expectedOutput: "images: [application:2.0.0, app:3.0.0] # depup package=app\n",
+			expectChange:   true,
+		},
+		{
+			name:           "Matching entry already up to date",
+			fileContent:    "images: [lib:2.0.0, app:3.0.0] # depup package=app\n",
+			expectedOutput: "images: [lib:2.0.0, app:3.0.0] # depup package=app\n",
+		},
+		{
+			name:           "No matching entry updates the first version",
+			fileContent:    "versions: [1.0.0, 2.0.0] # depup package=app\n",
+			expectedOutput: "versions: [3.0.0, 2.0.0] # depup package=app\n",
+			expectChange:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "3.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if (len(changes) == 1) != tt.expectChange {
+				t.Errorf("UpdateFile() changes = %+v, expectChange %v", changes, tt.expectChange)
+			}
+		})
+	}
+}
+
+func TestYamlFileUpdater_ConstraintLists(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		version        string
+		expectedOutput string
+		expectedSkip   string
+	}{
+		{
+			name:           "Lower bound of a two-element list",
+			fileContent:    "version: [\">=1.2.3\", \"<2.0.0\"] # depup package=app\n",
+			version:        "1.5.0",
+			expectedOutput: "version: [\">=1.5.0\", \"<2.0.0\"] # depup package=app\n",
+		},
+		{
+			name:           "Lower bound after the upper bound",
+			fileContent:    "# depup package=app\nversion: ['< 2.0.0', '>= 1.2.3']\n",
+			version:        "1.5.0",
+			expectedOutput: "# depup package=app\nversion: ['< 2.0.0', '>= 1.5.0']\n",
+		},
+		{
+			name:           "Version beyond the upper bound is skipped",
+			fileContent:    "version: [\">=1.2.3\", \"<2.0.0\"] # depup package=app\n",
+			version:        "2.0.0",
+			expectedOutput: "version: [\">=1.2.3\", \"<2.0.0\"] # depup package=app\n",
+			expectedSkip:   "version 2.0.0 is outside of the upper bound <2.0.0",
+		},
+		{
+			name:           "Only upper bounds are left alone",
+			fileContent:    "version: [\"!=1.3.0\", \"<2.0.0\"] # depup package=app\n",
+			version:        "1.5.0",
+			expectedOutput: "version: [\"!=1.3.0\", \"<2.0.0\"] # depup package=app\n",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create temp file with test content
 			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
 			if err != nil {
 				t.Fatalf("Failed to create temp file: %v", err)
 			}
 			defer os.Remove(tempFile)
 
-			// Create updater
-			updater := NewYamlFileUpdater()
+			var skipped []Skip
+			options := FileUpdaterOptions{DryRun: true, OnSkip: func(skip Skip) { skipped = append(skipped, skip) }}
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: tt.version}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
 
-			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
 
-			// Check error expectation
-			if (err != nil) != tt.expectError {
-				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
-				return
+			var reasons []string
+			for _, skip := range skipped {
+				reasons = append(reasons, skip.Reason)
+			}
+			if tt.expectedSkip == "" && len(reasons) > 0 || tt.expectedSkip != "" && (len(reasons) != 1 || reasons[0] != tt.expectedSkip) {
+				t.Errorf("UpdateFile() skipped = %q, expectedSkip %q", reasons, tt.expectedSkip)
 			}
+		})
+	}
+}
 
-			// Check updated flag
-			if updated != tt.expectUpdated {
-				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+func TestYamlFileUpdater_SyncCommentVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		options        FileUpdaterOptions
+		expectedOutput string
+	}{
+		{
+			name:           "Comment above",
+			fileContent:    "# depup package=app version=1.0.0\nimage: app:1.0.0\n",
+			options:        FileUpdaterOptions{SyncCommentVersion: true},
+			expectedOutput: "# depup package=app version=2.0.0\nimage: app:2.0.0\n",
+		},
+		{
+			name:           "Inline comment with further attributes",
+			fileContent:    "version: 1.0.0 # depup package=app version=1.0.0 scheme=semver\n",
+			options:        FileUpdaterOptions{SyncCommentVersion: true},
+			expectedOutput: "version: 2.0.0 # depup package=app version=2.0.0 scheme=semver\n",
+		},
+		{
+			name:           "Comment below",
+			fileContent:    "version: 1.0.0\n# depup package=app version=1.0.0\n",
+			options:        FileUpdaterOptions{SyncCommentVersion: true, CommentPosition: CommentPositionBelow},
+			expectedOutput: "version: 2.0.0\n# depup package=app version=2.0.0\n",
+		},
+		{
+			name:           "v of the comment is kept",
+			fileContent:    "# depup package=app version=v1.0.0\nimage: app:v1.0.0\n",
+			options:        FileUpdaterOptions{SyncCommentVersion: true},
+			expectedOutput: "# depup package=app version=v2.0.0\nimage: app:v2.0.0\n",
+		},
+		{
+			name:           "Comment without version attribute",
+			fileContent:    "# depup package=app\nversion: 1.0.0\n",
+			options:        FileUpdaterOptions{SyncCommentVersion: true},
+			expectedOutput: "# depup package=app\nversion: 2.0.0\n",
+		},
+		{
+			name:           "Disabled",
+			fileContent:    "# depup package=app version=1.0.0\nimage: app:1.0.0\n",
+			expectedOutput: "# depup package=app version=1.0.0\nimage: app:2.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			tt.options.DryRun = true
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, tt.options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
 			}
 
-			// Check output content
 			if output != tt.expectedOutput {
 				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
 			}
+			if len(changes) != 1 {
+				t.Errorf("UpdateFile() changes = %+v, expected one change", changes)
+			}
+		})
+	}
+}
 
-			// If not dry run and expected update, check file contents
-			if !tt.options.DryRun && tt.expectUpdated {
-				content, err := os.ReadFile(tempFile)
-				if err != nil {
-					t.Errorf("Failed to read temp file: %v", err)
-					return
-				}
+func TestYamlFileUpdater_NestedVersions(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		disabled       bool
+		expectedOutput string
+		expectedLine   int
+	}{
+		{
+			name:           "Version two levels below the annotated key",
+			fileContent:    "# depup package=app\napp:\n  image:\n    repository: registry.io/app\n    tag: 1.0.0\n  replicas: 2\n",
+			expectedOutput: "# depup package=app\napp:\n  image:\n    repository: registry.io/app\n    tag: 2.0.0\n  replicas: 2\n",
+			expectedLine:   5,
+		},
+		{
+			name:           "Inline comment on the key",
+			fileContent:    "app: # depup package=app\n  chart:\n    # pinned by the platform team\n    version: \"1.0.0\"\n",
+			expectedOutput: "app: # depup package=app\n  chart:\n    # pinned by the platform team\n    version: \"2.0.0\"\n",
+			expectedLine:   4,
+		},
+		{
+			name:           "Sequence entry",
+			fileContent:    "dependencies:\n  # depup package=app\n  - app:\n      settings:\n        appVersion: 1.0.0\n",
+			expectedOutput: "dependencies:\n  # depup package=app\n  - app:\n      settings:\n        appVersion: 2.0.0\n",
+			expectedLine:   5,
+		},
+		{
+			name:           "Version outside of the mapping",
+			fileContent:    "# depup package=app\napp:\n  image:\n    repository: registry.io/app\nversion: 1.0.0\n",
+			expectedOutput: "# depup package=app\napp:\n  image:\n    repository: registry.io/app\nversion: 1.0.0\n",
+		},
+		{
+			name:           "Disabled",
+			fileContent:    "# depup package=app\napp:\n  image:\n    tag: 1.0.0\n",
+			disabled:       true,
+			expectedOutput: "# depup package=app\napp:\n  image:\n    tag: 1.0.0\n",
+		},
+	}
 
-				if string(content) != tt.expectedOutput {
-					t.Errorf("File content = %q, expectedOutput %q", string(content), tt.expectedOutput)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			options := FileUpdaterOptions{DryRun: true, NestedVersions: !tt.disabled}
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if tt.expectedLine == 0 {
+				if len(changes) != 0 {
+					t.Errorf("UpdateFile() changes = %+v, expected none", changes)
 				}
+			} else if len(changes) != 1 || changes[0].Line != tt.expectedLine {
+				t.Errorf("UpdateFile() changes = %+v, expected one change on line %d", changes, tt.expectedLine)
 			}
 		})
 	}
 }
 
-func TestYamlFileUpdater_InlineComments(t *testing.T) {
+func TestYamlFileUpdater_ErrorOnMultipleMatches(t *testing.T) {
+	packages := []Package{{Name: "app", Version: "2.0.0"}, {Name: "lib", Version: "3.0.0"}}
+
 	tests := []struct {
 		name           string
 		fileContent    string
-		packages       []Package
-		options        FileUpdaterOptions
-		expectedOutput string
-		expectUpdated  bool
+		disabled       bool
 		expectError    bool
+		expectedOutput string
 	}{
 		{
-			name:           "Basic inline comment",
-			fileContent:    "version: 1.0.0 # depup package=test-pkg\n",
-			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
-			options:        FileUpdaterOptions{DryRun: false},
-			expectedOutput: "version: 2.0.0 # depup package=test-pkg\n",
-			expectUpdated:  true,
-			expectError:    false,
+			name:        "Ambiguous line",
+			fileContent: "image: app:1.0.0 lib:1.0.0 # depup package=app\n",
+			expectError: true,
 		},
 		{
-			name:           "Inline comment with double quotes",
-			fileContent:    "version: \"1.0.0\" # depup package=test-pkg\n",
-			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
-			options:        FileUpdaterOptions{DryRun: false},
-			expectedOutput: "version: \"2.0.0\" # depup package=test-pkg\n",
-			expectUpdated:  true,
-			expectError:    false,
+			name:           "Ambiguous line without the option",
+			fileContent:    "image: app:1.0.0 lib:1.0.0 # depup package=app\n",
+			disabled:       true,
+			expectedOutput: "image: app:2.0.0 lib:1.0.0 # depup package=app\n",
 		},
 		{
-			name:           "Inline comment with single quotes",
-			fileContent:    "version: '1.0.0' # depup package=test-pkg\n",
-			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
-			options:        FileUpdaterOptions{DryRun: false},
-			expectedOutput: "version: '2.0.0' # depup package=test-pkg\n",
-			expectUpdated:  true,
-			expectError:    false,
+			name:           "Ambiguous line already up to date",
+			fileContent:    "image: app:2.0.0 lib:1.0.0 # depup package=app\n",
+			expectedOutput: "image: app:2.0.0 lib:1.0.0 # depup package=app\n",
 		},
 		{
-			name:           "Inline comment with no matching package",
-			fileContent:    "version: 1.0.0 # depup package=test-pkg\n",
-			packages:       []Package{{Name: "other-pkg", Version: "2.0.0"}},
-			options:        FileUpdaterOptions{DryRun: false},
-			expectedOutput: "version: 1.0.0 # depup package=test-pkg\n",
-			expectUpdated:  false,
-			expectError:    false,
+			name:           "Package named for each version",
+			fileContent:    "image: app:1.0.0 lib:1.0.0 # depup package=app,lib\n",
+			expectedOutput: "image: app:2.0.0 lib:3.0.0 # depup package=app,lib\n",
 		},
 		{
-			name:           "Inline comment with indented line",
-			fileContent:    "  version: 1.0.0 # depup package=test-pkg\n",
-			packages:       []Package{{Name: "test-pkg", Version: "2.0.0"}},
-			options:        FileUpdaterOptions{DryRun: false},
-			expectedOutput: "  version: 2.0.0 # depup package=test-pkg\n",
-			expectUpdated:  true,
-			expectError:    false,
+			name:           "Flow sequence entry of the package",
+			fileContent:    "images: [lib:1.0.0, app:1.0.0] # depup package=app\n",
+			expectedOutput: "images: [lib:1.0.0, app:2.0.0] # depup package=app\n",
+		},
+		{
+			name:           "Single version",
+			fileContent:    "# depup package=app\nimage: app:1.0.0\n",
+			expectedOutput: "# depup package=app\nimage: app:2.0.0\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create temp file with test content
 			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
 			if err != nil {
 				t.Fatalf("Failed to create temp file: %v", err)
 			}
 			defer os.Remove(tempFile)
 
-			// Create updater
-			updater := NewYamlFileUpdater()
-
-			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
-
-			// Check error expectation
-			if (err != nil) != tt.expectError {
-				t.Errorf("UpdateFile() error = %v, expectError %v", err, tt.expectError)
+			options := FileUpdaterOptions{DryRun: true, ErrorOnMultipleMatches: !tt.disabled}
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, packages, options)
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "line 1") || !strings.Contains(err.Error(), "2 versions for package app") {
+					t.Errorf("UpdateFile() error = %v, expected an error about the versions of line 1", err)
+				}
 				return
 			}
-
-			// Check updated flag
-			if updated != tt.expectUpdated {
-				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
 			}
 
-			// Check output content
 			if output != tt.expectedOutput {
 				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
 			}
+		})
+	}
+}
 
-			// If not dry run and expected update, check file contents
-			if !tt.options.DryRun && tt.expectUpdated {
-				content, err := os.ReadFile(tempFile)
-				if err != nil {
-					t.Errorf("Failed to read temp file: %v", err)
-					return
-				}
+func TestYamlFileUpdater_TrailingWhitespace(t *testing.T) {
+	// Trailing whitespace after an updated version must be kept byte-for-byte
+	tests := []struct {
+		name           string
+		fileContent    string
+		options        FileUpdaterOptions
+		expectedOutput string
+	}{
+		{
+			name:           "Spaces after plain version",
+			fileContent:    "# depup package=app\nversion: 1.0.0   \n",
+			expectedOutput: "# depup package=app\nversion: 2.0.0   \n",
+		},
+		{
+			name:           "Tab after quoted version",
+			fileContent:    "# depup package=app\nversion: \"1.0.0\"\t\n",
+			expectedOutput: "# depup package=app\nversion: \"2.0.0\"\t\n",
+		},
+		{
+			name:           "Whitespace before inline comment",
+			fileContent:    "image: app:1.0.0 \t # depup package=app\n",
+			expectedOutput: "image: app:2.0.0 \t # depup package=app\n",
+		},
+		{
+			name:           "Whitespace with changed quote style",
+			fileContent:    "# depup package=app\nversion: 1.0.0  \n",
+			expectedOutput: "# depup package=app\nversion: \"2.0.0\"  \n",
+			options:        FileUpdaterOptions{QuoteStyle: QuoteStyleDouble},
+		},
+		{
+			name:           "Whitespace with replace template",
+			fileContent:    "# depup package=app replace=new/app:{version}\nimage: old/app:1.0.0  \n",
+			expectedOutput: "# depup package=app replace=new/app:{version}\nimage: new/app:2.0.0  \n",
+		},
+		{
+			name:           "Whitespace before CRLF",
+			fileContent:    "# depup package=app\r\nversion: 1.0.0 \t\r\n",
+			expectedOutput: "# depup package=app\r\nversion: 2.0.0 \t\r\n",
+		},
+	}
 
-				if string(content) != tt.expectedOutput {
-					t.Errorf("File content = %q, expectedOutput %q", string(content), tt.expectedOutput)
-				}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			tt.options.DryRun = true
+			output, changes, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, tt.options)
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+			if len(changes) != 1 {
+				t.Errorf("UpdateFile() changes = %+v, expected one change", changes)
 			}
 		})
 	}
@@ -414,7 +1475,7 @@ spec:
 			updater := NewYamlFileUpdater()
 
 			// Call the method
-			output, updated, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
+			output, changes, err := updater.UpdateFile(tempFile, tt.packages, tt.options)
 
 			// Check error expectation
 			if (err != nil) != tt.expectError {
@@ -423,7 +1484,7 @@ spec:
 			}
 
 			// Check updated flag
-			if updated != tt.expectUpdated {
+			if updated := len(changes) > 0; updated != tt.expectUpdated {
 				t.Errorf("UpdateFile() updated = %v, expectUpdated %v", updated, tt.expectUpdated)
 			}
 
@@ -471,3 +1532,56 @@ func TestYamlFileUpdater_Supports(t *testing.T) {
 		})
 	}
 }
+
+func TestYamlFileUpdater_AnchorsAndAliases(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileContent    string
+		expectedOutput string
+	}{
+		{
+			name:           "Anchored version",
+			fileContent:    "# depup package=app\nversion: &app_version 1.2.3\nimage: *app_version\n",
+			expectedOutput: "# depup package=app\nversion: &app_version 2.0.0\nimage: *app_version\n",
+		},
+		{
+			name:           "Anchor name holding a version",
+			fileContent:    "version: &release-1.2.3 1.2.3 # depup package=app\n",
+			expectedOutput: "version: &release-1.2.3 2.0.0 # depup package=app\n",
+		},
+		{
+			name:           "Alias reference is left alone",
+			fileContent:    "version: &release-1.2.3 1.2.3\n# depup package=app\nimage: *release-1.2.3\n",
+			expectedOutput: "version: &release-1.2.3 1.2.3\n# depup package=app\nimage: *release-1.2.3\n",
+		},
+		{
+			name:           "Anchor in a flow sequence",
+			fileContent:    "versions: [*release-1.2.3, &next 1.2.3] # depup package=app\n",
+			expectedOutput: "versions: [*release-1.2.3, &next 2.0.0] # depup package=app\n",
+		},
+		{
+			name:           "Anchored mapping of a sequence entry",
+			fileContent:    "# depup package=app\n- &app image: app:1.2.3\n",
+			expectedOutput: "# depup package=app\n- &app image: app:2.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := createTempFileWithContent(tt.fileContent, ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tempFile)
+
+			output, _, err := NewYamlFileUpdater().UpdateFile(tempFile, []Package{{Name: "app", Version: "2.0.0"}}, FileUpdaterOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+
+			if output != tt.expectedOutput {
+				t.Errorf("UpdateFile() output = %q, expectedOutput %q", output, tt.expectedOutput)
+			}
+		})
+	}
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"github.com/dtomasi/depup/cmd"
 	"log"
+	"os"
 )
 
 func main() {
 	// Execute runs the root command and all its subcommands.
-	// If any error occurs during execution, it will be captured here.
+	// If any error occurs during execution, it will be captured here and mapped to the exit code of the process.
 	if err := cmd.Execute(); err != nil {
-		log.Fatalln(err)
+		log.Println(err)
+		os.Exit(int(cmd.ExitCodeOf(err)))
 	}
 }